@@ -148,7 +148,7 @@ func main() {
 		Name: "[local-service-id]",
 		BaseChannelItem: &spec.ChannelItem{
 			Servers:     []string{"mqtt"},
-			Description: "[local-service-id] is a service-local-id interpreted as a go template where {{.DeviceId}} and {{.ShortDeviceId}} are valid placeholder. if the local-service-id does not contain a placeholder, config.actuator_topic_pattern is used as the template. config.actuator_topic_pattern may contain {{.LocalServiceId}} as placeholder (e.g.: 'something/{{.LocalDeviceId}}/{{.LocalServiceId}}')",
+			Description: "[local-service-id] is a service-local-id interpreted as a go template where {{.DeviceId}}, {{.ShortDeviceId}}, {{.LocalDeviceId}}, {{.DeviceTypeId}}, {{.ShortDeviceTypeId}}, {{.ServiceId}}, {{.ShortServiceId}}, {{.HubId}}, {{.ShortHubId}} and {{.HubLocalId}} are valid placeholder (hub placeholders only for devices in a hub). if the local-service-id does not contain a placeholder, config.actuator_topic_pattern is used as the template. config.actuator_topic_pattern may contain {{.LocalServiceId}} as placeholder (e.g.: 'something/{{.LocalDeviceId}}/{{.LocalServiceId}}')",
 		},
 		Subscribe: &asyncapi.MessageSample{
 			MessageEntity: spec.MessageEntity{
@@ -159,6 +159,21 @@ func main() {
 		},
 	}))
 
+	mustNotFail(reflector.AddChannel(asyncapi.ChannelInfo{
+		Name: "[command-response-topic]",
+		BaseChannelItem: &spec.ChannelItem{
+			Servers:     []string{"mqtt"},
+			Description: "[command-response-topic] is config.command_response_topic_pattern interpreted as a go template with the placeholders of [local-service-id], {{.CommandTopic}} and {{.CorrelationId}} (e.g.: '{{.CommandTopic}}/resp'). only used if the pattern is set.",
+		},
+		Publish: &asyncapi.MessageSample{
+			MessageEntity: spec.MessageEntity{
+				Name:        "DeviceCommandResponse",
+				Title:       "DeviceCommandResponse",
+				Description: "as described by the DeviceType.Service",
+			},
+		},
+	}))
+
 	mustNotFail(reflector.AddChannel(asyncapi.ChannelInfo{
 		Name: "[any-topic-with-valid-device-id]",
 		BaseChannelItem: &spec.ChannelItem{
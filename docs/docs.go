@@ -19,6 +19,148 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/acl": {
+            "post": {
+                "description": "checks if the user may access the device referenced by the topic; acc: 1=read, 2=write, 3=readwrite, 4=subscribe; responds with code=200 if allowed, else with code=403",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "mosquitto-go-auth acl check",
+                "parameters": [
+                    {
+                        "description": "acl infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.AclMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/authn": {
+            "post": {
+                "description": "checks auth; all responses are with code=200, AuthnResponse.Result is allow or deny",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "emqx authentication",
+                "parameters": [
+                    {
+                        "description": "login infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/emqx.AuthnMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/emqx.AuthnResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/authz": {
+            "post": {
+                "description": "checks if the user may access the device referenced by the topic; subscriptions must use the device-id prefix, because emqx can not rewrite topics; all responses are with code=200, AuthzResponse.Result is allow or deny",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "emqx authorization",
+                "parameters": [
+                    {
+                        "description": "authorization infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/emqx.AuthzMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/emqx.AuthzResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliver": {
+            "post": {
+                "description": "maps the topic of a delivered message back to the form the client subscribed with, reversing the device-id prefix added by /subscribe; may be registered for on_deliver and on_deliver_m5; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "on_deliver webhook",
+                "parameters": [
+                    {
+                        "description": "delivered message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.DeliverWebhookMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.OkResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.DeliverResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/disconnect": {
             "post": {
                 "description": "logs user hubs and devices as disconnected; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
@@ -93,6 +235,43 @@ const docTemplate = `{
                 }
             }
         },
+        "/login_m5": {
+            "post": {
+                "description": "checks auth of mqtt v5 clients; the session expiry interval decides if the session is logged as clean; denied logins respond with a v5 reason code; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "auth_on_register_m5 webhook",
+                "parameters": [
+                    {
+                        "description": "login infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.LoginM5WebhookMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.OkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.ErrorM5Response"
+                        }
+                    }
+                }
+            }
+        },
         "/online": {
             "post": {
                 "description": "logs hub as connected; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
@@ -173,6 +352,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/publish_m5": {
+            "post": {
+                "description": "checks auth for the published mqtt v5 message and forwards it with its content type and user properties to kafka; denied messages respond with a v5 reason code; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "auth_on_publish_m5 webhook",
+                "parameters": [
+                    {
+                        "description": "publish message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.PublishM5WebhookMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.OkResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.RedirectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.ErrorM5Response"
+                        }
+                    }
+                }
+            }
+        },
         "/subscribe": {
             "post": {
                 "description": "checks auth for the subscription; SubscriptionResponse.Topics.Qos==128 signals rejected subscription; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
@@ -216,6 +438,80 @@ const docTemplate = `{
                 }
             }
         },
+        "/subscribe_m5": {
+            "post": {
+                "description": "checks auth for the mqtt v5 subscription; SubscribeModifiers.Topics.Qos is the granted qos or, for rejected topics, a v5 reason code (135=not authorized, 143=topic filter invalid), the reasons are joined in SubscribeModifiers.Properties.ReasonString; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "auth_on_subscribe_m5 webhook",
+                "parameters": [
+                    {
+                        "description": "subscription message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.SubscribeM5WebhookMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.SubscribeM5WebhookResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/vernemqtt.ErrorM5Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/superuser": {
+            "post": {
+                "description": "only the connector itself is a superuser; responds with code=200 for superusers, else with code=403",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "mosquitto-go-auth superuser check",
+                "parameters": [
+                    {
+                        "description": "user infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.SuperuserMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/unsubscribe": {
             "post": {
                 "description": "logs device as disconnected; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format",
@@ -252,20 +548,297 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "vernemqtt.DisconnectWebhookMsg": {
-            "type": "object",
-            "properties": {
-                "client_id": {
-                    "type": "string"
-                }
-            }
         },
-        "vernemqtt.EmptyResponse": {
+        "/user": {
+            "post": {
+                "description": "checks auth; responds with code=200 if the user is allowed to connect, else with code=403",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "mosquitto-go-auth user check",
+                "parameters": [
+                    {
+                        "description": "login infos",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.UserMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/mosquitto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "post": {
+                "description": "handles client.connected, client.disconnected, session.subscribed, session.unsubscribed and message.publish events; published messages are forwarded to kafka; all responses are with code=200",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "emqx webhook",
+                "parameters": [
+                    {
+                        "description": "event",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/emqx.WebhookMsg"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/emqx.EmptyResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "emqx.AuthnMsg": {
+            "type": "object",
+            "properties": {
+                "clientid": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "peerhost": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "emqx.AuthnResponse": {
+            "type": "object",
+            "properties": {
+                "is_superuser": {
+                    "type": "boolean"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "allow"
+                }
+            }
+        },
+        "emqx.AuthzMsg": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "publish"
+                },
+                "clientid": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "emqx.AuthzResponse": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "ignored by emqx; explains denied requests",
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string",
+                    "example": "allow"
+                }
+            }
+        },
+        "emqx.EmptyResponse": {
+            "type": "object"
+        },
+        "emqx.WebhookMsg": {
+            "type": "object",
+            "properties": {
+                "clean_start": {
+                    "type": "boolean"
+                },
+                "clientid": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "message.publish"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "qos": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "mosquitto.AclMsg": {
+            "type": "object",
+            "properties": {
+                "acc": {
+                    "type": "integer"
+                },
+                "clientid": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "mosquitto.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "mosquitto.SuperuserMsg": {
+            "type": "object",
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "mosquitto.UserMsg": {
+            "type": "object",
+            "properties": {
+                "clientid": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.DeliverModifiers": {
+            "type": "object",
+            "properties": {
+                "topic": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.DeliverResponse": {
+            "type": "object",
+            "properties": {
+                "modifiers": {
+                    "$ref": "#/definitions/vernemqtt.DeliverModifiers"
+                },
+                "result": {
+                    "type": "string",
+                    "default": "ok",
+                    "example": "ok"
+                }
+            }
+        },
+        "vernemqtt.DeliverWebhookMsg": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.DisconnectWebhookMsg": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.EmptyResponse": {
             "type": "object"
         },
+        "vernemqtt.ErrorM5": {
+            "type": "object",
+            "properties": {
+                "reason_code": {
+                    "type": "string",
+                    "example": "not_authorized"
+                },
+                "reason_string": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.ErrorM5Response": {
+            "type": "object",
+            "properties": {
+                "result": {
+                    "$ref": "#/definitions/vernemqtt.ErrorM5ResponseResult"
+                }
+            }
+        },
+        "vernemqtt.ErrorM5ResponseResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/vernemqtt.ErrorM5"
+                }
+            }
+        },
         "vernemqtt.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -282,6 +855,32 @@ const docTemplate = `{
                 }
             }
         },
+        "vernemqtt.LoginM5WebhookMsg": {
+            "type": "object",
+            "properties": {
+                "clean_start": {
+                    "type": "boolean"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "peer_addr": {
+                    "type": "string"
+                },
+                "peer_port": {
+                    "type": "integer"
+                },
+                "properties": {
+                    "$ref": "#/definitions/vernemqtt.PropertiesM5"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "vernemqtt.LoginWebhookMsg": {
             "type": "object",
             "properties": {
@@ -328,6 +927,52 @@ const docTemplate = `{
                 }
             }
         },
+        "vernemqtt.PropertiesM5": {
+            "type": "object",
+            "properties": {
+                "p_content_type": {
+                    "type": "string"
+                },
+                "p_payload_format_indicator": {
+                    "type": "string"
+                },
+                "p_reason_string": {
+                    "type": "string"
+                },
+                "p_session_expiry_interval": {
+                    "type": "integer"
+                },
+                "p_user_property": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/vernemqtt.UserProperty"
+                    }
+                }
+            }
+        },
+        "vernemqtt.PublishM5WebhookMsg": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "properties": {
+                    "$ref": "#/definitions/vernemqtt.PropertiesM5"
+                },
+                "qos": {
+                    "type": "integer"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "vernemqtt.PublishWebhookMsg": {
             "type": "object",
             "properties": {
@@ -379,6 +1024,53 @@ const docTemplate = `{
                 }
             }
         },
+        "vernemqtt.SubscribeM5WebhookMsg": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "properties": {
+                    "$ref": "#/definitions/vernemqtt.PropertiesM5"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/vernemqtt.WebhookmsgTopic"
+                    }
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "vernemqtt.SubscribeM5WebhookResult": {
+            "type": "object",
+            "properties": {
+                "modifiers": {
+                    "$ref": "#/definitions/vernemqtt.SubscribeModifiers"
+                },
+                "result": {
+                    "type": "string",
+                    "default": "ok",
+                    "example": "ok"
+                }
+            }
+        },
+        "vernemqtt.SubscribeModifiers": {
+            "type": "object",
+            "properties": {
+                "properties": {
+                    "$ref": "#/definitions/vernemqtt.PropertiesM5"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/vernemqtt.WebhookmsgTopic"
+                    }
+                }
+            }
+        },
         "vernemqtt.SubscribeWebhookMsg": {
             "type": "object",
             "properties": {
@@ -443,6 +1135,17 @@ const docTemplate = `{
                 }
             }
         },
+        "vernemqtt.UserProperty": {
+            "type": "object",
+            "properties": {
+                "k": {
+                    "type": "string"
+                },
+                "v": {
+                    "type": "string"
+                }
+            }
+        },
         "vernemqtt.WebhookmsgTopic": {
             "type": "object",
             "properties": {
@@ -0,0 +1,27 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package association stores the topics of services generated from published messages (see topic.GenerateServiceAttr).
+// the topic parser uses them to prevent matches of generated services on topics which only contain the associated topic.
+package association
+
+// Store must be safe for concurrent use
+type Store interface {
+	Get(deviceId string, serviceId string) (topic string, found bool)
+	Set(deviceId string, serviceId string, topic string) error
+	RemoveDevice(deviceId string) error
+	RemoveService(serviceId string) error
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"sync"
+)
+
+type Memory struct {
+	mux     sync.RWMutex
+	devices map[string]map[string]string //device-id -> service-id -> topic
+}
+
+var _ Store = &Memory{}
+
+func NewMemory() *Memory {
+	return &Memory{devices: map[string]map[string]string{}}
+}
+
+func (this *Memory) Get(deviceId string, serviceId string) (topic string, found bool) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	topic, found = this.devices[deviceId][serviceId]
+	return topic, found
+}
+
+func (this *Memory) Set(deviceId string, serviceId string, topic string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	services, ok := this.devices[deviceId]
+	if !ok {
+		services = map[string]string{}
+		this.devices[deviceId] = services
+	}
+	services[serviceId] = topic
+	return nil
+}
+
+func (this *Memory) RemoveDevice(deviceId string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.devices, deviceId)
+	return nil
+}
+
+func (this *Memory) RemoveService(serviceId string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for deviceId, services := range this.devices {
+		delete(services, serviceId)
+		if len(services) == 0 {
+			delete(this.devices, deviceId)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	store := NewMemory()
+	_ = store.Set("d1", "s1", "d1/foo")
+	_ = store.Set("d1", "s2", "d1/bar")
+	_ = store.Set("d2", "s1", "d2/foo")
+
+	if topic, ok := store.Get("d1", "s1"); !ok || topic != "d1/foo" {
+		t.Error(topic, ok)
+	}
+	if _, ok := store.Get("d3", "s1"); ok {
+		t.Error("unexpected association")
+	}
+
+	_ = store.RemoveService("s1")
+	if _, ok := store.Get("d1", "s1"); ok {
+		t.Error("association of removed service")
+	}
+	if _, ok := store.Get("d2", "s1"); ok {
+		t.Error("association of removed service")
+	}
+	if store.hasDevice("d2") {
+		t.Error("device without associations should be removed")
+	}
+
+	_ = store.RemoveDevice("d1")
+	if _, ok := store.Get("d1", "s2"); ok {
+		t.Error("association of removed device")
+	}
+}
+
+func TestMemoryConcurrency(t *testing.T) {
+	store := NewMemory()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			device := "d" + strconv.Itoa(i%4)
+			service := "s" + strconv.Itoa(i)
+			for j := 0; j < 100; j++ {
+				_ = store.Set(device, service, device+"/"+service)
+				store.Get(device, service)
+				_ = store.RemoveService("s" + strconv.Itoa(j%20))
+				_ = store.RemoveDevice("d" + strconv.Itoa(j%4))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (this *Memory) hasDevice(deviceId string) bool {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	_, ok := this.devices[deviceId]
+	return ok
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+var Timeout = 1 * time.Second
+
+// Expiration is the time the associations of a device read from the database are used before they are read again,
+// so associations stored or removed by other instances become visible
+var Expiration = time.Minute
+
+// Postgres writes through to the database and reads the associations of a device through an in-memory mirror.
+// Get never waits for the database: missing devices and devices mirrored longer than Expiration are read again in the background,
+// until then the mirror is used. if the database is not reachable, the mirror is used
+type Postgres struct {
+	db         *sql.DB
+	mux        sync.Mutex
+	devices    map[string]mirroredDevice
+	lastSweep  time.Time
+	loading    map[string]bool //devices read from the database in the background
+	generation uint64          //incremented by writes; background reads started before a write are discarded
+}
+
+// mirroredDevice is replaced instead of modified, so services may be read without lock
+type mirroredDevice struct {
+	services map[string]string //service-id -> topic
+	loaded   time.Time
+}
+
+var _ Store = &Postgres{}
+
+func NewPostgres(conStr string) (result *Postgres, err error) {
+	db, err := sql.Open("postgres", conStr)
+	if err != nil {
+		return result, err
+	}
+	_, err = db.Exec(SqlCreateAssociationTable)
+	if err != nil {
+		return result, err
+	}
+	result = &Postgres{db: db, devices: map[string]mirroredDevice{}, lastSweep: time.Now(), loading: map[string]bool{}}
+	err = result.load()
+	return result, err
+}
+
+// load mirrors all associations
+func (this *Postgres) load() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*Timeout)
+	defer cancel()
+	rows, err := this.db.QueryContext(ctx, SqlSelectAssociations)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	devices := map[string]mirroredDevice{}
+	now := time.Now()
+	for rows.Next() {
+		var device, service, topic string
+		err = rows.Scan(&device, &service, &topic)
+		if err != nil {
+			return err
+		}
+		if _, ok := devices[device]; !ok {
+			devices[device] = mirroredDevice{services: map[string]string{}, loaded: now}
+		}
+		devices[device].services[service] = topic
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.devices = devices
+	return nil
+}
+
+// loadDevice reads the associations of the device from the database
+func (this *Postgres) loadDevice(deviceId string) (services map[string]string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	rows, err := this.db.QueryContext(ctx, SqlSelectAssociationsByDevice, deviceId)
+	if err != nil {
+		return services, err
+	}
+	defer rows.Close()
+	services = map[string]string{}
+	for rows.Next() {
+		var service, topic string
+		err = rows.Scan(&service, &topic)
+		if err != nil {
+			return services, err
+		}
+		services[service] = topic
+	}
+	return services, rows.Err()
+}
+
+// getDevice returns the mirrored associations of the device; missing or expired devices are read from the database in the background
+func (this *Postgres) getDevice(deviceId string) map[string]string {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	device, ok := this.devices[deviceId]
+	if (!ok || time.Since(device.loaded) >= Expiration) && !this.loading[deviceId] {
+		this.loading[deviceId] = true
+		go this.reloadDevice(deviceId, this.generation)
+	}
+	return device.services
+}
+
+// reloadDevice replaces the mirrored associations of the device, if no write happened since generation
+func (this *Postgres) reloadDevice(deviceId string, generation uint64) {
+	services, err := this.loadDevice(deviceId)
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.loading, deviceId)
+	if err != nil {
+		slog.Error("unable to load service topic associations; use mirror", "device_id", deviceId, "error", err)
+		return
+	}
+	if generation != this.generation {
+		return
+	}
+	this.sweep()
+	this.devices[deviceId] = mirroredDevice{services: services, loaded: time.Now()}
+}
+
+// sweep removes expired devices without associations once per Expiration; callers must hold the lock.
+// expired devices with associations are kept, so Get uses them until they are read again
+func (this *Postgres) sweep() {
+	if time.Since(this.lastSweep) < Expiration {
+		return
+	}
+	this.lastSweep = time.Now()
+	maps.DeleteFunc(this.devices, func(_ string, device mirroredDevice) bool {
+		return len(device.services) == 0 && time.Since(device.loaded) >= Expiration
+	})
+}
+
+func (this *Postgres) Get(deviceId string, serviceId string) (topic string, found bool) {
+	topic, found = this.getDevice(deviceId)[serviceId]
+	return topic, found
+}
+
+func (this *Postgres) Set(deviceId string, serviceId string, topic string) error {
+	if current, ok := this.Get(deviceId, serviceId); ok && current == topic {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlUpsertAssociation, deviceId, serviceId, topic)
+	if err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.generation++
+	//unmirrored devices are added as expired, so the other associations are read in the background
+	device := this.devices[deviceId]
+	services := maps.Clone(device.services)
+	if services == nil {
+		services = map[string]string{}
+	}
+	services[serviceId] = topic
+	this.devices[deviceId] = mirroredDevice{services: services, loaded: device.loaded}
+	return nil
+}
+
+// RemoveDevice always queries the database, because other instances may have stored associations of the device
+func (this *Postgres) RemoveDevice(deviceId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlDeleteAssociationsByDevice, deviceId)
+	if err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.generation++
+	delete(this.devices, deviceId)
+	return nil
+}
+
+// RemoveService always queries the database, because other instances may have stored associations of the service
+func (this *Postgres) RemoveService(serviceId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlDeleteAssociationsByService, serviceId)
+	if err != nil {
+		return err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.generation++
+	for deviceId, device := range this.devices {
+		if _, ok := device.services[serviceId]; ok {
+			services := maps.Clone(device.services)
+			delete(services, serviceId)
+			this.devices[deviceId] = mirroredDevice{services: services, loaded: device.loaded}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+const SqlCreateAssociationTable = `CREATE TABLE IF NOT EXISTS ServiceTopicAssociation (
+	Device		VARCHAR(255) NOT NULL,
+	Service		VARCHAR(255) NOT NULL,
+	Topic		VARCHAR(255) NOT NULL,
+	PRIMARY KEY (Device, Service)
+);
+CREATE INDEX IF NOT EXISTS association_service_index ON ServiceTopicAssociation (Service);`
+
+const SqlUpsertAssociation = `INSERT INTO ServiceTopicAssociation(Device, Service, Topic) VALUES ($1, $2, $3) ON CONFLICT (Device, Service) DO UPDATE SET Topic = $3;`
+
+const SqlDeleteAssociationsByDevice = `DELETE FROM ServiceTopicAssociation WHERE Device = $1;`
+
+const SqlDeleteAssociationsByService = `DELETE FROM ServiceTopicAssociation WHERE Service = $1;`
+
+const SqlSelectAssociations = `SELECT Device, Service, Topic FROM ServiceTopicAssociation;`
+
+const SqlSelectAssociationsByDevice = `SELECT Service, Topic FROM ServiceTopicAssociation WHERE Device = $1;`
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+var ErrCommandExpired = errors.New("command expired before it could be published")
+
+// contentTypes maps service serializations to the mqtt 5 content type of commands
+var contentTypes = map[models.Serialization]string{
+	models.JSON:      "application/json",
+	models.XML:       "application/xml",
+	models.PlainText: "text/plain",
+}
+
+// GetCommandMessageTtl parses config.CommandMessageTtl; 0 means commands do not expire
+func GetCommandMessageTtl(config configuration.Config) (time.Duration, error) {
+	if config.CommandMessageTtl == "" || config.CommandMessageTtl == "-" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(config.CommandMessageTtl)
+	if err != nil {
+		return 0, fmt.Errorf("invalid command_message_ttl: %w", err)
+	}
+	return ttl, nil
+}
+
+// CommandProperties returns the mqtt 5 properties of a command:
+//   - ResponseTopic and CorrelationData of the pending command, if command responses are enabled
+//   - ContentType derived from the serialization of the service input sent as "payload"
+//   - MessageExpiry as the rest of ttl since the request time t; ErrCommandExpired if nothing is left. ttl 0 sends no expiry
+//   - user properties with the device id, service id and the protocol segments of the request except "payload"
+func CommandProperties(commandRequest model.ProtocolMsg, pending topic.PendingCommand, ttl time.Duration, t time.Time) (result message.Properties, err error) {
+	if pending.ResponseTopic != "" {
+		result.ResponseTopic = pending.ResponseTopic
+		result.CorrelationData = []byte(pending.CorrelationId)
+	}
+	result.ContentType = commandContentType(commandRequest)
+	if ttl > 0 {
+		remaining := ttl
+		if !t.IsZero() {
+			remaining = ttl - time.Since(t)
+		}
+		if remaining <= 0 {
+			return result, ErrCommandExpired
+		}
+		expiry := uint32(min(math.Ceil(remaining.Seconds()), math.MaxUint32))
+		result.MessageExpiry = &expiry
+	}
+	result.UserProperties = []message.UserProperty{
+		{Key: "device_id", Value: commandRequest.Metadata.Device.Id},
+		{Key: "service_id", Value: commandRequest.Metadata.Service.Id},
+	}
+	segments := []string{}
+	for segment := range commandRequest.Request.Input {
+		if segment != "payload" {
+			segments = append(segments, segment)
+		}
+	}
+	slices.Sort(segments)
+	for _, segment := range segments {
+		result.UserProperties = append(result.UserProperties, message.UserProperty{Key: segment, Value: commandRequest.Request.Input[segment]})
+	}
+	return result, nil
+}
+
+// commandContentType uses the input content of the "payload" protocol segment or, if the protocol is unknown, the first input content
+func commandContentType(commandRequest model.ProtocolMsg) string {
+	segmentId := ""
+	for _, segment := range commandRequest.Metadata.Protocol.ProtocolSegments {
+		if segment.Name == "payload" {
+			segmentId = segment.Id
+		}
+	}
+	for _, content := range commandRequest.Metadata.Service.Inputs {
+		if segmentId == "" || content.ProtocolSegmentId == segmentId {
+			return contentTypes[content.Serialization]
+		}
+	}
+	return ""
+}
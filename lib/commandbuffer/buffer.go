@@ -0,0 +1,377 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package commandbuffer keeps commands to devices without active subscription until the device subscribes again.
+// subscribed devices are known from the connection log, which is wrapped to flush the buffer on subscriptions (see Buffer.ConnectionLog).
+package commandbuffer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topicfilter"
+	"github.com/google/uuid"
+)
+
+var ErrUnknownBuffer = errors.New("unknown command_buffer")
+var ErrMissingSubscriptionDb = errors.New("command_buffer needs subscription_db_con_str to know which devices are subscribed")
+
+// expired commands of devices that never subscribe again are removed every sweepInterval
+const sweepInterval = time.Minute
+
+// failed flushes are retried every flushRetryInterval until the remaining commands are published or expired
+const flushRetryInterval = time.Second
+
+// flushes are recorded in the store for flushLease after their last progress; instances treat expired flushes as ended
+const flushLease = time.Minute
+
+type Command struct {
+	Id       int64           `json:"-"` //position in the store, set by Store.Take
+	DeviceId string          `json:"device_id"`
+	Topic    string          `json:"topic"`
+	Payload  string          `json:"payload"`
+	Options  message.Options `json:"options"`
+	Created  time.Time       `json:"created"`
+	Expires  time.Time       `json:"expires"`
+}
+
+// Store must be safe for concurrent use
+type Store interface {
+	// Push appends the command; the oldest commands of the device beyond maxSize are dropped
+	Push(command Command, maxSize int) error
+	// Take removes and returns the commands of the device accepted by match, in the order they were pushed
+	Take(deviceId string, match func(Command) bool) ([]Command, error)
+	// Restore puts taken commands back at their position, in front of commands pushed after them; maxSize is not applied
+	Restore(commands []Command) error
+	// RemoveExpired drops all commands expired before now and all flushes with lease ended before now
+	RemoveExpired(now time.Time) error
+	// StartFlush records the flush of the device's commands matching filter until the lease ends; an existing flush with the id is extended
+	StartFlush(id string, deviceId string, filter string, until time.Time) error
+	// EndFlush removes the flush recorded by StartFlush
+	EndFlush(id string) error
+	// Pending returns the topics of the device's unexpired commands and the filters of its flushes with lease after now
+	Pending(deviceId string, now time.Time) (topics []string, filters []string, err error)
+}
+
+// Presence is implemented by connectionlog.ConnectionLog
+type Presence interface {
+	DeviceSubscribed(deviceId string) (bool, error)
+}
+
+// Publisher is implemented by lib.Mqtt.PublishWithOptions
+type Publisher func(topic string, msg string, options message.Options) error
+
+type Buffer struct {
+	store      Store
+	presence   Presence
+	size       int
+	ttl        time.Duration
+	flushDelay time.Duration
+	logger     *slog.Logger
+
+	mux     sync.RWMutex
+	publish Publisher
+
+	flushMux sync.Mutex
+	flushes  map[string][]flushState //pending or running flushes of this instance per device
+}
+
+type flushState struct {
+	id     string
+	filter string
+}
+
+// NewFromConfig returns nil if config.CommandBuffer is disabled; the postgres store uses config.SubscriptionDbConStr.
+// expired commands are removed until ctx is done
+func NewFromConfig(ctx context.Context, config configuration.Config, presence Presence) (result *Buffer, err error) {
+	if config.CommandBuffer == "" || config.CommandBuffer == "-" {
+		return nil, nil
+	}
+	if config.SubscriptionDbConStr == "" || config.SubscriptionDbConStr == "-" {
+		return nil, ErrMissingSubscriptionDb
+	}
+	var store Store
+	switch config.CommandBuffer {
+	case "memory":
+		store = NewMemory()
+	case "postgres":
+		store, err = NewPostgres(config.SubscriptionDbConStr)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownBuffer, config.CommandBuffer)
+	}
+	return New(ctx, config, store, presence)
+}
+
+// New uses config.CommandBufferSize, config.CommandBufferTtl and config.CommandBufferFlushDelay
+func New(ctx context.Context, config configuration.Config, store Store, presence Presence) (result *Buffer, err error) {
+	result = &Buffer{
+		store:    store,
+		presence: presence,
+		size:     int(config.CommandBufferSize),
+		logger:   config.GetLogger(),
+		flushes:  map[string][]flushState{},
+	}
+	result.ttl, err = time.ParseDuration(config.CommandBufferTtl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command_buffer_ttl: %w", err)
+	}
+	if config.CommandBufferFlushDelay != "" {
+		result.flushDelay, err = time.ParseDuration(config.CommandBufferFlushDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command_buffer_flush_delay: %w", err)
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				err := store.RemoveExpired(now)
+				if err != nil {
+					result.logger.Error("unable to remove expired buffered commands", "error", err)
+				}
+			}
+		}
+	}()
+	return result, nil
+}
+
+// SetPublisher sets the mqtt client used by Publish and the flushes; commands are buffered until it is set
+func (this *Buffer) SetPublisher(publish Publisher) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.publish = publish
+}
+
+func (this *Buffer) getPublisher() Publisher {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	return this.publish
+}
+
+// Publish publishes the command if the device has an active subscription, otherwise the command is buffered.
+// commands matching a pending flush of the device are buffered too, so they do not overtake the buffered commands.
+// flushes and buffered commands of other instances are read from the store: if the device has buffered commands with the same topic,
+// the command is buffered behind them and flushed, if no flush of any instance takes it.
+// if the subscription state can not be checked, the command is published
+func (this *Buffer) Publish(deviceId string, topic string, msg string, options message.Options) error {
+	subscribed, err := this.presence.DeviceSubscribed(deviceId)
+	if err != nil {
+		this.logger.Warn("unable to check device subscription; publish command", "error", err, "device", deviceId)
+		subscribed = true
+	}
+	now := time.Now()
+	command := Command{
+		DeviceId: deviceId,
+		Topic:    topic,
+		Payload:  msg,
+		Options:  options,
+		Created:  now,
+		Expires:  now.Add(this.ttl),
+	}
+	publish := this.getPublisher()
+	if !subscribed || publish == nil {
+		return this.store.Push(command, this.size)
+	}
+	this.flushMux.Lock()
+	if slices.ContainsFunc(this.flushes[deviceId], func(flush flushState) bool { return commandMatchesFilter(command, flush.filter) }) {
+		defer this.flushMux.Unlock()
+		return this.store.Push(command, this.size)
+	}
+	this.flushMux.Unlock()
+	topics, filters, err := this.store.Pending(deviceId, now)
+	if err != nil {
+		this.logger.Warn("unable to check buffered commands; publish command", "error", err, "device", deviceId)
+		return publish(topic, msg, options)
+	}
+	flushed := slices.ContainsFunc(filters, func(filter string) bool { return commandMatchesFilter(command, filter) })
+	if !flushed && !slices.Contains(topics, topic) {
+		return publish(topic, msg, options)
+	}
+	err = this.store.Push(command, this.size)
+	if err != nil {
+		return err
+	}
+	//a running flush checks for new commands after it ended in the store, so it takes this command
+	if !flushed {
+		this.Flush(deviceId, topic)
+	}
+	return nil
+}
+
+// Flush publishes the buffered commands of the device matching the subscribed topic filter after the flush delay,
+// in the order they were buffered. the filter may be requested with or without the device id prefix of command topics
+func (this *Buffer) Flush(deviceId string, filter string) {
+	if deviceId == "" {
+		return
+	}
+	this.startFlush(deviceId, filter, this.flushDelay)
+}
+
+// startFlush registers the flush in this instance and in the store, so Publish of all instances buffers matching commands
+func (this *Buffer) startFlush(deviceId string, filter string, delay time.Duration) {
+	flush := flushState{id: uuid.NewString(), filter: filter}
+	this.flushMux.Lock()
+	this.flushes[deviceId] = append(this.flushes[deviceId], flush)
+	this.flushMux.Unlock()
+	this.extendFlush(deviceId, flush, delay)
+	time.AfterFunc(delay, func() {
+		this.flush(deviceId, flush)
+	})
+}
+
+// flush publishes until no matching command is left, including commands buffered by Publish meanwhile, and ends the flush registered by Flush.
+// if publishing fails or no publisher is set, the flush is retried after flushRetryInterval
+func (this *Buffer) flush(deviceId string, flush flushState) {
+	publish := this.getPublisher()
+	if publish == nil {
+		this.logger.Warn("unable to flush buffered commands without mqtt client; retry", "device", deviceId)
+		this.retryFlush(deviceId, flush)
+		return
+	}
+	for {
+		this.extendFlush(deviceId, flush, 0)
+		commands, done, err := this.take(deviceId, flush)
+		if err != nil {
+			this.logger.Error("unable to load buffered commands", "error", err, "device", deviceId)
+		}
+		if done {
+			this.endFlush(deviceId, flush, err == nil)
+			return
+		}
+		err = this.publishCommands(publish, commands)
+		if err != nil {
+			this.retryFlush(deviceId, flush)
+			return
+		}
+	}
+}
+
+func (this *Buffer) retryFlush(deviceId string, flush flushState) {
+	this.extendFlush(deviceId, flush, flushRetryInterval)
+	time.AfterFunc(flushRetryInterval, func() {
+		this.flush(deviceId, flush)
+	})
+}
+
+func (this *Buffer) extendFlush(deviceId string, flush flushState, delay time.Duration) {
+	err := this.store.StartFlush(flush.id, deviceId, flush.filter, time.Now().Add(delay+flushLease))
+	if err != nil {
+		this.logger.Error("unable to store flush; commands of other instances may overtake buffered commands", "error", err, "device", deviceId)
+	}
+}
+
+// endFlush removes the flush from the store, after take removed it from this instance.
+// commands buffered by other instances until then expect this flush to publish them, so a new flush is started for them if recheck is set
+func (this *Buffer) endFlush(deviceId string, flush flushState, recheck bool) {
+	err := this.store.EndFlush(flush.id)
+	if err != nil {
+		this.logger.Error("unable to end stored flush", "error", err, "device", deviceId)
+	}
+	if !recheck {
+		return
+	}
+	topics, _, err := this.store.Pending(deviceId, time.Now())
+	if err != nil {
+		this.logger.Error("unable to check buffered commands after flush", "error", err, "device", deviceId)
+		return
+	}
+	if slices.ContainsFunc(topics, func(topic string) bool {
+		return commandMatchesFilter(Command{DeviceId: deviceId, Topic: topic}, flush.filter)
+	}) {
+		this.startFlush(deviceId, flush.filter, 0)
+	}
+}
+
+// take ends the flush in this instance if no command matches or on error; holds flushMux, so Publish can not buffer commands after the last take
+func (this *Buffer) take(deviceId string, flush flushState) (commands []Command, done bool, err error) {
+	this.flushMux.Lock()
+	defer this.flushMux.Unlock()
+	now := time.Now()
+	commands, err = this.store.Take(deviceId, func(command Command) bool {
+		return now.After(command.Expires) || commandMatchesFilter(command, flush.filter)
+	})
+	if err != nil || len(commands) == 0 {
+		this.removeFlush(deviceId, flush)
+		return commands, true, err
+	}
+	return commands, false, nil
+}
+
+// publishCommands skips expired commands; on error the unpublished commands are restored in front of newer commands
+func (this *Buffer) publishCommands(publish Publisher, commands []Command) error {
+	now := time.Now()
+	for i, command := range commands {
+		options, ok := remainingOptions(command, now)
+		if !ok {
+			continue
+		}
+		err := publish(command.Topic, command.Payload, options)
+		if err != nil {
+			this.logger.Error("unable to publish buffered command; buffer remaining commands again", "error", err, "device", command.DeviceId, "topic", command.Topic)
+			if restoreErr := this.store.Restore(commands[i:]); restoreErr != nil {
+				this.logger.Error("unable to buffer commands", "error", restoreErr, "device", command.DeviceId)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// removeFlush expects a locked flushMux
+func (this *Buffer) removeFlush(deviceId string, flush flushState) {
+	flushes := slices.DeleteFunc(this.flushes[deviceId], func(e flushState) bool { return e.id == flush.id })
+	if len(flushes) == 0 {
+		delete(this.flushes, deviceId)
+		return
+	}
+	this.flushes[deviceId] = flushes
+}
+
+func commandMatchesFilter(command Command, filter string) bool {
+	return topicfilter.Matches(filter, command.Topic) || topicfilter.Matches(filter, strings.TrimPrefix(command.Topic, command.DeviceId+"/"))
+}
+
+// remainingOptions reduces the mqtt 5 message expiry by the time the command was buffered; ok is false for expired commands
+func remainingOptions(command Command, now time.Time) (options message.Options, ok bool) {
+	options = command.Options
+	if now.After(command.Expires) {
+		return options, false
+	}
+	if options.Properties.MessageExpiry != nil {
+		buffered := uint32(now.Sub(command.Created) / time.Second)
+		if buffered >= *options.Properties.MessageExpiry {
+			return options, false
+		}
+		expiry := *options.Properties.MessageExpiry - buffered
+		options.Properties.MessageExpiry = &expiry
+	}
+	return options, true
+}
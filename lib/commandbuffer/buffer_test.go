@@ -0,0 +1,297 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+)
+
+type testPresence map[string]bool
+
+func (this testPresence) DeviceSubscribed(deviceId string) (bool, error) {
+	return this[deviceId], nil
+}
+
+type testPublisher struct {
+	mux       sync.Mutex
+	published []string
+	fail      bool
+}
+
+func (this *testPublisher) publish(topic string, msg string, options message.Options) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.fail {
+		return errors.New("test error")
+	}
+	this.published = append(this.published, topic+" "+msg)
+	return nil
+}
+
+func (this *testPublisher) setFail(fail bool) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.fail = fail
+}
+
+func (this *testPublisher) get() []string {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return append([]string{}, this.published...)
+}
+
+func newTestBuffer(t *testing.T, presence Presence) (*Buffer, *testPublisher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	buffer, err := New(ctx, configuration.Config{CommandBufferSize: 10, CommandBufferTtl: "1h"}, NewMemory(), presence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := &testPublisher{}
+	buffer.SetPublisher(publisher.publish)
+	return buffer, publisher
+}
+
+func TestBufferFlush(t *testing.T) {
+	buffer, publisher := newTestBuffer(t, testPresence{"online": true})
+
+	_ = buffer.Publish("online", "online/cmd", "1", message.Options{})
+	_ = buffer.Publish("offline", "offline/cmd/a", "1", message.Options{})
+	_ = buffer.Publish("offline", "offline/cmd/b", "2", message.Options{})
+	_ = buffer.Publish("offline", "offline/cmd/a", "3", message.Options{})
+	if expected := []string{"online/cmd 1"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+
+	buffer.flush("offline", flushState{id: "f1", filter: "cmd/a"})
+	expected := []string{"online/cmd 1", "offline/cmd/a 1", "offline/cmd/a 3"}
+	if !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+
+	buffer.flush("offline", flushState{id: "f2", filter: "offline/cmd/#"})
+	expected = append(expected, "offline/cmd/b 2")
+	if !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestBufferFlushError(t *testing.T) {
+	buffer, publisher := newTestBuffer(t, testPresence{})
+	_ = buffer.Publish("d", "d/a", "1", message.Options{})
+	_ = buffer.Publish("d", "d/a", "2", message.Options{})
+
+	publisher.fail = true
+	buffer.flush("d", flushState{id: "f", filter: "#"})
+	publisher.fail = false
+	buffer.flush("d", flushState{id: "f", filter: "#"})
+	if expected := []string{"d/a 1", "d/a 2"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestBufferFlushOrder(t *testing.T) {
+	presence := testPresence{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := New(ctx, configuration.Config{CommandBufferTtl: "1h", CommandBufferFlushDelay: "50ms"}, NewMemory(), presence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := &testPublisher{}
+	buffer.SetPublisher(publisher.publish)
+	_ = buffer.Publish("d", "d/a", "1", message.Options{})
+
+	//commands published while the flush is pending wait behind the buffered commands, other topics are published
+	presence["d"] = true
+	buffer.Flush("d", "a")
+	_ = buffer.Publish("d", "d/a", "2", message.Options{})
+	_ = buffer.Publish("d", "d/b", "3", message.Options{})
+	if expected := []string{"d/b 3"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if expected := []string{"d/b 3", "d/a 1", "d/a 2"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+
+	//after the flush, commands are published directly
+	_ = buffer.Publish("d", "d/a", "4", message.Options{})
+	if expected := []string{"d/b 3", "d/a 1", "d/a 2", "d/a 4"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestBufferFlushRetry(t *testing.T) {
+	presence := testPresence{}
+	buffer, publisher := newTestBuffer(t, presence)
+	_ = buffer.Publish("d", "d/a", "1", message.Options{})
+	_ = buffer.Publish("d", "d/a", "2", message.Options{})
+
+	publisher.setFail(true)
+	presence["d"] = true
+	buffer.Flush("d", "#")
+	time.Sleep(100 * time.Millisecond)
+
+	//failed commands stay in front of commands published until the retry
+	_ = buffer.Publish("d", "d/a", "3", message.Options{})
+	publisher.setFail(false)
+	time.Sleep(flushRetryInterval + 500*time.Millisecond)
+	if expected := []string{"d/a 1", "d/a 2", "d/a 3"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestBufferFlushWithoutPublisher(t *testing.T) {
+	presence := testPresence{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := New(ctx, configuration.Config{CommandBufferTtl: "1h"}, NewMemory(), presence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = buffer.Publish("d", "d/a", "1", message.Options{})
+
+	//the flush is retried until the mqtt client is set
+	presence["d"] = true
+	buffer.Flush("d", "#")
+	time.Sleep(100 * time.Millisecond)
+	publisher := &testPublisher{}
+	buffer.SetPublisher(publisher.publish)
+	time.Sleep(flushRetryInterval + 500*time.Millisecond)
+	if expected := []string{"d/a 1"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestBufferSharedStore(t *testing.T) {
+	presence := testPresence{}
+	store := NewMemory()
+	newBuffer := func() (*Buffer, *testPublisher) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		buffer, err := New(ctx, configuration.Config{CommandBufferTtl: "1h", CommandBufferFlushDelay: "50ms"}, store, presence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publisher := &testPublisher{}
+		buffer.SetPublisher(publisher.publish)
+		return buffer, publisher
+	}
+	a, publisherA := newBuffer()
+	b, publisherB := newBuffer()
+
+	//commands published by another instance while the flush is pending wait behind the buffered commands
+	_ = a.Publish("d", "d/a", "1", message.Options{})
+	presence["d"] = true
+	a.Flush("d", "a")
+	_ = b.Publish("d", "d/a", "2", message.Options{})
+	_ = b.Publish("d", "d/b", "3", message.Options{})
+	time.Sleep(200 * time.Millisecond)
+	if expected := []string{"d/a 1", "d/a 2"}; !reflect.DeepEqual(publisherA.get(), expected) {
+		t.Error(publisherA.get(), expected)
+	}
+	if expected := []string{"d/b 3"}; !reflect.DeepEqual(publisherB.get(), expected) {
+		t.Error(publisherB.get(), expected)
+	}
+
+	//commands with the topic of buffered commands are flushed behind them, even without running flush
+	presence["d"] = false
+	_ = a.Publish("d", "d/c", "4", message.Options{})
+	presence["d"] = true
+	_ = b.Publish("d", "d/c", "5", message.Options{})
+	time.Sleep(200 * time.Millisecond)
+	if expected := []string{"d/b 3", "d/c 4", "d/c 5"}; !reflect.DeepEqual(publisherB.get(), expected) {
+		t.Error(publisherB.get(), expected)
+	}
+	if topics, filters, _ := store.Pending("d", time.Now()); len(topics) != 0 || len(filters) != 0 {
+		t.Error("flushes should be ended", topics, filters)
+	}
+}
+
+func TestRemainingOptions(t *testing.T) {
+	now := time.Now()
+	expiry := uint32(60)
+	command := Command{
+		Options: message.Options{Qos: 1, Properties: message.Properties{MessageExpiry: &expiry}},
+		Created: now.Add(-20 * time.Second),
+		Expires: now.Add(time.Hour),
+	}
+	options, ok := remainingOptions(command, now)
+	if !ok || *options.Properties.MessageExpiry != 40 || options.Qos != 1 {
+		t.Error(ok, options)
+	}
+	if expiry != 60 {
+		t.Error("stored expiry should not be changed")
+	}
+
+	command.Created = now.Add(-time.Minute)
+	if _, ok = remainingOptions(command, now); ok {
+		t.Error("command with elapsed message expiry should be dropped")
+	}
+
+	command.Created = now
+	command.Expires = now.Add(-time.Second)
+	if _, ok = remainingOptions(command, now); ok {
+		t.Error("command with elapsed buffer ttl should be dropped")
+	}
+}
+
+func TestConnectionLogFlush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buffer, err := New(ctx, configuration.Config{CommandBufferTtl: "1h", CommandBufferFlushDelay: "10ms"}, NewMemory(), testPresence{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := &testPublisher{}
+	buffer.SetPublisher(publisher.publish)
+	_ = buffer.Publish("d", "d/a", "1", message.Options{})
+
+	buffer.ConnectionLog(connectionlog.Void).Subscribe("client", "a", "d")
+	if len(publisher.get()) != 0 {
+		t.Error("flush should be delayed")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if expected := []string{"d/a 1"}; !reflect.DeepEqual(publisher.get(), expected) {
+		t.Error(publisher.get(), expected)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	buffer, err := NewFromConfig(context.Background(), configuration.Config{CommandBuffer: "-"}, testPresence{})
+	if buffer != nil || err != nil {
+		t.Error(buffer, err)
+	}
+	_, err = NewFromConfig(context.Background(), configuration.Config{CommandBuffer: "memory", SubscriptionDbConStr: "-"}, testPresence{})
+	if !errors.Is(err, ErrMissingSubscriptionDb) {
+		t.Error(err)
+	}
+	_, err = NewFromConfig(context.Background(), configuration.Config{CommandBuffer: "foo", SubscriptionDbConStr: "postgres://"}, testPresence{})
+	if !errors.Is(err, ErrUnknownBuffer) {
+		t.Error(err)
+	}
+}
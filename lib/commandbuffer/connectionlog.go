@@ -0,0 +1,49 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+import (
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+)
+
+// ConnectionLog wraps the connection log to flush buffered commands of a device when it subscribes
+// or when a client with persistent session reconnects
+func (this *Buffer) ConnectionLog(log connectionlog.ConnectionLog) connectionlog.ConnectionLog {
+	return &flushingConnectionLog{ConnectionLog: log, buffer: this}
+}
+
+type flushingConnectionLog struct {
+	connectionlog.ConnectionLog
+	buffer *Buffer
+}
+
+func (this *flushingConnectionLog) Subscribe(client string, topic string, deviceId string) {
+	this.ConnectionLog.Subscribe(client, topic, deviceId)
+	this.buffer.Flush(deviceId, topic)
+}
+
+func (this *flushingConnectionLog) Connect(client string) {
+	this.ConnectionLog.Connect(client)
+	subscriptions, err := this.ConnectionLog.Subscriptions(client)
+	if err != nil {
+		this.buffer.logger.Error("unable to load subscriptions to flush buffered commands", "error", err, "client", client)
+		return
+	}
+	for _, subscription := range subscriptions {
+		this.buffer.Flush(subscription.DeviceId, subscription.Topic)
+	}
+}
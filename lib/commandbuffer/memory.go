@@ -0,0 +1,140 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Memory struct {
+	mux      sync.Mutex
+	devices  map[string][]Command
+	flushes  map[string]memoryFlush
+	sequence int64
+}
+
+type memoryFlush struct {
+	deviceId string
+	filter   string
+	until    time.Time
+}
+
+var _ Store = &Memory{}
+
+func NewMemory() *Memory {
+	return &Memory{devices: map[string][]Command{}, flushes: map[string]memoryFlush{}}
+}
+
+func (this *Memory) Push(command Command, maxSize int) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.sequence++
+	command.Id = this.sequence
+	commands := append(this.devices[command.DeviceId], command)
+	if maxSize > 0 && len(commands) > maxSize {
+		commands = slices.Clone(commands[len(commands)-maxSize:])
+	}
+	this.devices[command.DeviceId] = commands
+	return nil
+}
+
+func (this *Memory) Take(deviceId string, match func(Command) bool) (result []Command, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	remaining := []Command{}
+	for _, command := range this.devices[deviceId] {
+		if match(command) {
+			result = append(result, command)
+		} else {
+			remaining = append(remaining, command)
+		}
+	}
+	this.set(deviceId, remaining)
+	return result, nil
+}
+
+func (this *Memory) Restore(commands []Command) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, command := range commands {
+		this.devices[command.DeviceId] = append(this.devices[command.DeviceId], command)
+	}
+	for _, command := range commands {
+		slices.SortStableFunc(this.devices[command.DeviceId], func(a Command, b Command) int {
+			return cmp.Compare(a.Id, b.Id)
+		})
+	}
+	return nil
+}
+
+func (this *Memory) RemoveExpired(now time.Time) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for deviceId, commands := range this.devices {
+		this.set(deviceId, slices.DeleteFunc(commands, func(command Command) bool {
+			return now.After(command.Expires)
+		}))
+	}
+	for id, flush := range this.flushes {
+		if now.After(flush.until) {
+			delete(this.flushes, id)
+		}
+	}
+	return nil
+}
+
+func (this *Memory) StartFlush(id string, deviceId string, filter string, until time.Time) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.flushes[id] = memoryFlush{deviceId: deviceId, filter: filter, until: until}
+	return nil
+}
+
+func (this *Memory) EndFlush(id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.flushes, id)
+	return nil
+}
+
+func (this *Memory) Pending(deviceId string, now time.Time) (topics []string, filters []string, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, command := range this.devices[deviceId] {
+		if !now.After(command.Expires) && !slices.Contains(topics, command.Topic) {
+			topics = append(topics, command.Topic)
+		}
+	}
+	for _, flush := range this.flushes {
+		if flush.deviceId == deviceId && flush.until.After(now) {
+			filters = append(filters, flush.filter)
+		}
+	}
+	return topics, filters, nil
+}
+
+// set removes devices without commands; expects a locked mux
+func (this *Memory) set(deviceId string, commands []Command) {
+	if len(commands) == 0 {
+		delete(this.devices, deviceId)
+		return
+	}
+	this.devices[deviceId] = commands
+}
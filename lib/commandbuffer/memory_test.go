@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	store := NewMemory()
+	now := time.Now()
+	for _, topic := range []string{"d1/a", "d1/b", "d1/c", "d1/a"} {
+		_ = store.Push(Command{DeviceId: "d1", Topic: topic, Payload: topic, Expires: now.Add(time.Hour)}, 3)
+	}
+	_ = store.Push(Command{DeviceId: "d2", Topic: "d2/a", Expires: now.Add(-time.Second)}, 3)
+
+	commands, _ := store.Take("d1", func(command Command) bool {
+		return command.Topic == "d1/a"
+	})
+	if len(commands) != 1 {
+		t.Error("oldest command should be dropped for size limit", commands)
+	}
+	commands, _ = store.Take("d1", func(command Command) bool {
+		return true
+	})
+	if len(commands) != 2 || commands[0].Topic != "d1/b" || commands[1].Topic != "d1/c" {
+		t.Error(commands)
+	}
+	if _, ok := store.devices["d1"]; ok {
+		t.Error("device without commands should be removed")
+	}
+
+	_ = store.RemoveExpired(now)
+	if _, ok := store.devices["d2"]; ok {
+		t.Error("expired command should be removed")
+	}
+}
+
+func TestMemoryRestore(t *testing.T) {
+	store := NewMemory()
+	expires := time.Now().Add(time.Hour)
+	for _, topic := range []string{"d1/a", "d1/b", "d1/c"} {
+		_ = store.Push(Command{DeviceId: "d1", Topic: topic, Expires: expires}, 3)
+	}
+	taken, _ := store.Take("d1", func(command Command) bool {
+		return command.Topic == "d1/b"
+	})
+	_ = store.Push(Command{DeviceId: "d1", Topic: "d1/d", Expires: expires}, 3)
+
+	//restored commands keep their position and are not dropped for the size limit
+	_ = store.Restore(taken)
+	commands, _ := store.Take("d1", func(command Command) bool {
+		return true
+	})
+	topics := []string{}
+	for _, command := range commands {
+		topics = append(topics, command.Topic)
+	}
+	if expected := []string{"d1/a", "d1/b", "d1/c", "d1/d"}; !slices.Equal(topics, expected) {
+		t.Error(topics, expected)
+	}
+}
+
+func TestMemoryPending(t *testing.T) {
+	store := NewMemory()
+	now := time.Now()
+	_ = store.Push(Command{DeviceId: "d1", Topic: "d1/a", Expires: now.Add(time.Hour)}, 0)
+	_ = store.Push(Command{DeviceId: "d1", Topic: "d1/a", Expires: now.Add(time.Hour)}, 0)
+	_ = store.Push(Command{DeviceId: "d1", Topic: "d1/b", Expires: now.Add(-time.Second)}, 0)
+	_ = store.StartFlush("f1", "d1", "a", now.Add(time.Hour))
+	_ = store.StartFlush("f2", "d1", "b", now.Add(-time.Second))
+	_ = store.StartFlush("f3", "d2", "c", now.Add(time.Hour))
+
+	topics, filters, _ := store.Pending("d1", now)
+	if !slices.Equal(topics, []string{"d1/a"}) || !slices.Equal(filters, []string{"a"}) {
+		t.Error(topics, filters)
+	}
+
+	_ = store.EndFlush("f1")
+	_ = store.RemoveExpired(now)
+	if _, ok := store.flushes["f2"]; ok {
+		t.Error("ended flush should be removed")
+	}
+	topics, filters, _ = store.Pending("d1", now)
+	if !slices.Equal(topics, []string{"d1/a"}) || len(filters) != 0 {
+		t.Error(topics, filters)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var Timeout = 1 * time.Second
+
+// Postgres stores the commands in the BufferedCommand table and running flushes in the BufferFlush table;
+// instances sharing the database flush the commands of each other
+type Postgres struct {
+	db *sql.DB
+}
+
+var _ Store = &Postgres{}
+
+func NewPostgres(conStr string) (result *Postgres, err error) {
+	db, err := sql.Open("postgres", conStr)
+	if err != nil {
+		return result, err
+	}
+	_, err = db.Exec(SqlCreateCommandTable)
+	if err != nil {
+		return result, err
+	}
+	return &Postgres{db: db}, nil
+}
+
+func (this *Postgres) Push(command Command, maxSize int) error {
+	value, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, SqlInsertCommand, command.DeviceId, value, command.Expires)
+	if err != nil {
+		return err
+	}
+	if maxSize > 0 {
+		_, err = tx.ExecContext(ctx, SqlLimitDeviceCommands, command.DeviceId, maxSize)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (this *Postgres) Take(deviceId string, match func(Command) bool) (result []Command, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, SqlSelectDeviceCommandsForUpdate, deviceId)
+	if err != nil {
+		return result, err
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		var value []byte
+		err = rows.Scan(&id, &value)
+		if err != nil {
+			rows.Close()
+			return result, err
+		}
+		command := Command{}
+		err = json.Unmarshal(value, &command)
+		if err != nil {
+			rows.Close()
+			return result, err
+		}
+		command.Id = id
+		if match(command) {
+			ids = append(ids, id)
+			result = append(result, command)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	_, err = tx.ExecContext(ctx, SqlDeleteCommands, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Restore inserts the commands with their original id, so they keep their position
+func (this *Postgres) Restore(commands []Command) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, command := range commands {
+		value, err := json.Marshal(command)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, SqlRestoreCommand, command.Id, command.DeviceId, value, command.Expires)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (this *Postgres) RemoveExpired(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlDeleteExpiredCommands, now)
+	if err != nil {
+		return err
+	}
+	_, err = this.db.ExecContext(ctx, SqlDeleteEndedFlushes, now)
+	return err
+}
+
+func (this *Postgres) StartFlush(id string, deviceId string, filter string, until time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlUpsertFlush, id, deviceId, filter, until)
+	return err
+}
+
+func (this *Postgres) EndFlush(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlDeleteFlush, id)
+	return err
+}
+
+func (this *Postgres) Pending(deviceId string, now time.Time) (topics []string, filters []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	topics, err = this.queryStrings(ctx, SqlSelectDeviceCommandTopics, deviceId, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	filters, err = this.queryStrings(ctx, SqlSelectDeviceFlushFilters, deviceId, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return topics, filters, nil
+}
+
+func (this *Postgres) queryStrings(ctx context.Context, query string, args ...any) (result []string, err error) {
+	rows, err := this.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
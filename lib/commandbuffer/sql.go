@@ -0,0 +1,54 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package commandbuffer
+
+const SqlCreateCommandTable = `CREATE TABLE IF NOT EXISTS BufferedCommand (
+	Id			BIGSERIAL PRIMARY KEY,
+	Device		VARCHAR(255) NOT NULL,
+	Command		JSONB NOT NULL,
+	Expires		TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS buffered_command_device_index ON BufferedCommand (Device, Id);
+CREATE TABLE IF NOT EXISTS BufferFlush (
+	Id			VARCHAR(255) PRIMARY KEY,
+	Device		VARCHAR(255) NOT NULL,
+	Filter		TEXT NOT NULL,
+	Until		TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS buffer_flush_device_index ON BufferFlush (Device);`
+
+const SqlInsertCommand = `INSERT INTO BufferedCommand(Device, Command, Expires) VALUES ($1, $2, $3);`
+
+const SqlRestoreCommand = `INSERT INTO BufferedCommand(Id, Device, Command, Expires) VALUES ($1, $2, $3, $4) ON CONFLICT (Id) DO NOTHING;`
+
+const SqlLimitDeviceCommands = `DELETE FROM BufferedCommand WHERE Device = $1 AND Id NOT IN (SELECT Id FROM BufferedCommand WHERE Device = $1 ORDER BY Id DESC LIMIT $2);`
+
+const SqlSelectDeviceCommandsForUpdate = `SELECT Id, Command FROM BufferedCommand WHERE Device = $1 ORDER BY Id FOR UPDATE;`
+
+const SqlDeleteCommands = `DELETE FROM BufferedCommand WHERE Id = any($1);`
+
+const SqlDeleteExpiredCommands = `DELETE FROM BufferedCommand WHERE Expires < $1;`
+
+const SqlDeleteEndedFlushes = `DELETE FROM BufferFlush WHERE Until < $1;`
+
+const SqlUpsertFlush = `INSERT INTO BufferFlush(Id, Device, Filter, Until) VALUES ($1, $2, $3, $4) ON CONFLICT (Id) DO UPDATE SET Until = $4;`
+
+const SqlDeleteFlush = `DELETE FROM BufferFlush WHERE Id = $1;`
+
+const SqlSelectDeviceCommandTopics = `SELECT DISTINCT Command->>'topic' FROM BufferedCommand WHERE Device = $1 AND Expires > $2;`
+
+const SqlSelectDeviceFlushFilters = `SELECT Filter FROM BufferFlush WHERE Device = $1 AND Until > $2;`
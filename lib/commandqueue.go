@@ -0,0 +1,105 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/commandbuffer"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// commandQueueDepth counts the commands of each shard that wait for their worker, including commands that wait for free queue capacity.
+// the metric is exposed by the metrics endpoint started with statistics.Init
+var commandQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "mqtt_connector_command_queue_depth",
+	Help: "number of commands waiting for the command worker of the shard",
+}, []string{"shard"})
+
+type commandQueueValue struct {
+	commandRequest model.ProtocolMsg
+	requestMsg     platform_connector_lib.CommandRequestMsg
+	t              time.Time
+}
+
+// CreateQueuedCommandHandler handles commands with config.CommandWorkerCount workers (see ShardCommandHandler)
+func CreateQueuedCommandHandler(ctx context.Context, config configuration.Config, mqtt Mqtt, topics *topic.Topic, buffer *commandbuffer.Buffer) platform_connector_lib.AsyncCommandHandler {
+	return ShardCommandHandler(ctx, config, CreateCommandHandler(config, mqtt, topics, buffer))
+}
+
+// ShardCommandHandler distributes commands by the hash of the device id over config.CommandWorkerCount workers with one queue each.
+// commands of the same device are handled by the same worker, so they reach the broker in the order they were received.
+// the returned handler blocks while the queue of the shard is full
+func ShardCommandHandler(ctx context.Context, config configuration.Config, handler platform_connector_lib.AsyncCommandHandler) platform_connector_lib.AsyncCommandHandler {
+	workerCount := max(config.CommandWorkerCount, 1)
+	queues := make([]chan commandQueueValue, workerCount)
+	depths := make([]prometheus.Gauge, workerCount)
+	for i := range queues {
+		queue := make(chan commandQueueValue, workerCount)
+		depth := commandQueueDepth.WithLabelValues(strconv.Itoa(i))
+		depth.Set(0)
+		queues[i] = queue
+		depths[i] = depth
+		go func() {
+			for msg := range queue {
+				depth.Dec()
+				err := handler(msg.commandRequest, msg.requestMsg, msg.t)
+				if err != nil {
+					config.GetLogger().Error("unable to handle command", "error", err, "device", msg.commandRequest.Metadata.Device.Id)
+				}
+			}
+		}()
+	}
+	go func() {
+		<-ctx.Done()
+		for _, queue := range queues {
+			close(queue)
+		}
+	}()
+	return func(commandRequest model.ProtocolMsg, requestMsg platform_connector_lib.CommandRequestMsg, t time.Time) (err error) {
+		shard := commandShard(commandRequest.Metadata.Device.Id, len(queues))
+		depths[shard].Inc()
+		defer func() {
+			if r := recover(); r != nil {
+				depths[shard].Dec()
+				err = errors.New(fmt.Sprint(r))
+			}
+		}()
+		queues[shard] <- commandQueueValue{
+			commandRequest: commandRequest,
+			requestMsg:     requestMsg,
+			t:              t,
+		}
+		return err
+	}
+}
+
+func commandShard(deviceId string, shards int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(deviceId))
+	return int(hash.Sum32() % uint32(shards))
+}
@@ -67,12 +67,52 @@ type Config struct {
 	MqttLogLevel   string `json:"mqtt_log_level"`
 	MqttVersion    string `json:"mqtt_version"`
 	MqttAuthMethod string `json:"mqtt_auth_method"` // Whether the MQTT broker uses a username/password or client certificate authetication
+	BrokerFlavour  string `json:"broker_flavour"`   // Which webhooks are served: vernemq (default), mosquitto (mosquitto-go-auth http backend), emqx or embedded (in-process broker, needs build tag "embedded")
+
+	EmbeddedBrokerAddress string `json:"embedded_broker_address"`
+
+	MosquittoLogTopic string `json:"mosquitto_log_topic"` // broker_flavour mosquitto: topic of the broker log (log_dest topic) used to log client connects and disconnects; "-" to disable
+
+	// Shared subscription (e.g. $share/<group>/<filter>) of the connectors mqtt client; received messages are handled like published messages of the webhooks. "" or "-" to disable.
+	// The filter should not match command topics.
+	IngestionSubscription string `json:"ingestion_subscription"`
+
+	// Ingested messages to response topics of pending commands are only handled as command responses if the broker authorizes publishes with the connector
+	// (e.g. broker_flavour mosquitto or emqx), which only allows responses of users with access to the device. Otherwise, they are ignored.
+	IngestionCommandResponses bool `json:"ingestion_command_responses"`
 
 	WebhookPort             string `json:"webhook_port"`
 	HttpCommandConsumerPort string `json:"http_command_consumer_port"`
 
 	ActuatorTopicPattern string `json:"actuator_topic_pattern"`
 
+	// Patterns like "devices/{{.LocalDeviceId}}/{{.LocalServiceId}}" matched exactly against published topics before the heuristics;
+	// placeholders: DeviceId, ShortDeviceId, LocalDeviceId, LocalServiceId, HubId, ShortHubId; "+" and a trailing "#" are mqtt wildcards
+	EventTopicPatterns []string `json:"event_topic_patterns"`
+
+	// Regex rules normalising published, subscribed and unsubscribed topics before they are parsed; applied in order (see TopicRewriteRule)
+	TopicRewriteRules []TopicRewriteRule `json:"topic_rewrite_rules"`
+	// Regex rules applied in order to command topics created from service local ids or actuator_topic_pattern, before the device id prefix is added
+	CommandTopicRewriteRules []TopicRewriteRule `json:"command_topic_rewrite_rules"`
+
+	// Template of the topic devices publish command responses to, e.g. "{{.CommandTopic}}/resp" or "response/{{.CorrelationId}}";
+	// placeholders: CommandTopic, CorrelationId and those of command topics. "" or "-" disables command responses
+	CommandResponseTopicPattern string `json:"command_response_topic_pattern"`
+	// Duration a command waits for its response, e.g. "30s"
+	CommandResponseTimeout string `json:"command_response_timeout"`
+	// Time to live of commands, counted from the command request time, sent as mqtt 5 message expiry interval, e.g. "5m";
+	// commands that expired before they are published fail. "" or "-" sends commands without expiry
+	CommandMessageTtl string `json:"command_message_ttl"`
+
+	// Selection of a device if a topic matches multiple devices: reject (default), hub (device in the hub with the client id as id),
+	// owner (device owned by the user), recent (device with the most recent event) or fan_out (events are forwarded to all devices, subscriptions are rejected).
+	// recent only knows events forwarded by this instance, so multiple instances may select different devices
+	AmbiguityPolicy string `json:"ambiguity_policy"`
+
+	// Id of a device type used as template for device types created from descriptions of unknown homie devices:
+	// device class, attributes and protocol of its services are copied. "" or "-" disables the creation of homie devices
+	HomieDeviceTypeTemplate string `json:"homie_device_type_template"`
+
 	SerializationFallback string `json:"serialization_fallback"`
 
 	Validate                  bool `json:"validate"`
@@ -109,7 +149,19 @@ type Config struct {
 	SubscriptionDbConStr string `json:"subscription_db_con_str"`
 	DeviceLogTopic       string `json:"device_log_topic"`
 
+	// Buffer for commands to devices without active subscription: "" or "-" (disabled), "memory" or "postgres" (subscription_db_con_str).
+	// subscribed devices are known from the connection log, so buffering requires subscription_db_con_str
+	CommandBuffer string `json:"command_buffer"`
+	// Buffered commands per device; the oldest commands are dropped
+	CommandBufferSize int64 `json:"command_buffer_size"`
+	// Duration commands stay buffered, e.g. "1h"
+	CommandBufferTtl string `json:"command_buffer_ttl"`
+	// Wait after a subscription before buffered commands are published, because some brokers report subscriptions before they are active
+	CommandBufferFlushDelay string `json:"command_buffer_flush_delay"`
+
 	DeviceTypeTopic string `json:"device_type_topic"`
+	// Device change events evicting cached hubs and service topic associations of the device; "" or "-" disables the consumer
+	DeviceTopic string `json:"device_topic"`
 
 	NotificationUrl  string `json:"notification_url"`
 	PermissionsV2Url string `json:"permissions_v2_url"`
@@ -139,6 +191,14 @@ type Config struct {
 	MqttDocuMsg   string `json:"mqtt_docu_msg"`
 }
 
+// TopicRewriteRule replaces matches of the regex Pattern with Replacement (regexp.Regexp.ReplaceAllString, e.g. "$1/$2").
+// rules with UserId only apply to topics of clients of this user or, for command topics, to devices owned by this user
+type TopicRewriteRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	UserId      string `json:"user_id,omitempty"`
+}
+
 func LoadConfig() (result Config, err error) {
 	return LoadConfigFlag("config")
 }
@@ -204,7 +264,16 @@ func handleEnvironmentVars(config interface{}) {
 				b, _ := strconv.ParseBool(envValue)
 				configValue.FieldByName(fieldName).SetBool(b)
 			}
-			if configValue.FieldByName(fieldName).Kind() == reflect.Slice {
+			if configValue.FieldByName(fieldName).Kind() == reflect.Slice && configValue.FieldByName(fieldName).Type().Elem().Kind() == reflect.Struct {
+				//slices of structs (e.g. TopicRewriteRules) are expected as json
+				val := reflect.New(configValue.FieldByName(fieldName).Type())
+				err := json.Unmarshal([]byte(envValue), val.Interface())
+				if err != nil {
+					log.Println("ERROR: invalid json in environment variable", envName, err)
+				} else {
+					configValue.FieldByName(fieldName).Set(val.Elem())
+				}
+			} else if configValue.FieldByName(fieldName).Kind() == reflect.Slice {
 				val := []string{}
 				for _, element := range strings.Split(envValue, ",") {
 					val = append(val, strings.TrimSpace(element))
@@ -34,6 +34,17 @@ type ConnectionLog interface {
 	Subscribe(client string, topic string, deviceId string)
 	Unsubscribe(client string, topic string, deviceId string)
 	SetCleanSession(id string, session bool)
+
+	// Subscriptions returns the stored subscriptions of the client with the topics as requested by the client
+	Subscriptions(client string) ([]Subscription, error)
+
+	// DeviceSubscribed checks if an active client holds a subscription of the device
+	DeviceSubscribed(deviceId string) (bool, error)
+}
+
+type Subscription struct {
+	Topic    string
+	DeviceId string
 }
 
 func New(producer kafka.ProducerInterface, conStr string, deviceLogTopic string, connCheckUrl string, httpTimeoutStr string) (result ConnectionLog, err error) {
@@ -161,6 +172,15 @@ func (this *ConnectionLogImpl) Connect(client string) {
 	}
 }
 
+func (this *ConnectionLogImpl) Subscriptions(client string) ([]Subscription, error) {
+	return this.loadClientSubscriptions(client)
+}
+
+func (this *ConnectionLogImpl) DeviceSubscribed(deviceId string) (bool, error) {
+	noSub, err := this.noDeviceSubscriptionStored(deviceId)
+	return !noSub, err
+}
+
 func (this *ConnectionLogImpl) SetCleanSession(client string, clean bool) {
 	err := this.setCleanSession(client, clean)
 	if err != nil {
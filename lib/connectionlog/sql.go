@@ -40,6 +40,8 @@ const SqlDeleteClient = `DELETE FROM ClientDeviceSubscription WHERE Client = $1;
 
 const SqlSelectDeviceByClient = `SELECT DISTINCT Device FROM ClientDeviceSubscription WHERE Client = $1;`
 
+const SqlSelectSubscriptionsByClient = `SELECT Topic, Device FROM ClientDeviceSubscription WHERE Client = $1;`
+
 const SqlCheckDeviceSubscriptionExists = `SELECT COUNT(1) FROM ClientDeviceSubscription WHERE Device = $1 AND Inactive = FALSE LIMIT 1;`
 
 const SqlAnyDeviceSubscription = `SELECT DISTINCT Device FROM ClientDeviceSubscription WHERE Device = any($1) AND Inactive = FALSE LIMIT $2`
@@ -73,6 +73,25 @@ func (this *ConnectionLogImpl) loadSubscriptions(client string) (devices []strin
 	return devices, err
 }
 
+func (this *ConnectionLogImpl) loadClientSubscriptions(client string) (subscriptions []Subscription, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	resp, err := this.db.QueryContext(ctx, SqlSelectSubscriptionsByClient, client)
+	if err != nil {
+		return subscriptions, err
+	}
+	defer resp.Close()
+	for resp.Next() {
+		subscription := Subscription{}
+		err = resp.Scan(&subscription.Topic, &subscription.DeviceId)
+		if err != nil {
+			return subscriptions, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, resp.Err()
+}
+
 func (this *ConnectionLogImpl) noDeviceSubscriptionStored(device string) (result bool, err error) {
 	ctx, _ := context.WithTimeout(context.Background(), Timeout)
 	row := this.db.QueryRowContext(ctx, SqlCheckDeviceSubscriptionExists, device)
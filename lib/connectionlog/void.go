@@ -39,3 +39,12 @@ func (this *VoidType) Connect(client string) {
 func (this *VoidType) SetCleanSession(id string, session bool) {
 	return
 }
+
+func (this *VoidType) Subscriptions(client string) ([]Subscription, error) {
+	return nil, nil
+}
+
+// DeviceSubscribed reports every device as subscribed because Void does not track subscriptions
+func (this *VoidType) DeviceSubscribed(deviceId string) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package devicerepo is a minimal client for the device-repository endpoints not provided by the platform-connector-lib
+package devicerepo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Client struct {
+	url        string
+	client     *http.Client
+	expiration time.Duration
+	mux        sync.Mutex
+	hubs       map[string]cachedHub //token + hub id -> hub
+	deviceHubs map[string]cachedHub //token + device id -> hub of the device
+}
+
+type cachedHub struct {
+	hub     models.Hub
+	err     error
+	expires time.Time
+}
+
+// maxCachedHubs limits the cache size; expired entries are removed if the limit is reached
+const maxCachedHubs = 10000
+
+// New creates a client caching hubs for the expiration; expiration <= 0 disables the cache
+func New(deviceRepoUrl string, expiration time.Duration) *Client {
+	return &Client{url: deviceRepoUrl, client: &http.Client{Timeout: 10 * time.Second}, expiration: expiration, hubs: map[string]cachedHub{}, deviceHubs: map[string]cachedHub{}}
+}
+
+// GetHub returns the hub if it is readable with the token; ErrNotFound if the hub does not exist or is not accessible
+func (this *Client) GetHub(token security.JwtToken, id string) (hub models.Hub, err error) {
+	return this.cached(this.hubs, string(token)+"/"+id, func() (models.Hub, error) {
+		return this.getHub(token, id)
+	})
+}
+
+// GetHubOfDevice returns the hub containing the device, if it is readable with the token; ErrNotFound if the device is in no accessible hub
+func (this *Client) GetHubOfDevice(token security.JwtToken, deviceId string, localDeviceId string) (hub models.Hub, err error) {
+	return this.cached(this.deviceHubs, string(token)+"/"+deviceId, func() (models.Hub, error) {
+		return this.getHubOfDevice(token, deviceId, localDeviceId)
+	})
+}
+
+// cached uses the cache entry of the key or stores the result of load, including ErrNotFound, for the expiration
+func (this *Client) cached(cache map[string]cachedHub, key string, load func() (models.Hub, error)) (hub models.Hub, err error) {
+	if this.expiration <= 0 {
+		return load()
+	}
+	this.mux.Lock()
+	cached, ok := cache[key]
+	this.mux.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.hub, cached.err
+	}
+	hub, err = load()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return hub, err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if len(cache) >= maxCachedHubs {
+		now := time.Now()
+		for k, v := range cache {
+			if now.After(v.expires) {
+				delete(cache, k)
+			}
+		}
+	}
+	if len(cache) < maxCachedHubs {
+		cache[key] = cachedHub{hub: hub, err: err, expires: time.Now().Add(this.expiration)}
+	}
+	return hub, err
+}
+
+// InvalidateDevice removes cached hubs containing the device and the cached hub of the device
+func (this *Client) InvalidateDevice(deviceId string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for key, cached := range this.hubs {
+		if slices.Contains(cached.hub.DeviceIds, deviceId) {
+			delete(this.hubs, key)
+		}
+	}
+	for key := range this.deviceHubs {
+		if strings.HasSuffix(key, "/"+deviceId) {
+			delete(this.deviceHubs, key)
+		}
+	}
+}
+
+func (this *Client) getHub(token security.JwtToken, id string) (hub models.Hub, err error) {
+	req, err := http.NewRequest(http.MethodGet, this.url+"/hubs/"+url.PathEscape(id), nil)
+	if err != nil {
+		return hub, err
+	}
+	req.Header.Set("Authorization", string(token))
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return hub, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return hub, ErrNotFound
+	case resp.StatusCode >= 300:
+		return hub, fmt.Errorf("unexpected device-repository response: %v", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&hub)
+	return hub, err
+}
+
+// GetDevice reads the device without cache; returns security.ErrorNotFound or security.ErrorAccessDenied like the iot cache of the platform-connector-lib
+func (this *Client) GetDevice(token security.JwtToken, id string) (device models.Device, err error) {
+	err = this.get(token, "/devices/"+url.PathEscape(id), &device)
+	return device, err
+}
+
+// GetDeviceType reads the device-type without cache; returns security.ErrorNotFound or security.ErrorAccessDenied like the iot cache of the platform-connector-lib
+func (this *Client) GetDeviceType(token security.JwtToken, id string) (deviceType models.DeviceType, err error) {
+	err = this.get(token, "/device-types/"+url.PathEscape(id), &deviceType)
+	return deviceType, err
+}
+
+func (this *Client) get(token security.JwtToken, path string, result any) error {
+	req, err := http.NewRequest(http.MethodGet, this.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", string(token))
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return security.ErrorNotFound
+	case resp.StatusCode == http.StatusForbidden:
+		return security.ErrorAccessDenied
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected device-repository response: %v", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// hubPageSize is the limit of hub list requests
+const hubPageSize = 1000
+
+// getHubOfDevice lists the hubs with the local device id and returns the first one containing the device id
+func (this *Client) getHubOfDevice(token security.JwtToken, deviceId string, localDeviceId string) (hub models.Hub, err error) {
+	if localDeviceId == "" {
+		return hub, ErrNotFound
+	}
+	for offset := 0; ; offset += hubPageSize {
+		query := url.Values{}
+		query.Set("local-device-id", localDeviceId)
+		query.Set("limit", strconv.Itoa(hubPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		req, err := http.NewRequest(http.MethodGet, this.url+"/v2/hubs?"+query.Encode(), nil)
+		if err != nil {
+			return hub, err
+		}
+		req.Header.Set("Authorization", string(token))
+		page, err := this.listHubs(req)
+		if err != nil {
+			return hub, err
+		}
+		for _, candidate := range page {
+			if slices.Contains(candidate.DeviceIds, deviceId) {
+				return candidate, nil
+			}
+		}
+		if len(page) < hubPageSize {
+			return hub, ErrNotFound
+		}
+	}
+}
+
+func (this *Client) listHubs(req *http.Request) (result []models.Hub, err error) {
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return result, fmt.Errorf("unexpected device-repository response: %v", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// deviceTypePageSize is the limit of device-type list requests
+const deviceTypePageSize = 1000
+
+// ListDeviceTypesByProtocol returns all device-types readable with the token, which have a service using the protocol
+func (this *Client) ListDeviceTypesByProtocol(token security.JwtToken, protocolId string) (result []models.DeviceType, err error) {
+	for offset := 0; ; offset += deviceTypePageSize {
+		query := url.Values{}
+		query.Set("protocol-ids", protocolId)
+		query.Set("limit", strconv.Itoa(deviceTypePageSize))
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("sort", "name.asc")
+		req, err := http.NewRequest(http.MethodGet, this.url+"/v3/device-types?"+query.Encode(), nil)
+		if err != nil {
+			return result, err
+		}
+		req.Header.Set("Authorization", string(token))
+		page, err := this.listDeviceTypes(req)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, page...)
+		if len(page) < deviceTypePageSize {
+			return result, nil
+		}
+	}
+}
+
+func (this *Client) listDeviceTypes(req *http.Request) (result []models.DeviceType, err error) {
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return result, fmt.Errorf("unexpected device-repository response: %v", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
@@ -0,0 +1,320 @@
+//go:build embedded
+
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package embedded
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+type Broker struct {
+	server         *mqtt.Server
+	inline         *mqtt.Client //publishes packets with properties, like the inline client of server.Publish
+	mux            sync.Mutex
+	subscriptionId int
+}
+
+// Start runs the broker on config.EmbeddedBrokerAddress until ctx is done.
+// published messages are delivered in-process through the inline client of the broker.
+func Start(ctx context.Context, config configuration.Config, platform hooks.Hooks) (*Broker, error) {
+	server := mqtt.New(&mqtt.Options{
+		InlineClient: true,
+		Logger:       config.GetLogger().With("snrgy-log-type", "embedded-broker"),
+	})
+	err := server.AddHook(&platformHook{platform: platform, logger: config.GetLogger(), subscriptions: map[string]hooks.SubscribeDecision{}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = server.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: config.EmbeddedBrokerAddress}))
+	if err != nil {
+		return nil, err
+	}
+	err = server.Serve()
+	if err != nil {
+		return nil, err
+	}
+	config.GetLogger().Info("embedded broker started", "addr", config.EmbeddedBrokerAddress)
+	go func() {
+		<-ctx.Done()
+		config.GetLogger().Info("embedded broker shutdown", "result", server.Close())
+	}()
+	return &Broker{server: server, inline: server.NewClient(nil, mqtt.LocalListener, mqtt.InlineClientId, true)}, nil
+}
+
+func (this *Broker) Publish(topic, msg string) (err error) {
+	slog.Debug("embedded broker publish", "topic", topic, "msg", msg)
+	return this.server.Publish(topic, []byte(msg), false, 2)
+}
+
+func (this *Broker) PublishWithOptions(topic, msg string, options message.Options) (err error) {
+	slog.Debug("embedded broker publish", "topic", topic, "msg", msg, "qos", options.Qos, "retain", options.Retain, "properties", options.Properties)
+	properties := options.Properties
+	pk := packets.Packet{
+		FixedHeader: packets.FixedHeader{Type: packets.Publish, Qos: options.Qos, Retain: options.Retain},
+		TopicName:   topic,
+		Payload:     []byte(msg),
+		PacketID:    uint16(options.Qos), //like server.Publish
+		Properties: packets.Properties{
+			ContentType:     properties.ContentType,
+			ResponseTopic:   properties.ResponseTopic,
+			CorrelationData: properties.CorrelationData,
+		},
+	}
+	if properties.MessageExpiry != nil {
+		pk.Properties.MessageExpiryInterval = *properties.MessageExpiry
+	}
+	for _, property := range properties.UserProperties {
+		pk.Properties.User = append(pk.Properties.User, packets.UserProperty{Key: property.Key, Val: property.Value})
+	}
+	return this.server.InjectPacket(this.inline, pk)
+}
+
+func (this *Broker) PublishRetained(topic, msg string) (err error) {
+	slog.Debug("embedded broker publish", "topic", topic, "msg", msg)
+	return this.server.Publish(topic, []byte(msg), true, 2)
+}
+
+// Subscribe uses the inline client; inline subscriptions receive messages after the OnPublish rewrite
+func (this *Broker) Subscribe(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) (err error) {
+	this.mux.Lock()
+	this.subscriptionId++
+	id := this.subscriptionId
+	this.mux.Unlock()
+	return this.server.Subscribe(topic, id, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		handler(pk.TopicName, pk.Payload, pk.FixedHeader.Qos)
+	})
+}
+
+type platformHook struct {
+	mqtt.HookBase
+	platform hooks.Hooks
+	logger   *slog.Logger
+
+	mux           sync.Mutex
+	subscriptions map[string]hooks.SubscribeDecision //decisions of OnSubscribe by client id, consumed by OnSubscribed
+}
+
+func (this *platformHook) ID() string {
+	return "senergy-platform"
+}
+
+func (this *platformHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnConnectAuthenticate,
+		mqtt.OnACLCheck,
+		mqtt.OnSessionEstablished,
+		mqtt.OnDisconnect,
+		mqtt.OnPublish,
+		mqtt.OnSubscribe,
+		mqtt.OnSubscribed,
+		mqtt.OnUnsubscribe,
+		mqtt.OnPacketEncode,
+	}, []byte{b})
+}
+
+func (this *platformHook) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	if cl.Net.Inline {
+		return true
+	}
+	clean := pk.Connect.Clean
+	decision, err := this.platform.Authenticate(hooks.AuthRequest{
+		Username:     string(pk.Connect.Username),
+		Password:     string(pk.Connect.Password),
+		ClientId:     cl.ID,
+		PeerAddr:     cl.Net.Remote,
+		CleanSession: &clean,
+	})
+	if err != nil {
+		this.logger.Error("unable to authenticate", "error", err, "username", string(pk.Connect.Username), "clientId", cl.ID)
+	}
+	return decision.Verdict == hooks.Allow
+}
+
+// OnACLCheck allows every publish, because OnPublish decides about it.
+// subscriptions are checked after OnSubscribe has rewritten their filters.
+func (this *platformHook) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	if cl.Net.Inline || write {
+		return true
+	}
+	decision, err := this.platform.AuthorizeSubscribe(hooks.SubscribeRequest{
+		Username: string(cl.Properties.Username),
+		ClientId: cl.ID,
+		Topics:   []hooks.TopicRequest{{Topic: topic}},
+	})
+	if err != nil {
+		this.logger.Error("unable to check subscription", "error", err, "clientId", cl.ID, "topic", topic)
+		return false
+	}
+	return len(decision.Topics) == 1 && decision.Topics[0].Verdict == hooks.Allow
+}
+
+func (this *platformHook) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	if cl.Net.Inline {
+		return
+	}
+	this.platform.ClientOnline(hooks.OnlineRequest{ClientId: cl.ID})
+}
+
+func (this *platformHook) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	if cl.Net.Inline {
+		return
+	}
+	this.logger.Info("disconnect", "action", "disconnect", "clientId", cl.ID, "reason", err)
+	this.platform.ClientGone(cl.ID)
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.subscriptions, cl.ID)
+}
+
+// receivedProperties returns the content type and user properties forwarded with published messages
+func receivedProperties(properties packets.Properties) (result message.Properties) {
+	result.ContentType = properties.ContentType
+	for _, property := range properties.User {
+		result.UserProperties = append(result.UserProperties, message.UserProperty{Key: property.Key, Value: property.Val})
+	}
+	return result
+}
+
+func (this *platformHook) OnPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	if cl.Net.Inline {
+		return pk, nil
+	}
+	decision, err := this.platform.HandlePublish(hooks.PublishRequest{
+		Username:   string(cl.Properties.Username),
+		ClientId:   cl.ID,
+		Topic:      pk.TopicName,
+		Payload:    pk.Payload,
+		Qos:        int(pk.FixedHeader.Qos),
+		Properties: receivedProperties(pk.Properties),
+	})
+	if err != nil {
+		this.logger.Error("unable to handle publish", "error", err, "clientId", cl.ID, "topic", pk.TopicName)
+		return pk, rejectPublish(cl, pk, hooks.ReasonUnspecifiedError, err.Error())
+	}
+	switch decision.Verdict {
+	case hooks.Deny:
+		return pk, rejectPublish(cl, pk, decision.ReasonCode, decision.Reason)
+	case hooks.Ignore:
+		pk.TopicName = "ignored/" + pk.TopicName
+	default:
+		pk.TopicName = decision.Topic
+	}
+	return pk, nil
+}
+
+// rejectPublish returns the reason code for the puback of mqtt 5 clients; other publishes are acknowledged but not delivered to subscribers,
+// because mochi does not acknowledge rejected packets and the client would wait for the ack
+func rejectPublish(cl *mqtt.Client, pk packets.Packet, code hooks.ReasonCode, reason string) error {
+	if cl.Properties.ProtocolVersion == 5 && pk.FixedHeader.Qos > 0 {
+		if code == hooks.ReasonSuccess {
+			code = hooks.ReasonNotAuthorized
+		}
+		return packets.Code{Code: byte(code), Reason: reason}
+	}
+	return packets.CodeSuccessIgnore
+}
+
+// OnPacketEncode delivers messages on the topic the client subscribed with (see hooks.Hooks.Deliver)
+func (this *platformHook) OnPacketEncode(cl *mqtt.Client, pk packets.Packet) packets.Packet {
+	if cl.Net.Inline || pk.FixedHeader.Type != packets.Publish || pk.TopicName == "" {
+		return pk
+	}
+	decision, err := this.platform.Deliver(hooks.DeliverRequest{
+		Username: string(cl.Properties.Username),
+		ClientId: cl.ID,
+		Topic:    pk.TopicName,
+	})
+	if err != nil {
+		this.logger.Error("unable to map delivered topic", "error", err, "clientId", cl.ID, "topic", pk.TopicName)
+		return pk
+	}
+	pk.TopicName = decision.Topic
+	return pk
+}
+
+func (this *platformHook) OnSubscribe(cl *mqtt.Client, pk packets.Packet) packets.Packet {
+	if cl.Net.Inline {
+		return pk
+	}
+	req := hooks.SubscribeRequest{Username: string(cl.Properties.Username), ClientId: cl.ID}
+	for _, filter := range pk.Filters {
+		req.Topics = append(req.Topics, hooks.TopicRequest{Topic: filter.Filter, Qos: int(filter.Qos)})
+	}
+	decision, err := this.platform.AuthorizeSubscribe(req)
+	if err != nil {
+		this.logger.Error("unable to check subscription", "error", err, "clientId", cl.ID)
+		return pk
+	}
+	for i, t := range decision.Topics {
+		if t.Verdict == hooks.Allow && i < len(pk.Filters) {
+			pk.Filters[i].Filter = t.Topic
+		}
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.subscriptions[cl.ID] = decision
+	return pk
+}
+
+func (this *platformHook) OnSubscribed(cl *mqtt.Client, pk packets.Packet, reasonCodes []byte) {
+	this.mux.Lock()
+	decision, ok := this.subscriptions[cl.ID]
+	delete(this.subscriptions, cl.ID)
+	this.mux.Unlock()
+	if ok {
+		this.platform.Subscribed(cl.ID, decision)
+	}
+}
+
+// OnUnsubscribe applies the same rewrite as OnSubscribe, so that the prefixed subscriptions are removed
+func (this *platformHook) OnUnsubscribe(cl *mqtt.Client, pk packets.Packet) packets.Packet {
+	if cl.Net.Inline {
+		return pk
+	}
+	username := string(cl.Properties.Username)
+	req := hooks.SubscribeRequest{Username: username, ClientId: cl.ID}
+	topics := []string{}
+	for _, filter := range pk.Filters {
+		req.Topics = append(req.Topics, hooks.TopicRequest{Topic: filter.Filter})
+		topics = append(topics, filter.Filter)
+	}
+	err := this.platform.Unsubscribe(hooks.UnsubscribeRequest{Username: username, ClientId: cl.ID, Topics: topics})
+	if err != nil {
+		this.logger.Error("unable to handle unsubscribe", "error", err, "clientId", cl.ID)
+	}
+	decision, err := this.platform.AuthorizeSubscribe(req)
+	if err != nil {
+		return pk
+	}
+	for i, t := range decision.Topics {
+		if t.Verdict == hooks.Allow && i < len(pk.Filters) {
+			pk.Filters[i].Filter = t.Topic
+		}
+	}
+	return pk
+}
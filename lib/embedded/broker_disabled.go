@@ -0,0 +1,49 @@
+//go:build !embedded
+
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package embedded
+
+import (
+	"context"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+)
+
+type Broker struct{}
+
+func Start(ctx context.Context, config configuration.Config, platform hooks.Hooks) (*Broker, error) {
+	return nil, ErrNotCompiled
+}
+
+func (this *Broker) Publish(topic, msg string) (err error) {
+	return ErrNotCompiled
+}
+
+func (this *Broker) PublishWithOptions(topic, msg string, options message.Options) (err error) {
+	return ErrNotCompiled
+}
+
+func (this *Broker) PublishRetained(topic, msg string) (err error) {
+	return ErrNotCompiled
+}
+
+func (this *Broker) Subscribe(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) (err error) {
+	return ErrNotCompiled
+}
@@ -0,0 +1,229 @@
+//go:build embedded
+
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package embedded
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	paho4 "github.com/eclipse/paho.mqtt.golang"
+)
+
+// testHooks allows the user "user", prefixes subscriptions with "device/" and forwards publishes to "events/..."
+type testHooks struct {
+	hooks.Hooks
+	mux    sync.Mutex
+	events []string
+}
+
+func (this *testHooks) log(event string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.events = append(this.events, event)
+}
+
+func (this *testHooks) get() []string {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return append([]string{}, this.events...)
+}
+
+func (this *testHooks) Authenticate(req hooks.AuthRequest) (hooks.AuthDecision, error) {
+	if req.Username == "user" && req.Password == "pw" {
+		return hooks.AuthDecision{Verdict: hooks.Allow}, nil
+	}
+	return hooks.AuthDecision{Verdict: hooks.Deny, ReasonCode: hooks.ReasonBadUsernameOrPassword}, nil
+}
+
+func (this *testHooks) HandlePublish(req hooks.PublishRequest) (hooks.PublishDecision, error) {
+	this.log("publish " + req.Topic + " " + string(req.Payload))
+	if strings.HasPrefix(req.Topic, "denied") {
+		return hooks.PublishDecision{Verdict: hooks.Deny}, nil
+	}
+	return hooks.PublishDecision{Verdict: hooks.Allow, Topic: "events/" + req.Topic, Forwarded: true}, nil
+}
+
+func (this *testHooks) AuthorizeSubscribe(req hooks.SubscribeRequest) (result hooks.SubscribeDecision, err error) {
+	for _, t := range req.Topics {
+		decision := hooks.TopicDecision{Verdict: hooks.Allow, RequestedTopic: t.Topic, Topic: t.Topic, Qos: t.Qos}
+		if !strings.HasPrefix(t.Topic, "device/") {
+			decision.Topic = "device/" + t.Topic
+		}
+		if strings.Contains(t.Topic, "denied") {
+			decision.Verdict = hooks.Deny
+		}
+		result.Topics = append(result.Topics, decision)
+	}
+	return result, nil
+}
+
+func (this *testHooks) Subscribed(clientId string, decision hooks.SubscribeDecision) {
+	for _, t := range decision.Topics {
+		if t.Verdict == hooks.Allow {
+			this.log("subscribed " + t.RequestedTopic)
+		}
+	}
+}
+
+func (this *testHooks) Deliver(req hooks.DeliverRequest) (hooks.DeliverDecision, error) {
+	return hooks.DeliverDecision{Topic: strings.TrimPrefix(req.Topic, "device/")}, nil
+}
+
+func (this *testHooks) Unsubscribe(req hooks.UnsubscribeRequest) error {
+	return nil
+}
+
+func (this *testHooks) ClientOnline(req hooks.OnlineRequest) {
+	this.log("online " + req.ClientId)
+}
+
+func (this *testHooks) ClientGone(clientId string) {
+	this.log("gone " + clientId)
+}
+
+func TestBroker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	platform := &testHooks{}
+	broker, err := Start(ctx, configuration.Config{EmbeddedBrokerAddress: address}, platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	connect := func(username string, password string) (paho4.Client, error) {
+		client := paho4.NewClient(paho4.NewClientOptions().AddBroker("tcp://" + address).SetClientID("client-" + username).SetUsername(username).SetPassword(password))
+		token := client.Connect()
+		token.Wait()
+		return client, token.Error()
+	}
+
+	t.Run("deny login", func(t *testing.T) {
+		_, err := connect("user", "wrong")
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	client, err := connect("user", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan string, 10)
+	t.Run("subscribe", func(t *testing.T) {
+		token := client.Subscribe("cmd", 1, func(client paho4.Client, msg paho4.Message) {
+			received <- msg.Topic() + " " + string(msg.Payload())
+		})
+		token.Wait()
+		if token.Error() != nil {
+			t.Error(token.Error())
+		}
+		token = client.Subscribe("denied", 1, func(client paho4.Client, msg paho4.Message) {
+			received <- "denied " + msg.Topic()
+		})
+		token.Wait()
+	})
+
+	t.Run("command", func(t *testing.T) {
+		err := broker.Publish("device/cmd", "on")
+		if err != nil {
+			t.Error(err)
+		}
+		err = broker.Publish("device/denied", "on")
+		if err != nil {
+			t.Error(err)
+		}
+		select {
+		case msg := <-received:
+			if msg != "cmd on" {
+				t.Error(msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("command not received")
+		}
+	})
+
+	t.Run("command with options", func(t *testing.T) {
+		options := message.Options{Qos: 1, Properties: message.Properties{ContentType: "text/plain", UserProperties: []message.UserProperty{{Key: "device_id", Value: "device"}}}}
+		err := broker.PublishWithOptions("device/cmd", "on", options)
+		if err != nil {
+			t.Error(err)
+		}
+		err = broker.PublishWithOptions("device/denied", "on", options)
+		if err != nil {
+			t.Error(err)
+		}
+		select {
+		case msg := <-received:
+			if msg != "cmd on" {
+				t.Error(msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("command not received")
+		}
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		forwarded := make(chan string, 10)
+		err := broker.Subscribe("events/#", 1, func(topic string, payload []byte, qos byte) {
+			forwarded <- topic + " " + string(payload)
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		client.Publish("denied", 1, false, "1").Wait()
+		client.Publish("sensor", 1, false, "2").Wait()
+		select {
+		case msg := <-forwarded:
+			if msg != "events/sensor 2" {
+				t.Error(msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("event not received")
+		}
+	})
+
+	client.Disconnect(100)
+	time.Sleep(200 * time.Millisecond)
+
+	expected := []string{"online client-user", "subscribed cmd", "publish denied 1", "publish sensor 2", "gone client-user"}
+	if actual := platform.get(); strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Error(actual, expected)
+	}
+	select {
+	case msg := <-received:
+		t.Error("unexpected message", msg)
+	default:
+	}
+}
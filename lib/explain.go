@@ -0,0 +1,40 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// Explain explains how a topic published by the user (username or token) is resolved to a device and service,
+// like the debug endpoint GET /debug/explain of the webhook server, without starting the connector
+func Explain(config configuration.Config, username string, token security.JwtToken, mqttTopic string) (result topic.Explanation, err error) {
+	connector, err := NewConnector(config)
+	if err != nil {
+		return result, err
+	}
+	associations, err := NewAssociationStore(config)
+	if err != nil {
+		return result, err
+	}
+	topics := topic.NewFromConfig(connector.IotCache, config, associations)
+	return hooks.NewFromConnector(config, connector, topics, connectionlog.Void).ExplainTopic(username, token, mqttTopic)
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package homie
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Property is the description of a homie property collected from its $-attributes
+type Property struct {
+	Node     string
+	Property string
+	Name     string
+	DataType string //integer, float, boolean, string, enum, color, datetime or duration; defaults to string
+	Format   string
+	Unit     string
+	Settable bool
+}
+
+func (this Property) LocalServiceId() string {
+	return this.Node + "/" + this.Property
+}
+
+func (this Property) CommandLocalServiceId() string {
+	return this.LocalServiceId() + SetSuffix
+}
+
+// descriptions not updated within DescriptionExpiration are forgotten; homie devices publish their descriptions again when they reconnect
+const DescriptionExpiration = 24 * time.Hour
+
+// expired descriptions are removed at most once per descriptionSweepInterval, when a description is stored
+const descriptionSweepInterval = time.Minute
+
+// Descriptions collects device names and property descriptions; homie publishes every $-attribute as its own retained message
+type Descriptions struct {
+	mux         sync.Mutex
+	properties  map[string]description[Property]
+	deviceNames map[string]description[string]
+	lastSweep   time.Time
+}
+
+type description[T any] struct {
+	value   T
+	updated time.Time
+}
+
+func NewDescriptions() *Descriptions {
+	return &Descriptions{properties: map[string]description[Property]{}, deviceNames: map[string]description[string]{}, lastSweep: time.Now()}
+}
+
+// Apply stores the device name or property attribute and returns the updated property description.
+// homie device ids are only unique per user, so descriptions are stored per scope (e.g. the user).
+// complete is true for the attributes services are generated from ($datatype and $settable).
+func (this *Descriptions) Apply(scope string, topic Topic, value string) (property Property, complete bool) {
+	if topic.Node == "" && topic.Attribute == "$name" {
+		this.mux.Lock()
+		defer this.mux.Unlock()
+		now := this.sweepIfDue()
+		this.deviceNames[scope+"/"+topic.Device] = description[string]{value: value, updated: now}
+		return property, false
+	}
+	if topic.Property == "" || topic.Attribute == "" {
+		return property, false
+	}
+	key := scope + "/" + topic.Device + "/" + topic.Node + "/" + topic.Property
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := this.sweepIfDue()
+	stored, ok := this.properties[key]
+	property = stored.value
+	if !ok || now.Sub(stored.updated) >= DescriptionExpiration {
+		property = Property{Node: topic.Node, Property: topic.Property, DataType: "string"}
+	}
+	switch topic.Attribute {
+	case "$name":
+		property.Name = value
+	case "$datatype":
+		property.DataType = strings.ToLower(strings.TrimSpace(value))
+		complete = true
+	case "$format":
+		property.Format = value
+	case "$unit":
+		property.Unit = value
+	case "$settable":
+		property.Settable = strings.ToLower(strings.TrimSpace(value)) == "true"
+		complete = property.Settable
+	}
+	this.properties[key] = description[Property]{value: property, updated: now}
+	return property, complete
+}
+
+// DeviceName returns the $name of the device or the homie device id if no name is known
+func (this *Descriptions) DeviceName(scope string, device string) string {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if name, ok := this.deviceNames[scope+"/"+device]; ok && name.value != "" && time.Since(name.updated) < DescriptionExpiration {
+		return name.value
+	}
+	return device
+}
+
+// sweepIfDue removes expired descriptions at most once per descriptionSweepInterval and returns the current time; callers must hold the lock
+func (this *Descriptions) sweepIfDue() (now time.Time) {
+	now = time.Now()
+	if now.Sub(this.lastSweep) < descriptionSweepInterval {
+		return now
+	}
+	this.lastSweep = now
+	maps.DeleteFunc(this.properties, func(_ string, d description[Property]) bool {
+		return now.Sub(d.updated) >= DescriptionExpiration
+	})
+	maps.DeleteFunc(this.deviceNames, func(_ string, d description[string]) bool {
+		return now.Sub(d.updated) >= DescriptionExpiration
+	})
+	return now
+}
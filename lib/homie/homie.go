@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package homie handles topics of the Homie convention (homie/<device>/<node>/<property>, https://homieiot.github.io/).
+// Homie devices are mapped to platform devices with the local id "homie:<device>";
+// properties are mapped to event services with the local id "<node>/<property>"
+// and settable properties to request services with the local id "<node>/<property>/set".
+package homie
+
+import (
+	"errors"
+	"strings"
+)
+
+const BaseTopic = "homie"
+
+const LocalIdPrefix = "homie:"
+
+const SetSuffix = "/set"
+
+var ErrNoHomieLocalId = errors.New("local id is not a homie local id")
+
+// Topic is a parsed homie topic; Attribute is set for $-attribute topics of the device, node or property
+type Topic struct {
+	Device    string
+	Node      string
+	Property  string
+	Attribute string
+	Set       bool
+}
+
+// ParseTopic returns false for topics outside the homie base topic and for broadcasts.
+// node and property may be wildcards for subscription topic filters.
+func ParseTopic(topic string) (result Topic, ok bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 2 || len(parts) > 5 || parts[0] != BaseTopic {
+		return result, false
+	}
+	result.Device = parts[1]
+	if result.Device == "" || result.Device == "+" || result.Device == "#" || strings.HasPrefix(result.Device, "$") {
+		return result, false
+	}
+	rest := parts[2:]
+	for i, part := range rest {
+		if strings.HasPrefix(part, "$") {
+			if i != len(rest)-1 {
+				return result, false
+			}
+			result.Attribute = part
+			rest = rest[:i]
+			break
+		}
+	}
+	if len(rest) == 3 {
+		if rest[2] != "set" {
+			return result, false
+		}
+		result.Set = true
+		rest = rest[:2]
+	}
+	if len(rest) > 0 {
+		result.Node = rest[0]
+	}
+	if len(rest) > 1 {
+		result.Property = rest[1]
+	}
+	return result, true
+}
+
+// IsValue is true for property value updates
+func (this Topic) IsValue() bool {
+	return this.Property != "" && this.Attribute == "" && !this.Set
+}
+
+func (this Topic) LocalDeviceId() string {
+	return LocalIdPrefix + this.Device
+}
+
+func (this Topic) LocalServiceId() string {
+	return this.Node + "/" + this.Property
+}
+
+func IsLocalDeviceId(localId string) bool {
+	return strings.HasPrefix(localId, LocalIdPrefix)
+}
+
+// CommandTopic returns the /set topic for a command to the service with the local id "<node>/<property>/set"
+func CommandTopic(localDeviceId string, localServiceId string) (topic string, err error) {
+	if !IsLocalDeviceId(localDeviceId) || strings.TrimPrefix(localDeviceId, LocalIdPrefix) == "" {
+		return topic, ErrNoHomieLocalId
+	}
+	localServiceId = strings.TrimSuffix(localServiceId, SetSuffix)
+	return BaseTopic + "/" + strings.TrimPrefix(localDeviceId, LocalIdPrefix) + "/" + localServiceId + SetSuffix, nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package homie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTopic(t *testing.T) {
+	t.Run(testParseTopic("homie/sensor1/$state", Topic{Device: "sensor1", Attribute: "$state"}, true))
+	t.Run(testParseTopic("homie/sensor1/climate/$properties", Topic{Device: "sensor1", Node: "climate", Attribute: "$properties"}, true))
+	t.Run(testParseTopic("homie/sensor1/climate/temperature", Topic{Device: "sensor1", Node: "climate", Property: "temperature"}, true))
+	t.Run(testParseTopic("homie/sensor1/climate/temperature/$datatype", Topic{Device: "sensor1", Node: "climate", Property: "temperature", Attribute: "$datatype"}, true))
+	t.Run(testParseTopic("homie/sensor1/climate/target/set", Topic{Device: "sensor1", Node: "climate", Property: "target", Set: true}, true))
+	t.Run(testParseTopic("homie/sensor1/+/+/set", Topic{Device: "sensor1", Node: "+", Property: "+", Set: true}, true))
+	t.Run(testParseTopic("homie/sensor1/#", Topic{Device: "sensor1", Node: "#"}, true))
+	t.Run(testParseTopic("homie/sensor1/climate/target/foo", Topic{}, false))
+	t.Run(testParseTopic("homie/$broadcast/alert", Topic{}, false))
+	t.Run(testParseTopic("homie/+/climate/temperature", Topic{}, false))
+	t.Run(testParseTopic("foo/sensor1/climate/temperature", Topic{}, false))
+}
+
+func TestCommandTopic(t *testing.T) {
+	topic, err := CommandTopic("homie:sensor1", "climate/target/set")
+	if err != nil || topic != "homie/sensor1/climate/target/set" {
+		t.Error(topic, err)
+	}
+	_, err = CommandTopic("sensor1", "climate/target/set")
+	if err != ErrNoHomieLocalId {
+		t.Error(err)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	descriptions := NewDescriptions()
+	_, complete := descriptions.Apply("user", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$name"}, "Target")
+	if complete {
+		t.Error("name should not complete the description")
+	}
+	property, complete := descriptions.Apply("user", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$datatype"}, "Float")
+	if !complete || property.Name != "Target" || property.DataType != "float" || property.Settable {
+		t.Error(property, complete)
+	}
+	property, complete = descriptions.Apply("user", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$settable"}, "true")
+	if !complete || !property.Settable || property.CommandLocalServiceId() != "climate/target/set" {
+		t.Error(property, complete)
+	}
+
+	//descriptions of other users are separate
+	property, complete = descriptions.Apply("other", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$datatype"}, "integer")
+	if !complete || property.Name != "" || property.DataType != "integer" || property.Settable {
+		t.Error(property, complete)
+	}
+
+	if name := descriptions.DeviceName("user", "sensor1"); name != "sensor1" {
+		t.Error(name)
+	}
+	_, complete = descriptions.Apply("user", Topic{Device: "sensor1", Attribute: "$name"}, "Living Room Sensor")
+	if complete || descriptions.DeviceName("user", "sensor1") != "Living Room Sensor" || descriptions.DeviceName("other", "sensor1") != "sensor1" {
+		t.Error(complete, descriptions.DeviceName("user", "sensor1"), descriptions.DeviceName("other", "sensor1"))
+	}
+}
+
+func TestDescriptionsExpiration(t *testing.T) {
+	descriptions := NewDescriptions()
+	descriptions.Apply("user", Topic{Device: "sensor1", Attribute: "$name"}, "Living Room Sensor")
+	descriptions.Apply("user", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$settable"}, "true")
+	expired := time.Now().Add(-DescriptionExpiration)
+	for key, d := range descriptions.properties {
+		d.updated = expired
+		descriptions.properties[key] = d
+	}
+	for key, d := range descriptions.deviceNames {
+		d.updated = expired
+		descriptions.deviceNames[key] = d
+	}
+	if name := descriptions.DeviceName("user", "sensor1"); name != "sensor1" {
+		t.Error(name)
+	}
+	descriptions.lastSweep = expired
+	property, _ := descriptions.Apply("user", Topic{Device: "sensor1", Node: "climate", Property: "target", Attribute: "$datatype"}, "float")
+	if property.Settable {
+		t.Error("expired description should be forgotten", property)
+	}
+	if len(descriptions.deviceNames) != 0 || len(descriptions.properties) != 1 {
+		t.Error(descriptions.deviceNames, descriptions.properties)
+	}
+}
+
+func testParseTopic(topic string, expected Topic, expectedOk bool) (string, func(t *testing.T)) {
+	return topic, func(t *testing.T) {
+		actual, ok := ParseTopic(topic)
+		if ok != expectedOk {
+			t.Error(ok, expectedOk)
+			return
+		}
+		if ok && actual != expected {
+			t.Error(actual, expected)
+		}
+	}
+}
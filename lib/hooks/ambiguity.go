@@ -0,0 +1,187 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// policies for topics matching multiple devices (configuration.Config.AmbiguityPolicy)
+const (
+	AmbiguityPolicyReject = "reject"
+	AmbiguityPolicyHub    = "hub"     //device in the hub with the client id as id
+	AmbiguityPolicyOwner  = "owner"   //device owned by the user
+	AmbiguityPolicyRecent = "recent"  //device with the most recent forwarded event of this connector instance; not shared between instances
+	AmbiguityPolicyFanOut = "fan_out" //published messages are forwarded to all devices; subscriptions are rejected
+)
+
+var ErrUnknownAmbiguityPolicy = errors.New("unknown ambiguity policy")
+
+// Hubs is implemented by *devicerepo.Client
+type Hubs interface {
+	GetHub(token security.JwtToken, id string) (models.Hub, error)
+}
+
+func ValidateAmbiguityPolicy(policy string) error {
+	switch policy {
+	case "", AmbiguityPolicyReject, AmbiguityPolicyHub, AmbiguityPolicyOwner, AmbiguityPolicyRecent, AmbiguityPolicyFanOut:
+		return nil
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownAmbiguityPolicy, policy)
+	}
+}
+
+// resolveAmbiguity selects devices of a topic.MultipleMatchesError by the configured policy.
+// ok is false if the policy does not select exactly one device, or all devices with AmbiguityPolicyFanOut and fanOut=true.
+// decisions are logged with the runner-up candidates
+func (this *Platform) resolveAmbiguity(token security.JwtToken, username string, clientId string, mqttTopic string, fanOut bool, err error) (selected []topic.Match, ok bool) {
+	multiple := &topic.MultipleMatchesError{}
+	if !errors.As(err, &multiple) || len(multiple.Matches) == 0 {
+		return nil, false
+	}
+	policy := this.config.AmbiguityPolicy
+	switch policy {
+	case AmbiguityPolicyHub:
+		selected = this.selectByHub(token, clientId, multiple.Matches)
+	case AmbiguityPolicyOwner:
+		selected = this.selectByOwner(username, multiple.Matches)
+	case AmbiguityPolicyRecent:
+		selected = this.activity.mostRecent(multiple.Matches)
+	case AmbiguityPolicyFanOut:
+		if fanOut {
+			selected = multiple.Matches
+		}
+	default:
+		return nil, false
+	}
+	logger := this.config.GetLogger().With("policy", policy, "topic", mqttTopic, "username", username, "client_id", clientId)
+	if len(selected) == 0 || (len(selected) > 1 && policy != AmbiguityPolicyFanOut) {
+		logger.Info("unable to resolve ambiguous topic", "candidates", matchDeviceIds(multiple.Matches))
+		return nil, false
+	}
+	runnerUp := slices.DeleteFunc(slices.Clone(multiple.Matches), func(m topic.Match) bool {
+		return slices.ContainsFunc(selected, func(s topic.Match) bool { return s.Device.Id == m.Device.Id })
+	})
+	logger.Info("resolved ambiguous topic", "selected", matchDeviceIds(selected), "runner_up", matchDeviceIds(runnerUp))
+	return selected, true
+}
+
+func (this *Platform) selectByHub(token security.JwtToken, clientId string, matches []topic.Match) (result []topic.Match) {
+	if clientId == "" {
+		return nil
+	}
+	hub, err := this.hubs.GetHub(token, clientId)
+	if err != nil {
+		if !errors.Is(err, devicerepo.ErrNotFound) {
+			this.config.GetLogger().Error("unable to get hub", "error", err, "hub_id", clientId)
+		}
+		return nil
+	}
+	for _, match := range matches {
+		if slices.Contains(hub.DeviceIds, match.Device.Id) {
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+func (this *Platform) selectByOwner(username string, matches []topic.Match) (result []topic.Match) {
+	userId, err := this.security.GetUserId(username)
+	if err != nil {
+		this.config.GetLogger().Error("unable to get user id", "error", err, "username", username)
+		return nil
+	}
+	for _, match := range matches {
+		if match.Device.OwnerId == userId {
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+func matchDeviceIds(matches []topic.Match) (result []string) {
+	result = []string{}
+	for _, match := range matches {
+		result = append(result, match.Device.Id)
+	}
+	return result
+}
+
+// events older than activityExpiration do not count for AmbiguityPolicyRecent
+const activityExpiration = 24 * time.Hour
+
+// expired events are removed at most once per activitySweepInterval, when an event is recorded
+const activitySweepInterval = time.Minute
+
+// activity remembers the time of the last forwarded event per device; it is kept in memory per connector instance
+type activity struct {
+	mux       sync.RWMutex
+	lastEvent map[string]time.Time
+	lastSweep time.Time
+}
+
+func newActivity() *activity {
+	return &activity{lastEvent: map[string]time.Time{}, lastSweep: time.Now()}
+}
+
+func (this *activity) touch(deviceId string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if now.Sub(this.lastSweep) >= activitySweepInterval {
+		this.sweep(now)
+		this.lastSweep = now
+	}
+	this.lastEvent[deviceId] = now
+}
+
+// sweep removes expired events; callers must hold the lock
+func (this *activity) sweep(now time.Time) {
+	maps.DeleteFunc(this.lastEvent, func(_ string, last time.Time) bool {
+		return now.Sub(last) >= activityExpiration
+	})
+}
+
+// mostRecent returns the match with the latest event; nil if no match has events or the latest time is not unique
+func (this *activity) mostRecent(matches []topic.Match) []topic.Match {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	var result []topic.Match
+	latest := time.Time{}
+	for _, match := range matches {
+		last, ok := this.lastEvent[match.Device.Id]
+		switch {
+		case !ok || time.Since(last) >= activityExpiration:
+		case last.After(latest):
+			latest = last
+			result = []topic.Match{match}
+		case last.Equal(latest):
+			result = append(result, match)
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+func TestAmbiguityPolicies(t *testing.T) {
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyReject, "c1", Deny, nil))
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyHub, "hub1", Allow, []string{otherDeviceId}))
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyHub, "c1", Deny, nil))
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyOwner, "c1", Allow, []string{testDeviceId}))
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyRecent, "c1", Deny, nil))
+	t.Run(testAmbiguityPolicy(AmbiguityPolicyFanOut, "c1", Allow, []string{testDeviceId, otherDeviceId}))
+
+	t.Run("recent", func(t *testing.T) {
+		platform, events := newAmbiguityTestPlatform(AmbiguityPolicyRecent)
+		platform.activity.touch(otherDeviceId)
+		decision, err := platform.HandlePublish(PublishRequest{Username: "user", ClientId: "c1", Topic: "multiple/sensor"})
+		if err != nil || decision.Verdict != Allow || !decision.Forwarded || decision.Topic != otherDeviceId+"/multiple/sensor" {
+			t.Error(err, decision)
+		}
+		if !slices.Equal(events.deviceIds, []string{otherDeviceId}) {
+			t.Error(events.deviceIds)
+		}
+	})
+
+	t.Run("expired activity", func(t *testing.T) {
+		platform, _ := newAmbiguityTestPlatform(AmbiguityPolicyRecent)
+		platform.activity.lastEvent[otherDeviceId] = time.Now().Add(-activityExpiration)
+		decision, err := platform.HandlePublish(PublishRequest{Username: "user", ClientId: "c1", Topic: "multiple/sensor"})
+		if err != nil || decision.Verdict != Deny {
+			t.Error(err, decision)
+		}
+
+		platform.activity.lastSweep = time.Now().Add(-activitySweepInterval)
+		platform.activity.touch(testDeviceId)
+		if _, ok := platform.activity.lastEvent[otherDeviceId]; ok || len(platform.activity.lastEvent) != 1 {
+			t.Error("expired activity should be removed", platform.activity.lastEvent)
+		}
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		for policy, expected := range map[string]Verdict{AmbiguityPolicyOwner: Allow, AmbiguityPolicyFanOut: Deny} {
+			platform, _ := newAmbiguityTestPlatform(policy)
+			decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", ClientId: "c1", Topics: []TopicRequest{{Topic: "multiple/cmd"}}})
+			if err != nil || len(decision.Topics) != 1 || decision.Topics[0].Verdict != expected {
+				t.Error(policy, err, decision)
+				continue
+			}
+			if expected == Allow && (decision.Topics[0].DeviceId != testDeviceId || decision.Topics[0].Topic != testDeviceId+"/multiple/cmd") {
+				t.Error(policy, decision)
+			}
+		}
+	})
+
+	if !errors.Is(ValidateAmbiguityPolicy("foo"), ErrUnknownAmbiguityPolicy) || ValidateAmbiguityPolicy("") != nil || ValidateAmbiguityPolicy(AmbiguityPolicyFanOut) != nil {
+		t.Error("unexpected policy validation")
+	}
+}
+
+func testAmbiguityPolicy(policy string, clientId string, expected Verdict, expectedDeviceIds []string) (string, func(t *testing.T)) {
+	return policy + " " + clientId, func(t *testing.T) {
+		platform, events := newAmbiguityTestPlatform(policy)
+		decision, err := platform.HandlePublish(PublishRequest{Username: "user", ClientId: clientId, Topic: "multiple/sensor"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if decision.Verdict != expected {
+			t.Error(decision)
+			return
+		}
+		if decision.Forwarded != (expected == Allow) {
+			t.Error(decision)
+		}
+		if !slices.Equal(events.deviceIds, expectedDeviceIds) {
+			t.Error(events.deviceIds, expectedDeviceIds)
+		}
+	}
+}
+
+func newAmbiguityTestPlatform(policy string) (*Platform, *recordingEventHandler) {
+	events := &recordingEventHandler{}
+	config := configuration.Config{AuthClientId: "connector", AmbiguityPolicy: policy}
+	return New(config, testSecurity{}, testTopicParser{}, events, testHubs{}, nil, nil, connectionlog.Void), events
+}
+
+// recordingEventHandler accepts events of testDeviceId and otherDeviceId
+type recordingEventHandler struct {
+	mux       sync.Mutex
+	deviceIds []string
+}
+
+func (this *recordingEventHandler) HandleDeviceIdentEventWithAuthToken(token security.JwtToken, deviceId string, localDeviceId string, serviceId string, localServiceId string, eventMsg platform_connector_lib.EventMsg, qos platform_connector_lib.Qos) (info platform_connector_lib.HandledDeviceInfo, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.deviceIds = append(this.deviceIds, deviceId)
+	return info, nil
+}
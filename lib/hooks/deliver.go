@@ -0,0 +1,107 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"strings"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topicfilter"
+)
+
+const deviceIdPrefix = "urn:infai:ses:device:"
+
+// Deliver reverses the device-id prefix added by AuthorizeSubscribe.
+// if the connection-log knows subscriptions of the client, the prefix is only removed if a stored (requested) topic filter
+// matches the topic without prefix but none matches the delivered topic.
+// without stored subscriptions the prefix is removed if the rest of the topic still references the device by its id or short id,
+// because such topics were not subscribed with the prefix. the owner id prefix of topics of conventions is always removed.
+// subscriptions are cached per client (see subscriptionCache) and reloaded if none matches the delivered topic.
+func (this *Platform) Deliver(req DeliverRequest) (DeliverDecision, error) {
+	unchanged := DeliverDecision{Topic: req.Topic}
+	if req.Username == this.config.AuthClientId {
+		return unchanged, nil
+	}
+	subscriptions, err := this.clientSubscriptions(req.ClientId, subscriptionCacheExpiration)
+	if err != nil {
+		this.config.GetLogger().Error("unable to load subscriptions", "error", err, "clientId", req.ClientId)
+		return unchanged, err
+	}
+	decision, matched := deliverTopic(subscriptions, req.Topic)
+	if matched {
+		return decision, nil
+	}
+	//the client may have subscribed through another connector instance
+	subscriptions, err = this.clientSubscriptions(req.ClientId, subscriptionCacheRefreshInterval)
+	if err != nil {
+		this.config.GetLogger().Error("unable to load subscriptions", "error", err, "clientId", req.ClientId)
+		return unchanged, err
+	}
+	decision, _ = deliverTopic(subscriptions, req.Topic)
+	return decision, nil
+}
+
+// deliverTopic returns the topic the client receives the message on; matched is false if no subscription matches the topic
+func deliverTopic(subscriptions []connectionlog.Subscription, topic string) (decision DeliverDecision, matched bool) {
+	if len(subscriptions) == 0 {
+		return DeliverDecision{Topic: stripReferencedDevicePrefix(topic)}, false
+	}
+	for _, subscription := range subscriptions {
+		if topicfilter.Matches(subscription.Topic, topic) {
+			return DeliverDecision{Topic: topic}, true
+		}
+	}
+	for _, subscription := range subscriptions {
+		stripped, ok := stripSubscriptionPrefix(subscription, topic)
+		if ok && topicfilter.Matches(subscription.Topic, stripped) {
+			return DeliverDecision{Topic: stripped}, true
+		}
+	}
+	return DeliverDecision{Topic: topic}, false
+}
+
+// stripSubscriptionPrefix removes the prefix AuthorizeSubscribe adds to the subscription: the device id or, for topics of conventions, the owner id of the device
+func stripSubscriptionPrefix(subscription connectionlog.Subscription, topic string) (stripped string, ok bool) {
+	if subscription.DeviceId == "" {
+		return topic, false
+	}
+	if isConventionTopic(subscription.Topic) {
+		_, stripped, ok = strings.Cut(topic, "/")
+		return stripped, ok
+	}
+	return strings.CutPrefix(topic, subscription.DeviceId+"/")
+}
+
+// stripReferencedDevicePrefix removes the device id prefix, if the rest of the topic references the device, and the owner id prefix of topics of conventions
+func stripReferencedDevicePrefix(topic string) string {
+	if _, conventionTopic, ok := splitOwnerPrefix(topic); ok {
+		return conventionTopic
+	}
+	deviceId, rest, found := strings.Cut(topic, "/")
+	if !found || !strings.HasPrefix(deviceId, deviceIdPrefix) {
+		return topic
+	}
+	if strings.Contains(rest, deviceId) {
+		return rest
+	}
+	short, err := shortid.ShortId(deviceId)
+	if err == nil && short != "" && strings.Contains(rest, short) {
+		return rest
+	}
+	return topic
+}
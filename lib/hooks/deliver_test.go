@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+)
+
+func TestDeliver(t *testing.T) {
+	platform, connLog := newTestPlatform()
+
+	//without stored subscriptions
+	t.Run(testDeliver(platform, "connector", testDeviceId+"/cmd/"+testDeviceId, testDeviceId+"/cmd/"+testDeviceId))
+	t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/"+testDeviceId, "cmd/"+testDeviceId))
+	t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/a9B7ddfMShqI26yT9hqnsw", "cmd/a9B7ddfMShqI26yT9hqnsw"))
+	t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local", testDeviceId+"/cmd/local"))
+
+	//with stored subscriptions
+	connLog.stored = []connectionlog.Subscription{{Topic: "cmd/local/#", DeviceId: testDeviceId}, {Topic: testDeviceId + "/direct/#", DeviceId: testDeviceId}}
+	platform.subscriptions.invalidate("c1")
+	t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local/power", "cmd/local/power"))
+	t.Run(testDeliver(platform, "user", testDeviceId+"/direct/power", testDeviceId+"/direct/power"))
+	t.Run(testDeliver(platform, "user", testDeviceId+"/other/power", testDeviceId+"/other/power"))
+}
+
+func TestDeliverSubscriptionCache(t *testing.T) {
+	platform, connLog := newTestPlatform()
+	connLog.stored = []connectionlog.Subscription{{Topic: "cmd/local/#", DeviceId: testDeviceId}}
+
+	t.Run("load once", func(t *testing.T) {
+		t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local/power", "cmd/local/power"))
+		t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local/light", "cmd/local/light"))
+		if connLog.loads != 1 {
+			t.Error(connLog.loads)
+		}
+	})
+
+	t.Run("connection-log error uses cache", func(t *testing.T) {
+		connLog.storedErr = errors.New("test error")
+		defer func() { connLog.storedErr = nil }()
+		platform.subscriptions.entries["c1"] = subscriptionCacheEntry{subscriptions: connLog.stored, loaded: time.Now().Add(-subscriptionCacheExpiration)}
+		t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local/power", "cmd/local/power"))
+		if connLog.loads != 2 {
+			t.Error(connLog.loads)
+		}
+	})
+
+	t.Run("reload unmatched", func(t *testing.T) {
+		connLog.loads = 0
+		connLog.stored = append(connLog.stored, connectionlog.Subscription{Topic: "other/#", DeviceId: testDeviceId})
+		platform.subscriptions.entries["c1"] = subscriptionCacheEntry{subscriptions: connLog.stored[:1], loaded: time.Now().Add(-subscriptionCacheRefreshInterval)}
+		t.Run(testDeliver(platform, "user", testDeviceId+"/other/power", "other/power"))
+		if connLog.loads != 1 {
+			t.Error(connLog.loads)
+		}
+	})
+
+	t.Run("invalidate on subscribe", func(t *testing.T) {
+		connLog.loads = 0
+		platform.Subscribed("c1", SubscribeDecision{Topics: []TopicDecision{{Verdict: Allow, RequestedTopic: "new/#", Topic: testDeviceId + "/new/#", DeviceId: testDeviceId}}})
+		t.Run(testDeliver(platform, "user", testDeviceId+"/cmd/local/power", "cmd/local/power"))
+		if connLog.loads != 1 {
+			t.Error(connLog.loads)
+		}
+	})
+
+	t.Run("invalidate on disconnect", func(t *testing.T) {
+		platform.ClientGone("c1")
+		if _, ok := platform.subscriptions.get("c1"); ok {
+			t.Error("subscriptions should be removed from cache")
+		}
+	})
+}
+
+func testDeliver(platform *Platform, username string, topic string, expectedTopic string) (string, func(t *testing.T)) {
+	return topic, func(t *testing.T) {
+		decision, err := platform.Deliver(DeliverRequest{Username: username, ClientId: "c1", Topic: topic})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if decision.Topic != expectedTopic {
+			t.Error(decision.Topic, expectedTopic)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+var ErrMissingExplainUser = errors.New("missing username or token")
+
+// ExplainTopic explains how a topic published by the user is resolved to a device and service.
+// the token is used if set, otherwise the cached token of the username. user specific topic rewrite rules are only applied with username
+func (this *Platform) ExplainTopic(username string, token security.JwtToken, mqttTopic string) (result topic.Explanation, err error) {
+	if token == "" {
+		if username == "" {
+			return result, ErrMissingExplainUser
+		}
+		token, err = this.security.GetCachedUserToken(username, model.RemoteInfo{})
+		if err != nil {
+			return result, err
+		}
+	}
+	parseTopic := this.normalizeTopic(username, mqttTopic)
+	result = this.topicParser.Explain(token, parseTopic)
+	if parseTopic != mqttTopic {
+		result.RewrittenFrom = mqttTopic
+	}
+	return result, nil
+}
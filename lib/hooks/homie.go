@@ -0,0 +1,79 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/homie"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+// handleHomiePublish generates services from property descriptions and forwards property values as events.
+// descriptions of unknown devices are ignored by the broker but passed to the generator, which may create the device.
+// homie topics are prefixed with the owner id of the device; homie controllers of the owner may subscribe to them.
+func (this *Platform) handleHomiePublish(req PublishRequest, hTopic homie.Topic, msgSize float64) (PublishDecision, error) {
+	decision, token, device, services, err := this.authorizeLocalDevicePublish(req, hTopic.LocalDeviceId())
+	if err != nil {
+		return decision, err
+	}
+	unknownDevice := decision.Verdict == Ignore && device.Id == ""
+	if decision.Verdict != Allow && !unknownDevice {
+		return decision, nil
+	}
+	if hTopic.Attribute != "" {
+		property, complete := this.homieDescriptions.Apply(req.Username, hTopic, string(req.Payload))
+		if complete && unknownDevice {
+			device, err = this.unknownHomieDevice(req.Username, hTopic)
+			if err != nil {
+				this.config.GetLogger().Error("unable to get user id for homie device", "error", err, "username", req.Username)
+				return decision, nil
+			}
+		}
+		if complete {
+			this.homieServiceGenerator(token, device, property)
+		}
+		return decision, nil
+	}
+	if unknownDevice {
+		return decision, nil
+	}
+	if !hTopic.IsValue() {
+		return decision, nil
+	}
+	service, ok := services[hTopic.LocalServiceId()]
+	if !ok {
+		this.config.GetLogger().Debug("no service for homie property", "device", device.Id, "topic", req.Topic)
+		return decision, nil
+	}
+	err = this.forward(token, req.Username, device, service, req, msgSize)
+	if err == nil {
+		decision.Forwarded = true
+	}
+	return decision, nil
+}
+
+// unknownHomieDevice describes a not yet existing device for the homie topic, owned by the user
+func (this *Platform) unknownHomieDevice(username string, hTopic homie.Topic) (device model.Device, err error) {
+	userId, err := this.security.GetUserId(username)
+	if err != nil {
+		return device, err
+	}
+	return model.Device{
+		LocalId: hTopic.LocalDeviceId(),
+		Name:    this.homieDescriptions.DeviceName(username, hTopic.Device),
+		OwnerId: userId,
+	}, nil
+}
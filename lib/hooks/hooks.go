@@ -0,0 +1,194 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import "github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+
+// Hooks is the broker-neutral platform logic behind the broker webhooks.
+// broker adapters (vernemqtt, mosquitto, emqx) translate their request formats into these calls
+// and the returned decisions into their response formats.
+type Hooks interface {
+	// Authenticate checks the credentials of a connecting client.
+	Authenticate(req AuthRequest) (AuthDecision, error)
+
+	// AuthorizePublish checks if the client may publish to the topic, without forwarding the message.
+	AuthorizePublish(req PublishRequest) (PublishDecision, error)
+
+	// HandlePublish checks the publish like AuthorizePublish and forwards allowed messages to the platform.
+	HandlePublish(req PublishRequest) (PublishDecision, error)
+
+	// AuthorizeSubscribe checks every requested topic filter; it has no side effects.
+	AuthorizeSubscribe(req SubscribeRequest) (SubscribeDecision, error)
+
+	// Subscribed records the allowed topics of a decision returned by AuthorizeSubscribe in the connection-log.
+	Subscribed(clientId string, decision SubscribeDecision)
+
+	// Deliver maps the topic of a message delivered to a client back to the form the client subscribed with.
+	Deliver(req DeliverRequest) (DeliverDecision, error)
+
+	// Unsubscribe records removed subscriptions in the connection-log.
+	Unsubscribe(req UnsubscribeRequest) error
+
+	// ClientOnline records a connected client.
+	ClientOnline(req OnlineRequest)
+
+	// ClientGone records a disconnected client.
+	ClientGone(clientId string)
+}
+
+type Verdict int
+
+const (
+	// Allow the request; a rewritten topic may be set in the decision
+	Allow Verdict = iota
+	// Deny the request
+	Deny
+	// Ignore accepts a publish but signals that it should not reach normal subscribers (vernemqtt redirects it to "ignored/<topic>")
+	Ignore
+)
+
+func (this Verdict) String() string {
+	switch this {
+	case Allow:
+		return "allow"
+	case Deny:
+		return "deny"
+	case Ignore:
+		return "ignore"
+	default:
+		return "unknown"
+	}
+}
+
+// ReasonCode is the mqtt v5 reason code matching a Deny or Ignore verdict; brokers without v5 support may ignore it
+type ReasonCode byte
+
+const (
+	ReasonSuccess               ReasonCode = 0x00
+	ReasonUnspecifiedError      ReasonCode = 0x80
+	ReasonBadUsernameOrPassword ReasonCode = 0x86
+	ReasonNotAuthorized         ReasonCode = 0x87
+	ReasonTopicFilterInvalid    ReasonCode = 0x8F
+	ReasonTopicNameInvalid      ReasonCode = 0x90
+	ReasonPayloadFormatInvalid  ReasonCode = 0x99
+)
+
+type AuthRequest struct {
+	Username string
+	Password string
+	ClientId string
+	PeerAddr string
+	PeerPort string
+
+	// CleanSession is stored for the client after a successful login, if set
+	CleanSession *bool
+}
+
+type AuthDecision struct {
+	Verdict    Verdict
+	Superuser  bool //client of the connector itself
+	ReasonCode ReasonCode
+	Reason     string
+}
+
+type PublishRequest struct {
+	Username string
+	ClientId string
+	Topic    string
+	Payload  []byte
+	Qos      int
+
+	// Size of the received broker message, used for statistics; defaults to len(Payload)
+	Size int
+
+	// Properties of mqtt 5 messages; the content type and user properties are forwarded with the payload (see protocolMsg)
+	Properties message.Properties
+}
+
+type PublishDecision struct {
+	Verdict    Verdict
+	Superuser  bool   //client of the connector itself; no checks applied
+	Topic      string //topic the message should be published to; may differ from the requested topic
+	Qos        int
+	Forwarded  bool //message has been forwarded to the platform
+	ReasonCode ReasonCode
+	Reason     string
+}
+
+// Rewritten returns true if the broker should publish the message to a different topic than requested.
+func (this PublishDecision) Rewritten(req PublishRequest) bool {
+	return this.Topic != req.Topic
+}
+
+type SubscribeRequest struct {
+	Username string
+	ClientId string
+	Topics   []TopicRequest
+}
+
+type TopicRequest struct {
+	Topic string
+	Qos   int
+}
+
+type SubscribeDecision struct {
+	Superuser bool //client of the connector itself; no checks applied
+	Topics    []TopicDecision
+}
+
+type TopicDecision struct {
+	Verdict        Verdict
+	RequestedTopic string
+	Topic          string //topic the broker should subscribe to; may differ from RequestedTopic
+	Qos            int
+	DeviceId       string
+	ReasonCode     ReasonCode
+	Reason         string
+}
+
+// Rewritten returns true if the broker should subscribe to a different topic than requested.
+func (this TopicDecision) Rewritten() bool {
+	return this.Topic != this.RequestedTopic
+}
+
+type DeliverRequest struct {
+	Username string
+	ClientId string
+	Topic    string
+}
+
+type DeliverDecision struct {
+	Topic string //topic the client should receive the message on; may differ from the delivered topic
+}
+
+// Rewritten returns true if the broker should deliver the message with a different topic.
+func (this DeliverDecision) Rewritten(req DeliverRequest) bool {
+	return this.Topic != req.Topic
+}
+
+type UnsubscribeRequest struct {
+	Username string
+	ClientId string
+	Topics   []string
+}
+
+type OnlineRequest struct {
+	ClientId string
+
+	// CleanSession is stored for the client, if set
+	CleanSession *bool
+}
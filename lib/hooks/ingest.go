@@ -0,0 +1,109 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/platform-connector-lib/statistics"
+)
+
+var ErrCommandTopic = errors.New("topic references a service without event interaction or is the command topic of the service")
+var ErrIngestedDeviceMismatch = errors.New("topic references another device for the device owner")
+var ErrIngestedCommandResponse = errors.New("ingested command responses are disabled")
+
+// HandleIngestedPublish forwards a message received by the connectors own (shared) subscription.
+// there is no mqtt user: the referenced device is found with admin rights to get its owner, then the topic is parsed again
+// and the event is sent with a token of the owner. topics matching devices of multiple users fail with a topic.MultipleMatchesError,
+// because local device ids are not unique across users; topics should reference devices by id or short id.
+// messages to services with request-only interaction and to the command topic of the service (commands published by the connector) are not forwarded.
+// convention topics prefixed with the owner id (see rewriteTopic) are parsed without the prefix with a token of this owner.
+// the publisher of ingested messages is unknown, so messages to response topics of pending commands are only handled as command responses
+// if config.IngestionCommandResponses confirms that the broker has authorized the publish (see Platform.AuthorizePublish); otherwise ErrIngestedCommandResponse is returned.
+func (this *Platform) HandleIngestedPublish(mqttTopic string, payload []byte, qos int) error {
+	msgSize := float64(len(payload))
+	if this.pendingCommands != nil && this.commandResponses != nil {
+		if !this.config.IngestionCommandResponses {
+			if _, ok := this.pendingCommands.FindPendingCommand(mqttTopic, acceptAnyCommand); ok {
+				return ErrIngestedCommandResponse
+			}
+		} else if pending, ok := this.pendingCommands.TakePendingCommand(mqttTopic, acceptAnyCommand); ok {
+			err := this.commandResponses.HandleCommandResponse(pending.Request, platform_connector_lib.CommandResponseMsg{"payload": string(payload)}, platform_connector_lib.Qos(qos))
+			if err != nil {
+				this.pendingCommands.RestorePendingCommand(pending)
+			}
+			return err
+		}
+	}
+	candidate := model.Device{}
+	ownerId, parseTopic, prefixed := splitOwnerPrefix(mqttTopic)
+	if prefixed {
+		//convention topics rewritten by the broker name the owner
+		candidate.OwnerId = ownerId
+	} else {
+		var err error
+		candidate, _, err = this.topicParser.Parse(security.JwtToken(client.InternalAdminToken), mqttTopic)
+		if err != nil && !errors.Is(err, topic.ErrNoServiceMatchFound) {
+			return err
+		}
+	}
+	token, err := this.security.ExchangeUserToken(candidate.OwnerId, model.RemoteInfo{Protocol: this.config.SecRemoteProtocol})
+	if err != nil {
+		this.config.GetLogger().Error("unable to get token of device owner", "error", err, "device", candidate.Id, "owner", candidate.OwnerId)
+		return err
+	}
+	device, service, err := this.topicParser.Parse(token, parseTopic)
+	if err != nil && !errors.Is(err, topic.ErrNoServiceMatchFound) {
+		return err
+	}
+	if prefixed && device.OwnerId != ownerId {
+		return fmt.Errorf("%w: %v is owned by %v instead of %v", ErrIngestedDeviceMismatch, device.Id, device.OwnerId, ownerId)
+	}
+	if !prefixed && device.Id != candidate.Id {
+		return fmt.Errorf("%w: %v instead of %v", ErrIngestedDeviceMismatch, device.Id, candidate.Id)
+	}
+	if errors.Is(err, topic.ErrNoServiceMatchFound) {
+		this.serviceGenerator(device, parseTopic, payload)
+		return nil
+	}
+	if service.Interaction == models.REQUEST || this.isCommandTopic(token, device, service, mqttTopic) {
+		return ErrCommandTopic
+	}
+	statistics.SourceReceive(msgSize, device.OwnerId)
+	return this.forward(token, device.OwnerId, device, service, PublishRequest{Topic: mqttTopic, Payload: payload, Qos: qos}, msgSize)
+}
+
+// acceptAnyCommand is used for ingested messages, which were already authorized by the broker
+func acceptAnyCommand(topic.PendingCommand) bool {
+	return true
+}
+
+// isCommandTopic returns true if the connector publishes commands of the service to mqttTopic
+func (this *Platform) isCommandTopic(token security.JwtToken, device model.Device, service model.Service, mqttTopic string) bool {
+	if service.Interaction == models.EVENT {
+		return false
+	}
+	commandTopic, err := this.topicParser.CreateForService(token, device, service)
+	return err == nil && commandTopic == mqttTopic
+}
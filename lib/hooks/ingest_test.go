@@ -0,0 +1,129 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package hooks
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// ingestTopicParser owns testDeviceId by "user" with the service "sensor" (event and request) and "switch" (request);
+// topics starting with "shadowed/" reference otherDeviceId for the owner
+type ingestTopicParser struct {
+	testTopicParser
+	tokens *[]security.JwtToken
+}
+
+func (this ingestTopicParser) Parse(token security.JwtToken, mqttTopic string) (device model.Device, service model.Service, err error) {
+	*this.tokens = append(*this.tokens, token)
+	device, service, err = this.testTopicParser.Parse(token, mqttTopic)
+	if device.Id != "" {
+		device.OwnerId = "user"
+	}
+	if strings.HasPrefix(mqttTopic, "shadowed/") && token != security.JwtToken(client.InternalAdminToken) {
+		device.Id = otherDeviceId
+	}
+	switch {
+	case strings.Contains(mqttTopic, "switch"):
+		return device, model.Service{Id: "switch", LocalId: "switch", Interaction: models.REQUEST}, nil
+	case service.Id != "":
+		service.Interaction = models.EVENT_AND_REQUEST
+	}
+	return device, service, err
+}
+
+// ingestEventHandler records events with token, device, service, payload and qos
+type ingestEventHandler struct {
+	events []string
+}
+
+func (this *ingestEventHandler) HandleDeviceIdentEventWithAuthToken(token security.JwtToken, deviceId string, localDeviceId string, serviceId string, localServiceId string, eventMsg platform_connector_lib.EventMsg, qos platform_connector_lib.Qos) (info platform_connector_lib.HandledDeviceInfo, err error) {
+	this.events = append(this.events, string(token)+" "+deviceId+" "+serviceId+" "+eventMsg["payload"]+" "+strconv.Itoa(int(qos)))
+	return info, nil
+}
+
+func TestHandleIngestedPublish(t *testing.T) {
+	tokens := []security.JwtToken{}
+	events := &ingestEventHandler{}
+	platform, _ := newTestPlatform()
+	platform.topicParser = ingestTopicParser{tokens: &tokens}
+	platform.events = events
+
+	t.Run("event with owner token and source qos", func(t *testing.T) {
+		tokens = nil
+		err := platform.HandleIngestedPublish(testDeviceId+"/sensor", []byte("42"), 1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(tokens, []security.JwtToken{security.JwtToken(client.InternalAdminToken), "Bearer user"}) {
+			t.Error(tokens)
+		}
+		if !reflect.DeepEqual(events.events, []string{"Bearer user " + testDeviceId + " sensor 42 1"}) {
+			t.Error(events.events)
+		}
+	})
+
+	t.Run("command topic", func(t *testing.T) {
+		err := platform.HandleIngestedPublish(testDeviceId+"/cmd/sensor", []byte("42"), 2)
+		if !errors.Is(err, ErrCommandTopic) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("request service", func(t *testing.T) {
+		err := platform.HandleIngestedPublish(testDeviceId+"/switch", []byte("on"), 2)
+		if !errors.Is(err, ErrCommandTopic) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		err := platform.HandleIngestedPublish("multiple/sensor", []byte("42"), 2)
+		multiple := &topic.MultipleMatchesError{}
+		if !errors.As(err, &multiple) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("other device for owner", func(t *testing.T) {
+		err := platform.HandleIngestedPublish("shadowed/"+testDeviceId+"/sensor", []byte("42"), 2)
+		if !errors.Is(err, ErrIngestedDeviceMismatch) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		err := platform.HandleIngestedPublish("unknown/sensor", []byte("42"), 2)
+		if !errors.Is(err, topic.ErrNoDeviceMatchFound) {
+			t.Error(err)
+		}
+	})
+
+	if len(events.events) != 1 {
+		t.Error(events.events)
+	}
+}
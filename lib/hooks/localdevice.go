@@ -0,0 +1,79 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// authorizeLocalDevicePublish checks the access to the device with the local id; the topic is prefixed with the owner id of the device
+func (this *Platform) authorizeLocalDevicePublish(req PublishRequest, localDeviceId string) (decision PublishDecision, token security.JwtToken, device model.Device, services map[string]model.Service, err error) {
+	decision = PublishDecision{Verdict: Allow, Topic: req.Topic, Qos: req.Qos}
+	token, err = this.security.GetCachedUserToken(req.Username, model.RemoteInfo{})
+	if err != nil {
+		this.config.GetLogger().Error("unable to get user token", "error", err, "username", req.Username)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonNotAuthorized, Reason: err.Error()}, token, device, services, err
+	}
+	device, services, err = this.topicParser.ParseLocalDevice(token, localDeviceId)
+	switch {
+	case errors.Is(err, topic.ErrNoDeviceMatchFound):
+		decision.Verdict = Ignore
+		decision.ReasonCode = ReasonNotAuthorized
+		decision.Reason = err.Error()
+		return decision, token, device, services, nil
+	case errors.Is(err, topic.ErrMultipleMatchingDevicesFound):
+		decision.Verdict = Deny
+		decision.ReasonCode = ReasonNotAuthorized
+		decision.Reason = err.Error()
+		return decision, token, device, services, nil
+	case err != nil:
+		this.config.GetLogger().Error("unable to find device by local id", "error", err, "topic", req.Topic)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonUnspecifiedError, Reason: err.Error()}, token, device, services, err
+	}
+	decision.Topic = topic.WithPrefix(device.OwnerId, req.Topic)
+	return decision, token, device, services, nil
+}
+
+// authorizeLocalDeviceSubscribe allows subscriptions to topics of the accessible device with the local id; the topic is prefixed with the owner id of the device,
+// because local ids are only unique per user. topics already prefixed by the client (ownerId not empty) have to be prefixed with the owner of the device
+func (this *Platform) authorizeLocalDeviceSubscribe(token security.JwtToken, req TopicRequest, localDeviceId string, ownerId string) (decision TopicDecision, err error) {
+	decision = TopicDecision{Verdict: Allow, RequestedTopic: req.Topic, Topic: req.Topic, Qos: req.Qos}
+	device, _, err := this.topicParser.ParseLocalDevice(token, localDeviceId)
+	if errors.Is(err, topic.ErrNoDeviceMatchFound) || errors.Is(err, topic.ErrMultipleMatchingDevicesFound) {
+		decision.Verdict = Deny
+		decision.ReasonCode = ReasonNotAuthorized
+		decision.Reason = err.Error()
+		return decision, nil
+	}
+	if err != nil {
+		this.config.GetLogger().Warn("unable to find device by local id", "error", err, "topic", req.Topic)
+		return decision, err
+	}
+	if ownerId != "" && ownerId != device.OwnerId {
+		decision.Verdict = Deny
+		decision.ReasonCode = ReasonNotAuthorized
+		decision.Reason = "topic prefix is not the owner of the device"
+		return decision, nil
+	}
+	decision.DeviceId = device.Id
+	decision.Topic = topic.WithPrefix(device.OwnerId, req.Topic)
+	return decision, nil
+}
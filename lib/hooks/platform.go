@@ -0,0 +1,473 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/homie"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/sparkplug"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/platform-connector-lib/statistics"
+)
+
+// Security is the subset of *security.Security used by Platform
+type Security interface {
+	GetUserToken(username, password string, remoteInfo model.RemoteInfo) (security.JwtToken, error)
+	ExchangeUserToken(userid string, remoteInfo model.RemoteInfo) (security.JwtToken, error)
+	GetCachedUserToken(username string, remoteInfo model.RemoteInfo) (security.JwtToken, error)
+	GetUserId(username string) (string, error)
+}
+
+// TopicParser is implemented by *topic.Topic
+type TopicParser interface {
+	Parse(token security.JwtToken, topic string) (device model.Device, service model.Service, err error)
+	ParseLocalDevice(token security.JwtToken, localDeviceId string) (device model.Device, services map[string]model.Service, err error)
+	ConventionName(token security.JwtToken, device model.Device) string
+	CreateForService(token security.JwtToken, device model.Device, service model.Service) (topic string, err error)
+	CanAccessDevice(token security.JwtToken, deviceId string) (bool, error)
+	Explain(token security.JwtToken, topic string) topic.Explanation
+}
+
+// EventHandler is the subset of *platform_connector_lib.Connector used by Platform
+type EventHandler interface {
+	HandleDeviceIdentEventWithAuthToken(token security.JwtToken, deviceId string, localDeviceId string, serviceId string, localServiceId string, eventMsg platform_connector_lib.EventMsg, qos platform_connector_lib.Qos) (info platform_connector_lib.HandledDeviceInfo, err error)
+}
+
+// ServiceGenerator is called for published messages of known devices without matching service
+type ServiceGenerator func(device model.Device, topic string, payload []byte)
+
+// HomieServiceGenerator is called for homie property descriptions with the token of the publishing user.
+// device.Id is empty for unknown devices; then device carries the local id, the name and the id of the user the device may be created for.
+type HomieServiceGenerator func(token security.JwtToken, device model.Device, property homie.Property)
+
+func skipHomieServiceGeneration(security.JwtToken, model.Device, homie.Property) {}
+
+type Platform struct {
+	config                configuration.Config
+	security              Security
+	topicParser           TopicParser
+	events                EventHandler
+	hubs                  Hubs
+	serviceGenerator      ServiceGenerator
+	homieServiceGenerator HomieServiceGenerator
+	connectionLog         connectionlog.ConnectionLog
+	subscriptions         *subscriptionCache
+	sparkplugAliases      *sparkplug.Aliases
+	homieDescriptions     *homie.Descriptions
+	activity              *activity
+	rewriter              *topic.Rewriter        //config.TopicRewriteRules
+	pendingCommands       PendingCommands        //optional; see SetCommandResponses
+	commandResponses      CommandResponseHandler //optional; see SetCommandResponses
+}
+
+var _ Hooks = &Platform{}
+
+func New(config configuration.Config, security Security, topicParser TopicParser, events EventHandler, hubs Hubs, serviceGenerator ServiceGenerator, homieServiceGenerator HomieServiceGenerator, connectionLog connectionlog.ConnectionLog) *Platform {
+	if serviceGenerator == nil {
+		serviceGenerator = func(model.Device, string, []byte) {}
+	}
+	if homieServiceGenerator == nil {
+		homieServiceGenerator = skipHomieServiceGeneration
+	}
+	rewriter, err := topic.CompileRewriteRules(config.TopicRewriteRules)
+	if err != nil {
+		config.GetLogger().Error("skip topic rewrite rules", "error", err)
+	}
+	return &Platform{
+		config:                config,
+		security:              security,
+		topicParser:           topicParser,
+		events:                events,
+		hubs:                  hubs,
+		serviceGenerator:      serviceGenerator,
+		homieServiceGenerator: homieServiceGenerator,
+		connectionLog:         connectionLog,
+		subscriptions:         newSubscriptionCache(),
+		sparkplugAliases:      sparkplug.NewAliases(),
+		homieDescriptions:     homie.NewDescriptions(),
+		activity:              newActivity(),
+		rewriter:              rewriter,
+	}
+}
+
+// NewFromConnector creates a Platform with the dependencies provided by the connector; command responses are enabled if topicParser has a response topic pattern
+func NewFromConnector(config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) *Platform {
+	result := New(config, connector.Security(), topicParser, connector, devicerepo.New(config.DeviceRepoUrl, time.Duration(config.DeviceExpiration)*time.Second), func(device model.Device, topic string, payload []byte) {
+		TryCreateService(config, connector, device, topic, payload)
+		topicParser.InvalidateDeviceType(device.DeviceTypeId)
+	}, NewHomieDeviceGenerator(config, connector, topicParser).Generate, connectionLog)
+	if topicParser.CommandResponsesEnabled() {
+		result.SetCommandResponses(topicParser, connector)
+	}
+	return result
+}
+
+func (this *Platform) Authenticate(req AuthRequest) (AuthDecision, error) {
+	if req.Username == this.config.AuthClientId {
+		if this.checksConnectorSecret() && req.Password != this.config.AuthClientSecret {
+			return AuthDecision{Verdict: Deny, ReasonCode: ReasonBadUsernameOrPassword, Reason: "access denied"}, nil
+		}
+		return AuthDecision{Verdict: Allow, Superuser: true}, nil
+	}
+	remoteInfo := model.RemoteInfo{
+		Ip:       req.PeerAddr,
+		Port:     req.PeerPort,
+		Protocol: this.config.SecRemoteProtocol,
+	}
+	var token security.JwtToken
+	var err error
+	switch this.config.MqttAuthMethod {
+	case "password":
+		token, err = this.security.GetUserToken(req.Username, req.Password, remoteInfo)
+	case "certificate":
+		// The user is already authenticated by the TLS client certificate validation in the broker
+		token, err = this.security.ExchangeUserToken(req.Username, remoteInfo)
+	}
+	if err != nil {
+		return AuthDecision{Verdict: Deny, ReasonCode: authErrorReasonCode(this.config.MqttAuthMethod), Reason: err.Error()}, err
+	}
+	if token == "" {
+		return AuthDecision{Verdict: Deny, ReasonCode: authErrorReasonCode(this.config.MqttAuthMethod), Reason: "access denied"}, nil
+	}
+	if req.CleanSession != nil {
+		this.connectionLog.SetCleanSession(req.ClientId, *req.CleanSession)
+	}
+	return AuthDecision{Verdict: Allow}, nil
+}
+
+// checksConnectorSecret returns true if the connector client has to log in with config.AuthClientSecret.
+// vernemq accepts the connector client without password check, like the login webhook always did;
+// the other flavours have no further authentication, so the secret is checked unless clients authenticate with certificates
+func (this *Platform) checksConnectorSecret() bool {
+	if this.config.MqttAuthMethod == "certificate" {
+		return false
+	}
+	switch this.config.BrokerFlavour {
+	case "mosquitto", "emqx", "embedded":
+		return true
+	default:
+		return false
+	}
+}
+
+func (this *Platform) AuthorizePublish(req PublishRequest) (PublishDecision, error) {
+	if req.Username != this.config.AuthClientId {
+		if decision, ok := this.authorizeCommandResponse(req); ok {
+			return decision, nil
+		}
+	}
+	if localDeviceId, ok := conventionLocalDeviceId(req.Topic); ok && req.Username != this.config.AuthClientId {
+		decision, _, _, _, err := this.authorizeLocalDevicePublish(req, localDeviceId)
+		return decision, err
+	}
+	decision, _, _, err := this.authorizePublish(req, this.normalizeTopic(req.Username, req.Topic))
+	return decision, err
+}
+
+func (this *Platform) HandlePublish(req PublishRequest) (PublishDecision, error) {
+	if req.Username == this.config.AuthClientId {
+		return this.AuthorizePublish(req)
+	}
+	msgSize := float64(req.Size)
+	if req.Size == 0 {
+		msgSize = float64(len(req.Payload))
+	}
+	statistics.SourceReceive(msgSize, req.Username)
+	if decision, ok := this.handleCommandResponse(req, msgSize); ok {
+		return decision, nil
+	}
+	if spTopic, ok := sparkplug.ParseTopic(req.Topic); ok {
+		return this.handleSparkplugPublish(req, spTopic)
+	}
+	if hTopic, ok := homie.ParseTopic(req.Topic); ok {
+		return this.handleHomiePublish(req, hTopic, msgSize)
+	}
+	parseTopic := this.normalizeTopic(req.Username, req.Topic)
+	decision, token, matches, err := this.authorizePublish(req, parseTopic)
+	if err != nil || decision.Verdict != Allow {
+		return decision, err
+	}
+	if len(matches) == 1 && matches[0].Service.Id == "" {
+		this.serviceGenerator(matches[0].Device, parseTopic, req.Payload)
+	}
+	if !slices.ContainsFunc(matches, func(match topic.Match) bool { return match.Service.Id != "" }) {
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonTopicNameInvalid, Reason: topic.ErrNoServiceMatchFound.Error()}, nil
+	}
+	//multiple matches are only returned for AmbiguityPolicyFanOut; matches without service are skipped
+	for _, match := range matches {
+		if match.Service.Id == "" {
+			continue
+		}
+		if this.forward(token, req.Username, match.Device, match.Service, req, msgSize) == nil {
+			decision.Forwarded = true
+		}
+	}
+	if !decision.Forwarded {
+		//the message is accepted by the broker but not redirected
+		return PublishDecision{Verdict: Allow, Topic: req.Topic, Qos: req.Qos}, nil
+	}
+	return decision, nil
+}
+
+// forward sends the payload as event of the service to the platform; user is only used for statistics
+func (this *Platform) forward(token security.JwtToken, user string, device model.Device, service model.Service, req PublishRequest, msgSize float64) error {
+	info, err := this.events.HandleDeviceIdentEventWithAuthToken(token, device.Id, device.LocalId, service.Id, service.LocalId, protocolMsg(req), platform_connector_lib.Qos(req.Qos))
+	if info.DeviceId != "" && info.DeviceTypeId != "" {
+		statistics.DeviceMsgReceive(msgSize, user, info.DeviceId, info.DeviceTypeId, info.ServiceIds)
+	}
+	if err != nil {
+		this.config.GetLogger().Error("unable to handle device ident event", "error", err, "device", device.Id, "service", service.Id, "device-local-id", device.LocalId, "service-local-id", service.LocalId, "topic", req.Topic)
+		return err
+	}
+	this.activity.touch(device.Id)
+	statistics.SourceReceiveHandled(msgSize, user)
+	statistics.DeviceMsgHandled(msgSize, user, info.DeviceId, info.DeviceTypeId, info.ServiceIds)
+	return nil
+}
+
+// contentTypeSegment is the protocol segment of the mqtt 5 content type of received messages
+const contentTypeSegment = "content_type"
+
+// protocolMsg returns the protocol segments of a received message: the payload as "payload", the mqtt 5 content type as "content_type"
+// and the user properties as segments with their key. segments unknown to the protocol of the service are ignored by the platform
+func protocolMsg(req PublishRequest) map[string]string {
+	result := map[string]string{}
+	for _, property := range req.Properties.UserProperties {
+		result[property.Key] = property.Value
+	}
+	if req.Properties.ContentType != "" {
+		result[contentTypeSegment] = req.Properties.ContentType
+	}
+	result["payload"] = string(req.Payload)
+	return result
+}
+
+// authorizePublish returns the matching device of parseTopic (see normalizeTopic) with its service; the service is empty if the topic references a device but no service of it.
+// multiple matches are only returned for AmbiguityPolicyFanOut and the topic is not rewritten
+func (this *Platform) authorizePublish(req PublishRequest, parseTopic string) (decision PublishDecision, token security.JwtToken, matches []topic.Match, err error) {
+	decision = PublishDecision{Verdict: Allow, Topic: req.Topic, Qos: req.Qos}
+	if req.Username == this.config.AuthClientId {
+		decision.Superuser = true
+		return decision, token, matches, nil
+	}
+	token, err = this.security.GetCachedUserToken(req.Username, model.RemoteInfo{})
+	if err != nil {
+		this.config.GetLogger().Error("unable to get user token", "error", err, "username", req.Username)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonNotAuthorized, Reason: err.Error()}, token, matches, err
+	}
+	device, service, err := this.topicParser.Parse(token, parseTopic)
+	switch {
+	case errors.Is(err, topic.ErrNoDeviceIdCandidateFound) || errors.Is(err, topic.ErrNoDeviceMatchFound):
+		decision.Verdict = Ignore
+		decision.ReasonCode = topicErrorReasonCode(err, ReasonTopicNameInvalid)
+		decision.Reason = err.Error()
+		return decision, token, matches, nil
+	case errors.Is(err, topic.ErrMultipleMatchingDevicesFound):
+		selected, ok := this.resolveAmbiguity(token, req.Username, req.ClientId, req.Topic, true, err)
+		if !ok {
+			decision.Verdict = Deny
+			decision.ReasonCode = ReasonNotAuthorized
+			decision.Reason = err.Error()
+			return decision, token, matches, nil
+		}
+		if len(selected) > 1 {
+			return decision, token, selected, nil
+		}
+		device, service = selected[0].Device, selected[0].Service
+	case errors.Is(err, topic.ErrNoServiceMatchFound):
+		service = model.Service{}
+	case err != nil:
+		this.config.GetLogger().Error("unable to parse topic", "error", err, "topic", req.Topic)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonUnspecifiedError, Reason: err.Error()}, token, matches, err
+	}
+	decision.Topic = this.rewriteTopic(token, device, req.Topic)
+	return decision, token, []topic.Match{{Device: device, Service: service}}, nil
+}
+
+func (this *Platform) AuthorizeSubscribe(req SubscribeRequest) (result SubscribeDecision, err error) {
+	if req.Username == this.config.AuthClientId {
+		result.Superuser = true
+		for _, t := range req.Topics {
+			result.Topics = append(result.Topics, TopicDecision{Verdict: Allow, RequestedTopic: t.Topic, Topic: t.Topic, Qos: t.Qos})
+		}
+		return result, nil
+	}
+	token, err := this.security.GetCachedUserToken(req.Username, model.RemoteInfo{})
+	if err != nil {
+		return result, err
+	}
+	for _, t := range req.Topics {
+		ownerId, unprefixed, _ := splitOwnerPrefix(t.Topic)
+		if localDeviceId, ok := conventionLocalDeviceId(unprefixed); ok {
+			decision, err := this.authorizeLocalDeviceSubscribe(token, t, localDeviceId, ownerId)
+			if err != nil {
+				return result, err
+			}
+			result.Topics = append(result.Topics, decision)
+			continue
+		}
+		decision := TopicDecision{Verdict: Allow, RequestedTopic: t.Topic, Topic: t.Topic, Qos: t.Qos}
+		device, _, err := this.topicParser.Parse(token, this.normalizeTopic(req.Username, unprefixed))
+		if errors.Is(err, topic.ErrNoServiceMatchFound) {
+			//we want to only check device access
+			err = nil
+		}
+		if selected, ok := this.resolveAmbiguity(token, req.Username, req.ClientId, t.Topic, false, err); ok {
+			device, err = selected[0].Device, nil
+		}
+		if errors.Is(err, topic.ErrMultipleMatchingDevicesFound) || errors.Is(err, topic.ErrNoDeviceMatchFound) || errors.Is(err, topic.ErrNoDeviceIdCandidateFound) {
+			decision.Verdict = Deny
+			decision.ReasonCode = topicErrorReasonCode(err, ReasonTopicFilterInvalid)
+			decision.Reason = err.Error()
+			err = nil
+		}
+		if err != nil {
+			this.config.GetLogger().Warn("unable to parse topic", "error", err, "topic", t.Topic)
+			return result, err
+		}
+		if decision.Verdict == Allow && ownerId != "" && (ownerId != device.OwnerId || this.topicParser.ConventionName(token, device) == "") {
+			decision.Verdict = Deny
+			decision.ReasonCode = ReasonNotAuthorized
+			decision.Reason = "topic prefix is not the owner of the device"
+		}
+		if decision.Verdict == Allow {
+			decision.DeviceId = device.Id
+			decision.Topic = this.rewriteTopic(token, device, unprefixed)
+		}
+		result.Topics = append(result.Topics, decision)
+	}
+	return result, nil
+}
+
+func (this *Platform) Subscribed(clientId string, decision SubscribeDecision) {
+	if decision.Superuser {
+		return
+	}
+	for _, t := range decision.Topics {
+		if t.Verdict == Allow {
+			this.connectionLog.Subscribe(clientId, t.RequestedTopic, t.DeviceId)
+		}
+	}
+	this.subscriptions.invalidate(clientId)
+}
+
+func (this *Platform) Unsubscribe(req UnsubscribeRequest) error {
+	if req.Username == this.config.AuthClientId {
+		return nil
+	}
+	defer this.subscriptions.invalidate(req.ClientId)
+	token, err := this.security.GetCachedUserToken(req.Username, model.RemoteInfo{})
+	if err != nil {
+		this.config.GetLogger().Error("unable to get user token", "error", err, "username", req.Username)
+		return err
+	}
+	for _, t := range req.Topics {
+		var device model.Device
+		_, unprefixed, _ := splitOwnerPrefix(t)
+		if localDeviceId, ok := conventionLocalDeviceId(unprefixed); ok {
+			device, _, err = this.topicParser.ParseLocalDevice(token, localDeviceId)
+		} else {
+			device, _, err = this.topicParser.Parse(token, this.normalizeTopic(req.Username, unprefixed))
+		}
+		if err != nil && !errors.Is(err, topic.ErrNoServiceMatchFound) {
+			this.config.GetLogger().Error("unable to parse topic", "error", err, "topic", t)
+			return err
+		}
+		this.connectionLog.Unsubscribe(req.ClientId, t, device.Id)
+	}
+	return nil
+}
+
+func (this *Platform) ClientOnline(req OnlineRequest) {
+	if req.CleanSession != nil {
+		this.connectionLog.SetCleanSession(req.ClientId, *req.CleanSession)
+	}
+	this.connectionLog.Connect(req.ClientId)
+	this.subscriptions.invalidate(req.ClientId)
+}
+
+func (this *Platform) ClientGone(clientId string) {
+	this.connectionLog.Disconnect(clientId)
+	this.subscriptions.invalidate(clientId)
+}
+
+// conventionLocalDeviceId returns the local id of the device referenced by topics of conventions with fixed local id scheme (sparkplug, homie)
+func conventionLocalDeviceId(mqttTopic string) (localDeviceId string, ok bool) {
+	if spTopic, ok := sparkplug.ParseTopic(mqttTopic); ok {
+		return spTopic.LocalDeviceId(), true
+	}
+	if hTopic, ok := homie.ParseTopic(mqttTopic); ok {
+		return hTopic.LocalDeviceId(), true
+	}
+	return "", false
+}
+
+// rewriteTopic adds the prefix used by commands: the device id or, for devices with a topic convention, the owner id of the device
+func (this *Platform) rewriteTopic(token security.JwtToken, device model.Device, mqttTopic string) string {
+	if this.topicParser.ConventionName(token, device) != "" {
+		return topic.WithPrefix(device.OwnerId, mqttTopic)
+	}
+	return topic.WithPrefix(device.Id, mqttTopic)
+}
+
+// splitOwnerPrefix returns the owner id and the topic without it, if mqttTopic is a topic of a convention prefixed with the owner id.
+// clients of brokers without topic rewrite (or who know the prefix) subscribe to these topics instead of the convention topic.
+// device ids and short device ids are not owner ids: topics like <short-device-id>/tele/... are device topics with convention-like suffix
+func splitOwnerPrefix(mqttTopic string) (ownerId string, conventionTopic string, ok bool) {
+	if isConventionTopic(mqttTopic) {
+		return "", mqttTopic, false
+	}
+	ownerId, conventionTopic, found := strings.Cut(mqttTopic, "/")
+	if !found || ownerId == "" || ownerId == "+" || ownerId == "#" || strings.HasPrefix(ownerId, deviceIdPrefix) || shortid.IsShortId(ownerId) || !isConventionTopic(conventionTopic) {
+		return "", mqttTopic, false
+	}
+	return ownerId, conventionTopic, true
+}
+
+// isConventionTopic returns true for topics of conventions, which are prefixed with the owner id of the device instead of the device id
+func isConventionTopic(mqttTopic string) bool {
+	if _, ok := conventionLocalDeviceId(mqttTopic); ok {
+		return true
+	}
+	return topic.MatchesConvention(mqttTopic)
+}
+
+func authErrorReasonCode(authMethod string) ReasonCode {
+	if authMethod == "certificate" {
+		return ReasonNotAuthorized
+	}
+	return ReasonBadUsernameOrPassword
+}
+
+// topicErrorReasonCode returns invalidTopicCode for topics without any device reference, else ReasonNotAuthorized
+func topicErrorReasonCode(err error, invalidTopicCode ReasonCode) ReasonCode {
+	if errors.Is(err, topic.ErrNoDeviceIdCandidateFound) {
+		return invalidTopicCode
+	}
+	return ReasonNotAuthorized
+}
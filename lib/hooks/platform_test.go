@@ -0,0 +1,589 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/homie"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/sparkplug"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topicfilter"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+const testDeviceId = "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"
+const otherDeviceId = "urn:infai:ses:device:2f6c1b0e-4a53-4d6e-9a35-8f7e1c2d3b4a"
+
+func TestAuthenticate(t *testing.T) {
+	platform, connLog := newTestPlatform()
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "connector", Password: "secret"}, Allow, true))
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "connector", Password: "wrong"}, Allow, true))
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "user", Password: "user"}, Allow, false))
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "user", Password: "wrong"}, Deny, false))
+
+	platform.config.BrokerFlavour = "emqx"
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "connector", Password: "secret"}, Allow, true))
+	t.Run(testAuthenticate(platform, AuthRequest{Username: "connector", Password: "wrong"}, Deny, false))
+	platform.config.BrokerFlavour = ""
+
+	cleanSession := true
+	_, _ = platform.Authenticate(AuthRequest{Username: "user", Password: "user", ClientId: "c1", CleanSession: &cleanSession})
+	if !connLog.cleanSession["c1"] {
+		t.Error("clean session not stored")
+	}
+}
+
+func TestAuthorizePublish(t *testing.T) {
+	platform, _ := newTestPlatform()
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "connector", Topic: "foo/bar"}, Allow, "foo/bar"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "sensor/" + testDeviceId}, Allow, testDeviceId+"/sensor/"+testDeviceId))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: testDeviceId + "/sensor"}, Allow, testDeviceId+"/sensor"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: testDeviceId + "/unknown"}, Allow, testDeviceId+"/unknown"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "unknown/sensor"}, Ignore, "unknown/sensor"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "multiple/sensor"}, Deny, "multiple/sensor"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "spBv1.0/plant/DDATA/edge1/pump"}, Allow, "user/spBv1.0/plant/DDATA/edge1/pump"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "spBv1.0/plant/DDATA/edge2/pump"}, Ignore, "spBv1.0/plant/DDATA/edge2/pump"))
+}
+
+func TestAuthorizeSparkplugSubscribe(t *testing.T) {
+	platform, _ := newTestPlatform()
+	decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", ClientId: "c1", Topics: []TopicRequest{
+		{Topic: "spBv1.0/plant/DCMD/edge1/#", Qos: 1},
+		{Topic: "spBv1.0/plant/NCMD/edge2", Qos: 1},
+	}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(decision.Topics) != 2 {
+		t.Error(decision)
+		return
+	}
+	if d := decision.Topics[0]; d.Verdict != Allow || d.Topic != "user/spBv1.0/plant/DCMD/edge1/#" || d.DeviceId != testDeviceId {
+		t.Error(d)
+	}
+	if d := decision.Topics[1]; d.Verdict != Deny || d.ReasonCode != ReasonNotAuthorized {
+		t.Error(d)
+	}
+
+	decision, err = platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", ClientId: "c1", Topics: []TopicRequest{
+		{Topic: "user/spBv1.0/plant/DCMD/edge1/#", Qos: 1},
+		{Topic: "other/spBv1.0/plant/DCMD/edge1/#", Qos: 1},
+	}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if d := decision.Topics[0]; d.Verdict != Allow || d.Rewritten() || d.DeviceId != testDeviceId {
+		t.Error(d)
+	}
+	if d := decision.Topics[1]; d.Verdict != Deny || d.ReasonCode != ReasonNotAuthorized {
+		t.Error(d)
+	}
+}
+
+// TestSparkplugTenants checks that tenants with the same sparkplug group and edge node do not share topics
+func TestSparkplugTenants(t *testing.T) {
+	platform, connLog := newTestPlatform()
+	subscribe := func(username string) TopicDecision {
+		decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: username, ClientId: username, Topics: []TopicRequest{{Topic: "spBv1.0/plant/DCMD/edge1/#", Qos: 1}}})
+		if err != nil || len(decision.Topics) != 1 || decision.Topics[0].Verdict != Allow {
+			t.Error(decision, err)
+			return TopicDecision{}
+		}
+		platform.Subscribed(username, decision)
+		return decision.Topics[0]
+	}
+	userSubscription := subscribe("user")
+	otherSubscription := subscribe("other")
+	connLog.stored = []connectionlog.Subscription{{Topic: "spBv1.0/plant/DCMD/edge1/#", DeviceId: testDeviceId}}
+
+	//command to the device of "user", as published by the command handler
+	cmdTopic, err := sparkplug.CommandTopic("spBv1.0:plant:edge1:pump")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	command := topic.WithPrefix("user", cmdTopic.String())
+	if !topicfilter.Matches(userSubscription.Topic, command) {
+		t.Error("user does not receive the command", userSubscription.Topic, command)
+	}
+	if topicfilter.Matches(otherSubscription.Topic, command) {
+		t.Error("other receives the command of user", otherSubscription.Topic, command)
+	}
+	t.Run(testDeliver(platform, "user", command, "spBv1.0/plant/DCMD/edge1/pump"))
+
+	//data of the edge node of "other" is not published to the topic of "user"
+	decision, err := platform.AuthorizePublish(PublishRequest{Username: "other", Topic: "spBv1.0/plant/DDATA/edge1/pump"})
+	if err != nil || decision.Verdict != Allow || decision.Topic != "other/spBv1.0/plant/DDATA/edge1/pump" {
+		t.Error(decision, err)
+	}
+}
+
+func TestAuthorizeSubscribe(t *testing.T) {
+	platform, connLog := newTestPlatform()
+	decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", ClientId: "c1", Topics: []TopicRequest{
+		{Topic: "cmd/" + testDeviceId + "/#", Qos: 1},
+		{Topic: testDeviceId + "/cmd/#", Qos: 2},
+		{Topic: "#", Qos: 2},
+	}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(decision.Topics) != 3 || decision.Superuser {
+		t.Error(decision)
+		return
+	}
+	if d := decision.Topics[0]; d.Verdict != Allow || !d.Rewritten() || d.Topic != testDeviceId+"/cmd/"+testDeviceId+"/#" || d.Qos != 1 {
+		t.Error(d)
+	}
+	if d := decision.Topics[1]; d.Verdict != Allow || d.Rewritten() || d.DeviceId != testDeviceId {
+		t.Error(d)
+	}
+	if d := decision.Topics[2]; d.Verdict != Deny || d.Rewritten() || d.ReasonCode != ReasonNotAuthorized {
+		t.Error(d)
+	}
+	platform.Subscribed("c1", decision)
+	if len(connLog.subscriptions) != 2 || connLog.subscriptions[0] != "c1 cmd/"+testDeviceId+"/# "+testDeviceId {
+		t.Error(connLog.subscriptions)
+	}
+
+	decision, err = platform.AuthorizeSubscribe(SubscribeRequest{Username: "connector", Topics: []TopicRequest{{Topic: "#"}}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !decision.Superuser || decision.Topics[0].Verdict != Allow || decision.Topics[0].Rewritten() {
+		t.Error(decision)
+	}
+}
+
+// TestConventionTenants checks that tenants with the same zigbee2mqtt friendly name do not share topics
+func TestConventionTenants(t *testing.T) {
+	connLog := &testConnectionLog{}
+	parser := conventionTopicParser{}
+	platform := New(configuration.Config{AuthClientId: "connector"}, testSecurity{}, parser, testEventHandler{}, testHubs{}, nil, nil, connLog)
+
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "zigbee2mqtt/lamp"}, Allow, "user/zigbee2mqtt/lamp"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "other", Topic: "zigbee2mqtt/lamp"}, Allow, "other/zigbee2mqtt/lamp"))
+
+	subscribe := func(username string, filter string) TopicDecision {
+		decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: username, ClientId: username, Topics: []TopicRequest{{Topic: filter, Qos: 1}}})
+		if err != nil || len(decision.Topics) != 1 {
+			t.Error(decision, err)
+			return TopicDecision{}
+		}
+		return decision.Topics[0]
+	}
+	userSubscription := subscribe("user", "zigbee2mqtt/lamp/set")
+	otherSubscription := subscribe("other", "zigbee2mqtt/lamp/set")
+	if userSubscription.Verdict != Allow || userSubscription.Topic != "user/zigbee2mqtt/lamp/set" || userSubscription.DeviceId != testDeviceId {
+		t.Error(userSubscription)
+	}
+	if otherSubscription.Verdict != Allow || otherSubscription.Topic != "other/zigbee2mqtt/lamp/set" || otherSubscription.DeviceId != otherDeviceId {
+		t.Error(otherSubscription)
+	}
+
+	command, err := parser.CreateForService("Bearer user", model.Device{Id: testDeviceId, LocalId: "lamp", OwnerId: "user"}, model.Service{LocalId: "set"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !topicfilter.Matches(userSubscription.Topic, command) {
+		t.Error("user does not receive the command", userSubscription.Topic, command)
+	}
+	if topicfilter.Matches(otherSubscription.Topic, command) {
+		t.Error("other receives the command of user", otherSubscription.Topic, command)
+	}
+	connLog.stored = []connectionlog.Subscription{{Topic: "zigbee2mqtt/lamp/set", DeviceId: testDeviceId}}
+	t.Run(testDeliver(platform, "user", command, "zigbee2mqtt/lamp/set"))
+
+	//clients of brokers without topic rewrite subscribe with the owner prefix
+	if d := subscribe("user", "user/zigbee2mqtt/lamp/set"); d.Verdict != Allow || d.Rewritten() || d.DeviceId != testDeviceId {
+		t.Error(d)
+	}
+	if d := subscribe("user", "other/zigbee2mqtt/lamp/set"); d.Verdict != Deny || d.ReasonCode != ReasonNotAuthorized {
+		t.Error(d)
+	}
+}
+
+func TestSplitOwnerPrefix(t *testing.T) {
+	if ownerId, conventionTopic, ok := splitOwnerPrefix("user/zigbee2mqtt/lamp/set"); !ok || ownerId != "user" || conventionTopic != "zigbee2mqtt/lamp/set" {
+		t.Error(ownerId, conventionTopic, ok)
+	}
+	//device topics with convention-like suffix keep their prefix
+	for _, mqttTopic := range []string{"zigbee2mqtt/lamp/set", "a9B7ddfMShqI26yT9hqnsw/zigbee2mqtt/lamp/set", testDeviceId + "/zigbee2mqtt/lamp/set", "+/zigbee2mqtt/lamp/set"} {
+		if ownerId, conventionTopic, ok := splitOwnerPrefix(mqttTopic); ok || ownerId != "" || conventionTopic != mqttTopic {
+			t.Error(mqttTopic, ownerId, conventionTopic, ok)
+		}
+	}
+}
+
+func TestProtocolMsg(t *testing.T) {
+	msg := protocolMsg(PublishRequest{Payload: []byte("42"), Properties: message.Properties{
+		ContentType:    "application/json",
+		UserProperties: []message.UserProperty{{Key: "unit", Value: "°C"}, {Key: "payload", Value: "ignored"}},
+	}})
+	if !reflect.DeepEqual(msg, map[string]string{"payload": "42", "content_type": "application/json", "unit": "°C"}) {
+		t.Error(msg)
+	}
+	msg = protocolMsg(PublishRequest{Payload: []byte("42")})
+	if !reflect.DeepEqual(msg, map[string]string{"payload": "42"}) {
+		t.Error(msg)
+	}
+}
+
+func TestHomie(t *testing.T) {
+	generated := []homie.Property{}
+	generatedFor := []model.Device{}
+	platform := New(configuration.Config{AuthClientId: "connector"}, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, func(token security.JwtToken, device model.Device, property homie.Property) {
+		generated = append(generated, property)
+		generatedFor = append(generatedFor, device)
+	}, &testConnectionLog{})
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "homie/sensor1/climate/temperature"}, Allow, "user/homie/sensor1/climate/temperature"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "homie/sensor2/climate/temperature"}, Ignore, "homie/sensor2/climate/temperature"))
+
+	for _, msg := range []PublishRequest{
+		{Username: "user", Topic: "homie/sensor1/climate/temperature/$name", Payload: []byte("Temperature")},
+		{Username: "user", Topic: "homie/sensor1/climate/temperature/$datatype", Payload: []byte("float")},
+		{Username: "user", Topic: "homie/sensor1/climate/temperature/$settable", Payload: []byte("false")},
+		{Username: "user", Topic: "homie/sensor1/climate/target/$datatype", Payload: []byte("integer")},
+		{Username: "user", Topic: "homie/sensor1/climate/target/$settable", Payload: []byte("true")},
+	} {
+		decision, err := platform.HandlePublish(msg)
+		if err != nil || decision.Verdict != Allow || decision.Forwarded {
+			t.Error(msg.Topic, decision, err)
+		}
+	}
+	if len(generated) != 3 {
+		t.Error(generated)
+		return
+	}
+	if p := generated[0]; p.LocalServiceId() != "climate/temperature" || p.Name != "Temperature" || p.DataType != "float" || p.Settable {
+		t.Error(p)
+	}
+	if p := generated[2]; p.CommandLocalServiceId() != "climate/target/set" || p.DataType != "integer" || !p.Settable {
+		t.Error(p)
+	}
+	if d := generatedFor[0]; d.Id != testDeviceId {
+		t.Error(d)
+	}
+
+	decision, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", Topics: []TopicRequest{{Topic: "homie/sensor1/+/+/set"}}})
+	if err != nil || decision.Topics[0].Verdict != Allow || decision.Topics[0].Topic != "user/homie/sensor1/+/+/set" {
+		t.Error(decision, err)
+	}
+
+	t.Run("unknown device", func(t *testing.T) {
+		generated, generatedFor = nil, nil
+		for _, msg := range []PublishRequest{
+			{Username: "user", Topic: "homie/sensor2/$name", Payload: []byte("Living Room")},
+			{Username: "user", Topic: "homie/sensor2/climate/humidity/$datatype", Payload: []byte("float")},
+			{Username: "user", Topic: "homie/sensor2/climate/humidity", Payload: []byte("42")},
+		} {
+			decision, err := platform.HandlePublish(msg)
+			if err != nil || decision.Verdict != Ignore || decision.Forwarded {
+				t.Error(msg.Topic, decision, err)
+			}
+		}
+		if len(generatedFor) != 1 {
+			t.Error(generatedFor)
+			return
+		}
+		if d := generatedFor[0]; d.Id != "" || d.LocalId != "homie:sensor2" || d.Name != "Living Room" || d.OwnerId != "user" {
+			t.Error(d)
+		}
+		if p := generated[0]; p.LocalServiceId() != "climate/humidity" || p.DataType != "float" {
+			t.Error(p)
+		}
+	})
+
+	t.Run("tenants", func(t *testing.T) {
+		generated, generatedFor = nil, nil
+		//the homie device sensor1 of "other" is another platform device; its descriptions do not mix with those of "user"
+		decision, err := platform.HandlePublish(PublishRequest{Username: "other", Topic: "homie/sensor1/climate/target/$datatype", Payload: []byte("float")})
+		if err != nil || decision.Verdict != Allow || decision.Topic != "other/homie/sensor1/climate/target/$datatype" {
+			t.Error(decision, err)
+		}
+		if len(generated) != 1 || generatedFor[0].Id != otherDeviceId || generated[0].DataType != "float" || generated[0].Settable {
+			t.Error(generatedFor, generated)
+		}
+
+		userSubscription, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", Topics: []TopicRequest{{Topic: "homie/sensor1/+/+/set"}}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		otherSubscription, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "other", Topics: []TopicRequest{{Topic: "homie/sensor1/+/+/set"}}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		cmdTopic, err := homie.CommandTopic("homie:sensor1", "climate/target/set")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		command := topic.WithPrefix("user", cmdTopic)
+		if !topicfilter.Matches(userSubscription.Topics[0].Topic, command) {
+			t.Error("user does not receive the command", userSubscription.Topics[0].Topic, command)
+		}
+		if topicfilter.Matches(otherSubscription.Topics[0].Topic, command) {
+			t.Error("other receives the command of user", otherSubscription.Topics[0].Topic, command)
+		}
+
+		//other may not subscribe to the prefixed topics of user
+		decision2, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "other", Topics: []TopicRequest{{Topic: "user/homie/sensor1/+/+/set"}}})
+		if err != nil || decision2.Topics[0].Verdict != Deny {
+			t.Error(decision2, err)
+		}
+	})
+}
+
+func testAuthenticate(platform *Platform, req AuthRequest, expectedVerdict Verdict, expectedSuperuser bool) (string, func(t *testing.T)) {
+	return req.Username + ":" + req.Password, func(t *testing.T) {
+		decision, _ := platform.Authenticate(req)
+		if decision.Verdict != expectedVerdict || decision.Superuser != expectedSuperuser {
+			t.Error(decision)
+		}
+	}
+}
+
+func testAuthorizePublish(platform *Platform, req PublishRequest, expectedVerdict Verdict, expectedTopic string) (string, func(t *testing.T)) {
+	return req.Topic, func(t *testing.T) {
+		decision, err := platform.AuthorizePublish(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if decision.Verdict != expectedVerdict || decision.Topic != expectedTopic {
+			t.Error(decision, expectedVerdict, expectedTopic)
+		}
+	}
+}
+
+func TestExplainTopic(t *testing.T) {
+	platform, _ := newTestPlatform()
+	_, err := platform.ExplainTopic("", "", testDeviceId+"/sensor")
+	if !errors.Is(err, ErrMissingExplainUser) {
+		t.Error(err)
+	}
+	result, err := platform.ExplainTopic("user", "", testDeviceId+"/sensor")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Result.Service == nil || result.Result.Service.Id != "sensor" {
+		t.Errorf("%#v", result)
+	}
+	result, err = platform.ExplainTopic("", "Bearer user", "foo/bar")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Result.Error != topic.ErrNoDeviceMatchFound.Error() {
+		t.Errorf("%#v", result)
+	}
+}
+
+func newTestPlatform() (*Platform, *testConnectionLog) {
+	connLog := &testConnectionLog{cleanSession: map[string]bool{}}
+	config := configuration.Config{AuthClientId: "connector", AuthClientSecret: "secret", MqttAuthMethod: "password"}
+	return New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, connLog), connLog
+}
+
+type testSecurity struct{}
+
+func (this testSecurity) GetUserToken(username, password string, remoteInfo model.RemoteInfo) (security.JwtToken, error) {
+	if username != password {
+		return "", security.ErrorAccessDenied
+	}
+	return security.JwtToken("Bearer " + username), nil
+}
+
+func (this testSecurity) ExchangeUserToken(userid string, remoteInfo model.RemoteInfo) (security.JwtToken, error) {
+	return security.JwtToken("Bearer " + userid), nil
+}
+
+func (this testSecurity) GetCachedUserToken(username string, remoteInfo model.RemoteInfo) (security.JwtToken, error) {
+	return security.JwtToken("Bearer " + username), nil
+}
+
+func (this testSecurity) GetUserId(username string) (string, error) {
+	return username, nil
+}
+
+// testTopicParser knows testDeviceId with the service "sensor"; topics starting with "multiple/" match testDeviceId (owned by "user") and otherDeviceId (owned by "other")
+type testTopicParser struct{}
+
+func (this testTopicParser) Parse(token security.JwtToken, mqttTopic string) (device model.Device, service model.Service, err error) {
+	if strings.HasPrefix(mqttTopic, "multiple/") {
+		return device, service, &topic.MultipleMatchesError{Matches: []topic.Match{
+			{Device: model.Device{Id: testDeviceId, OwnerId: "user"}, Service: model.Service{Id: "sensor", LocalId: "sensor"}},
+			{Device: model.Device{Id: otherDeviceId, OwnerId: "other"}, Service: model.Service{Id: "sensor", LocalId: "sensor"}},
+		}}
+	}
+	if !strings.Contains(mqttTopic, testDeviceId) {
+		return device, service, topic.ErrNoDeviceMatchFound
+	}
+	device = model.Device{Id: testDeviceId}
+	if !strings.Contains(mqttTopic, "sensor") {
+		return device, service, topic.ErrNoServiceMatchFound
+	}
+	return device, model.Service{Id: "sensor", LocalId: "sensor"}, nil
+}
+
+// ParseLocalDevice knows the sparkplug edge node "edge1", its devices and the homie device "sensor1" with the service "temperature",
+// owned by the user of the token: testDeviceId for "user", otherDeviceId for other users
+func (this testTopicParser) ParseLocalDevice(token security.JwtToken, localDeviceId string) (device model.Device, services map[string]model.Service, err error) {
+	if !strings.HasPrefix(localDeviceId, "spBv1.0:plant:edge1") && localDeviceId != "homie:sensor1" {
+		return device, services, topic.ErrNoDeviceMatchFound
+	}
+	device = model.Device{Id: testDeviceId, LocalId: localDeviceId, OwnerId: strings.TrimPrefix(string(token), "Bearer ")}
+	if device.OwnerId != "user" {
+		device.Id = otherDeviceId
+	}
+	return device, map[string]model.Service{"temperature": {Id: "temperature", LocalId: "temperature"}}, nil
+}
+
+func (this testTopicParser) ConventionName(token security.JwtToken, device model.Device) string {
+	return ""
+}
+
+// CanAccessDevice allows "user" to access testDeviceId, "other" to access otherDeviceId and "shared" to access both
+func (this testTopicParser) CanAccessDevice(token security.JwtToken, deviceId string) (bool, error) {
+	switch strings.TrimPrefix(string(token), "Bearer ") {
+	case "user":
+		return deviceId == testDeviceId, nil
+	case "other":
+		return deviceId == otherDeviceId, nil
+	case "shared":
+		return deviceId == testDeviceId || deviceId == otherDeviceId, nil
+	default:
+		return false, nil
+	}
+}
+
+func (this testTopicParser) CreateForService(token security.JwtToken, device model.Device, service model.Service) (string, error) {
+	return device.Id + "/cmd/" + service.LocalId, nil
+}
+
+// conventionTopicParser knows the zigbee2mqtt device "lamp" of every user: testDeviceId for "user", otherDeviceId for other users
+type conventionTopicParser struct {
+	testTopicParser
+}
+
+func (this conventionTopicParser) Parse(token security.JwtToken, mqttTopic string) (device model.Device, service model.Service, err error) {
+	references := topic.Zigbee2Mqtt{BaseTopic: "zigbee2mqtt"}.Parse(mqttTopic)
+	if len(references) == 0 || references[0].LocalDeviceId != "lamp" {
+		return this.testTopicParser.Parse(token, mqttTopic)
+	}
+	device = model.Device{Id: testDeviceId, LocalId: "lamp", OwnerId: strings.TrimPrefix(string(token), "Bearer ")}
+	if device.OwnerId != "user" {
+		device.Id = otherDeviceId
+	}
+	return device, model.Service{Id: references[0].LocalServiceId, LocalId: references[0].LocalServiceId}, nil
+}
+
+func (this conventionTopicParser) ConventionName(token security.JwtToken, device model.Device) string {
+	if device.LocalId == "lamp" {
+		return "zigbee2mqtt"
+	}
+	return ""
+}
+
+func (this conventionTopicParser) CreateForService(token security.JwtToken, device model.Device, service model.Service) (string, error) {
+	if this.ConventionName(token, device) == "" {
+		return this.testTopicParser.CreateForService(token, device, service)
+	}
+	command, err := topic.Zigbee2Mqtt{BaseTopic: "zigbee2mqtt"}.Create(device.LocalId, service.LocalId)
+	return topic.WithPrefix(device.OwnerId, command), err
+}
+
+func (this testTopicParser) Explain(token security.JwtToken, mqttTopic string) (result topic.Explanation) {
+	result.Topic = mqttTopic
+	device, service, err := this.Parse(token, mqttTopic)
+	if device.Id != "" {
+		result.Result.Device = &topic.ExplainedDevice{Id: device.Id}
+	}
+	if service.Id != "" {
+		result.Result.Service = &topic.ExplainedService{Id: service.Id, LocalId: service.LocalId}
+	}
+	if err != nil {
+		result.Result.Error = err.Error()
+	}
+	return result
+}
+
+type testEventHandler struct{}
+
+func (this testEventHandler) HandleDeviceIdentEventWithAuthToken(token security.JwtToken, deviceId string, localDeviceId string, serviceId string, localServiceId string, eventMsg platform_connector_lib.EventMsg, qos platform_connector_lib.Qos) (info platform_connector_lib.HandledDeviceInfo, err error) {
+	return info, errors.New("not implemented")
+}
+
+// testHubs knows the hub "hub1" containing otherDeviceId
+type testHubs struct{}
+
+func (this testHubs) GetHub(token security.JwtToken, id string) (hub models.Hub, err error) {
+	if id != "hub1" {
+		return hub, devicerepo.ErrNotFound
+	}
+	return models.Hub{Id: id, DeviceIds: []string{otherDeviceId}}, nil
+}
+
+type testConnectionLog struct {
+	stored        []connectionlog.Subscription
+	storedErr     error
+	loads         int
+	subscriptions []string
+	cleanSession  map[string]bool
+}
+
+func (this *testConnectionLog) Connect(client string) {}
+
+func (this *testConnectionLog) Disconnect(client string) {}
+
+func (this *testConnectionLog) Subscribe(client string, topic string, deviceId string) {
+	this.subscriptions = append(this.subscriptions, client+" "+topic+" "+deviceId)
+}
+
+func (this *testConnectionLog) Unsubscribe(client string, topic string, deviceId string) {}
+
+func (this *testConnectionLog) SetCleanSession(id string, session bool) {
+	this.cleanSession[id] = session
+}
+
+func (this *testConnectionLog) Subscriptions(client string) ([]connectionlog.Subscription, error) {
+	this.loads++
+	return this.stored, this.storedErr
+}
+
+func (this *testConnectionLog) DeviceSubscribed(deviceId string) (bool, error) {
+	return true, nil
+}
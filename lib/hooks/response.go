@@ -0,0 +1,114 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/platform-connector-lib/statistics"
+)
+
+// PendingCommands is implemented by *topic.Topic
+type PendingCommands interface {
+	FindPendingCommand(mqttTopic string, accept func(topic.PendingCommand) bool) (topic.PendingCommand, bool)
+	TakePendingCommand(mqttTopic string, accept func(topic.PendingCommand) bool) (topic.PendingCommand, bool)
+	RestorePendingCommand(command topic.PendingCommand)
+}
+
+// CommandResponseHandler is the subset of *platform_connector_lib.Connector used to answer commands
+type CommandResponseHandler interface {
+	HandleCommandResponse(commandRequest model.ProtocolMsg, commandResponse platform_connector_lib.CommandResponseMsg, qos platform_connector_lib.Qos) error
+}
+
+// SetCommandResponses enables the handling of publishes to response topics of pending commands (see topic.Topic.RegisterPendingCommand)
+func (this *Platform) SetCommandResponses(pending PendingCommands, responses CommandResponseHandler) *Platform {
+	this.pendingCommands = pending
+	this.commandResponses = responses
+	return this
+}
+
+// acceptCommandResponse only accepts responses to commands of devices accessible by the user, e.g. owned or shared with the user;
+// the user id and token are resolved once, on the first pending command found
+func (this *Platform) acceptCommandResponse(username string) func(pending topic.PendingCommand) bool {
+	userId := ""
+	var token security.JwtToken
+	resolved := false
+	accessible := map[string]bool{}
+	return func(pending topic.PendingCommand) bool {
+		if !resolved {
+			resolved = true
+			var err error
+			userId, err = this.security.GetUserId(username)
+			if err != nil {
+				this.config.GetLogger().Warn("unable to get user id for command response", "error", err, "username", username)
+			}
+			token, err = this.security.GetCachedUserToken(username, model.RemoteInfo{})
+			if err != nil {
+				this.config.GetLogger().Warn("unable to get user token for command response", "error", err, "username", username)
+			}
+		}
+		device := pending.Request.Metadata.Device
+		if userId != "" && device.OwnerId == userId {
+			return true
+		}
+		if token == "" {
+			return false
+		}
+		if result, ok := accessible[device.Id]; ok {
+			return result
+		}
+		result, err := this.topicParser.CanAccessDevice(token, device.Id)
+		if err != nil {
+			this.config.GetLogger().Warn("unable to check device access for command response", "error", err, "username", username, "device", device.Id)
+		}
+		accessible[device.Id] = result
+		return result
+	}
+}
+
+// authorizeCommandResponse allows publishes to the response topic of a pending command; ok is false for other topics
+func (this *Platform) authorizeCommandResponse(req PublishRequest) (decision PublishDecision, ok bool) {
+	if this.pendingCommands == nil {
+		return decision, false
+	}
+	if _, ok = this.pendingCommands.FindPendingCommand(req.Topic, this.acceptCommandResponse(req.Username)); !ok {
+		return decision, false
+	}
+	return PublishDecision{Verdict: Allow, Topic: req.Topic, Qos: req.Qos}, true
+}
+
+// handleCommandResponse hands the payload of a publish to the response topic of a pending command to the platform as the command response;
+// ok is false for other topics. if the response can not be handled, the publish is denied and the command stays pending for a retry
+func (this *Platform) handleCommandResponse(req PublishRequest, msgSize float64) (decision PublishDecision, ok bool) {
+	if this.pendingCommands == nil || this.commandResponses == nil {
+		return decision, false
+	}
+	pending, ok := this.pendingCommands.TakePendingCommand(req.Topic, this.acceptCommandResponse(req.Username))
+	if !ok {
+		return decision, false
+	}
+	err := this.commandResponses.HandleCommandResponse(pending.Request, protocolMsg(req), platform_connector_lib.Qos(req.Qos))
+	if err != nil {
+		this.config.GetLogger().Error("unable to handle command response", "error", err, "device", pending.Request.Metadata.Device.Id, "service", pending.Request.Metadata.Service.Id, "correlation-id", pending.CorrelationId, "topic", req.Topic)
+		this.pendingCommands.RestorePendingCommand(pending)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonUnspecifiedError, Reason: err.Error()}, true
+	}
+	statistics.SourceReceiveHandled(msgSize, req.Username)
+	return PublishDecision{Verdict: Allow, Topic: req.Topic, Qos: req.Qos, Forwarded: true}, true
+}
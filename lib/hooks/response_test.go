@@ -0,0 +1,161 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/pendingcommand"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestCommandResponses(t *testing.T) {
+	topics := topic.NewFromConfig(nil, configuration.Config{CommandResponseTopicPattern: "response/{{.CorrelationId}}"}, nil)
+	responses := &testCommandResponses{}
+	config := configuration.Config{AuthClientId: "connector"}
+	platform := New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, connectionlog.Void).SetCommandResponses(topics, responses)
+
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testDeviceId, OwnerId: "user"}, Service: model.Service{Id: "set", LocalId: "set"}}}
+	pending, err := topics.RegisterPendingCommand(testDeviceId+"/set", request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	//responses are only accepted from users with access to the device
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "other", Topic: pending.ResponseTopic}, Ignore, pending.ResponseTopic))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "shared", Topic: pending.ResponseTopic}, Allow, pending.ResponseTopic))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: pending.ResponseTopic}, Allow, pending.ResponseTopic))
+
+	decision, err := platform.HandlePublish(PublishRequest{Username: "user", Topic: pending.ResponseTopic, Payload: []byte("ok")})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if decision.Verdict != Allow || !decision.Forwarded || decision.Rewritten(PublishRequest{Topic: pending.ResponseTopic}) {
+		t.Errorf("%#v", decision)
+	}
+	if len(responses.handled) != 1 || responses.handled[0].request.Metadata.Device.Id != testDeviceId || responses.handled[0].response["payload"] != "ok" {
+		t.Errorf("%#v", responses.handled)
+	}
+
+	//the pending command is answered only once
+	decision, err = platform.HandlePublish(PublishRequest{Username: "user", Topic: pending.ResponseTopic, Payload: []byte("ok")})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if decision.Verdict != Ignore || len(responses.handled) != 1 {
+		t.Errorf("%#v", decision)
+	}
+
+	responses.err = errors.New("test")
+	pending, err = topics.RegisterPendingCommand(testDeviceId+"/set", request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	decision, _ = platform.HandlePublish(PublishRequest{Username: "user", Topic: pending.ResponseTopic, Payload: []byte("ok")})
+	if decision.Verdict != Deny || decision.ReasonCode != ReasonUnspecifiedError || decision.Forwarded {
+		t.Errorf("%#v", decision)
+	}
+
+	//a response that could not be handled may be retried
+	responses.err = nil
+	decision, _ = platform.HandlePublish(PublishRequest{Username: "user", Topic: pending.ResponseTopic, Payload: []byte("ok")})
+	if decision.Verdict != Allow || !decision.Forwarded || len(responses.handled) != 2 {
+		t.Errorf("%#v", decision)
+	}
+}
+
+func TestSharedCommandResponses(t *testing.T) {
+	config := configuration.Config{AuthClientId: "connector", CommandResponseTopicPattern: "response/{{.CorrelationId}}"}
+	store := pendingcommand.NewMemory()
+	sender := topic.NewFromConfig(nil, config, nil).SetPendingCommands(store)
+	receiver := topic.NewFromConfig(nil, config, nil).SetPendingCommands(store)
+	responses := &testCommandResponses{}
+	platform := New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, connectionlog.Void).SetCommandResponses(receiver, responses)
+
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testDeviceId, OwnerId: "user"}, Service: model.Service{Id: "set", LocalId: "set"}}}
+	pending, err := sender.RegisterPendingCommand(testDeviceId+"/set", request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	//a response reaching an other instance than the sender is handled by that instance
+	decision, err := platform.HandlePublish(PublishRequest{Username: "shared", Topic: pending.ResponseTopic, Payload: []byte("ok")})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if decision.Verdict != Allow || !decision.Forwarded {
+		t.Errorf("%#v", decision)
+	}
+	if len(responses.handled) != 1 || responses.handled[0].request.Metadata.Device.Id != testDeviceId {
+		t.Errorf("%#v", responses.handled)
+	}
+}
+
+func TestIngestedCommandResponses(t *testing.T) {
+	config := configuration.Config{AuthClientId: "connector", CommandResponseTopicPattern: "response/{{.CorrelationId}}"}
+	topics := topic.NewFromConfig(nil, config, nil)
+	responses := &testCommandResponses{}
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testDeviceId, OwnerId: "user"}, Service: model.Service{Id: "set", LocalId: "set"}}}
+	pending, err := topics.RegisterPendingCommand(testDeviceId+"/set", request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	//the publisher is unknown: responses are ignored unless the broker authorizes publishes
+	platform := New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, connectionlog.Void).SetCommandResponses(topics, responses)
+	err = platform.HandleIngestedPublish(pending.ResponseTopic, []byte("ok"), 1)
+	if !errors.Is(err, ErrIngestedCommandResponse) || len(responses.handled) != 0 {
+		t.Error(err, responses.handled)
+	}
+
+	config.IngestionCommandResponses = true
+	platform = New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, connectionlog.Void).SetCommandResponses(topics, responses)
+	err = platform.HandleIngestedPublish(pending.ResponseTopic, []byte("ok"), 1)
+	if err != nil || len(responses.handled) != 1 {
+		t.Error(err, responses.handled)
+	}
+}
+
+type handledCommandResponse struct {
+	request  model.ProtocolMsg
+	response platform_connector_lib.CommandResponseMsg
+}
+
+type testCommandResponses struct {
+	handled []handledCommandResponse
+	err     error
+}
+
+func (this *testCommandResponses) HandleCommandResponse(commandRequest model.ProtocolMsg, commandResponse platform_connector_lib.CommandResponseMsg, qos platform_connector_lib.Qos) error {
+	if this.err != nil {
+		return this.err
+	}
+	this.handled = append(this.handled, handledCommandResponse{request: commandRequest, response: commandResponse})
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+// normalizeTopic applies config.TopicRewriteRules of the user before topics are parsed.
+// decisions keep the requested topic, so the broker rewrites and the connection log use the topic of the client
+func (this *Platform) normalizeTopic(username string, mqttTopic string) string {
+	userId := ""
+	if username != "" && this.rewriter.HasUserRules() {
+		var err error
+		userId, err = this.security.GetUserId(username)
+		if err != nil {
+			this.config.GetLogger().Warn("unable to get user id for topic rewrite rules; only global rules are applied", "error", err, "username", username)
+		}
+	}
+	return this.rewriter.Rewrite(userId, mqttTopic)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+)
+
+func TestTopicRewriteRules(t *testing.T) {
+	config := configuration.Config{AuthClientId: "connector", TopicRewriteRules: []configuration.TopicRewriteRule{
+		{Pattern: `^acme/v1/(.+)/(urn:infai:ses:device:[^/]+)$`, Replacement: "$2/$1"},
+		{Pattern: `^legacy/(.+)$`, Replacement: testDeviceId + "/$1", UserId: "user"},
+	}}
+	platform := New(config, testSecurity{}, testTopicParser{}, testEventHandler{}, testHubs{}, nil, nil, &testConnectionLog{})
+
+	//decisions keep the requested topic
+	legacyTopic := "acme/v1/sensor/" + testDeviceId
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: legacyTopic}, Allow, testDeviceId+"/"+legacyTopic))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "user", Topic: "legacy/sensor"}, Allow, testDeviceId+"/legacy/sensor"))
+	t.Run(testAuthorizePublish(platform, PublishRequest{Username: "other", Topic: "legacy/sensor"}, Ignore, "legacy/sensor"))
+
+	result, err := platform.AuthorizeSubscribe(SubscribeRequest{Username: "user", Topics: []TopicRequest{{Topic: legacyTopic}}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result.Topics) != 1 || result.Topics[0].Verdict != Allow || result.Topics[0].DeviceId != testDeviceId || result.Topics[0].Topic != testDeviceId+"/"+legacyTopic {
+		t.Errorf("%#v", result)
+	}
+
+	explanation, err := platform.ExplainTopic("user", "", "legacy/sensor")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if explanation.RewrittenFrom != "legacy/sensor" {
+		t.Errorf("%#v", explanation)
+	}
+}
@@ -0,0 +1,433 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/homie"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+const GenerateServiceAttr = topic.GenerateServiceAttr
+
+func TryCreateService(config configuration.Config, connector *platform_connector_lib.Connector, device model.Device, topic string, payload []byte) {
+	token := security.JwtToken(client.InternalAdminToken)
+	dt, ok := getGeneratingDeviceType(config, connector, token, device)
+	if !ok {
+		return
+	}
+
+	short, err := shortid.ShortId(device.Id)
+	if err != nil {
+		config.GetLogger().Error("unable to shorten device id", "error", err, "device-id", device.Id)
+		return
+	}
+
+	var parts []string
+	if strings.Contains(topic, device.Id) {
+		parts = strings.Split(topic, device.Id)
+	} else if strings.Contains(topic, short) {
+		parts = strings.Split(topic, short)
+	} else if strings.Contains(topic, device.LocalId) {
+		parts = strings.Split(topic, device.LocalId)
+	}
+
+	parts = slices.DeleteFunc(parts, func(a string) bool {
+		if a == "" {
+			return true
+		}
+		for _, service := range dt.Services {
+			if service.LocalId == a {
+				return true
+			}
+		}
+		return false
+	})
+	if len(parts) == 0 {
+		config.GetLogger().Warn("unable to find valid/unused topic part to generate new service", "topic", topic, "device-id", device.Id)
+		return
+	}
+	serviceLocalId := slices.MaxFunc(parts, func(a, b string) int {
+		return len(a) - len(b)
+	})
+
+	service := models.Service{
+		LocalId:     serviceLocalId,
+		Name:        serviceLocalId,
+		Interaction: models.EVENT,
+		Outputs: []models.Content{{
+			Serialization: models.JSON,
+		}},
+	}
+
+	service.Outputs[0].ContentVariable, err = jsonValueToContentVariable("payload", payload)
+	if errors.Is(err, NotJsonErr) {
+		service.Outputs = []models.Content{{
+			ContentVariable: models.ContentVariable{
+				Name: "payload",
+				Type: models.String,
+			},
+			Serialization: models.PlainText,
+		}}
+		err = nil
+	}
+	if err != nil {
+		config.GetLogger().Error("unable to generate content variable", "error", err, "device-id", device.Id, "topic", topic, "payload", string(payload))
+		return
+	}
+
+	if !setProtocol(dt, &service.ProtocolId, service.Outputs) {
+		config.GetLogger().Warn("unable to find protocol-id and protocol-segment-id for new service", "device-type-id", device.DeviceTypeId, "topic", topic)
+		return
+	}
+
+	dt.Services = append(dt.Services, service)
+
+	_, err = connector.IotCache.UpdateDeviceType(token, dt)
+	if err != nil {
+		config.GetLogger().Error("unable to update device type with generated service", "error", err, "device-type-id", device.DeviceTypeId)
+		return
+	}
+
+}
+
+// getGeneratingDeviceType returns false if the device-type does not carry the GenerateServiceAttr
+func getGeneratingDeviceType(config configuration.Config, connector *platform_connector_lib.Connector, token security.JwtToken, device model.Device) (dt models.DeviceType, ok bool) {
+	dt, err := connector.IotCache.GetDeviceType(token, device.DeviceTypeId)
+	if err != nil {
+		config.GetLogger().Error("unable to get device type", "error", err, "device-type-id", device.DeviceTypeId)
+		return dt, false
+	}
+	if !slices.ContainsFunc(dt.Attributes, func(a models.Attribute) bool {
+		return a.Key == GenerateServiceAttr && strings.ToLower(strings.TrimSpace(a.Value)) == "true"
+	}) {
+		return dt, false //device-type does not wish to let services be generated --> ignore
+	}
+	return dt, true
+}
+
+// setProtocol copies the protocol-id and protocol-segment-id of existing services of the device-type to the new service
+func setProtocol(dt models.DeviceType, protocolId *string, contents []models.Content) bool {
+	segmentId := ""
+	for _, s := range dt.Services {
+		*protocolId = s.ProtocolId
+		for _, c := range append(slices.Clone(s.Outputs), s.Inputs...) {
+			segmentId = c.ProtocolSegmentId
+			break
+		}
+		if *protocolId != "" && segmentId != "" {
+			break
+		}
+	}
+	if *protocolId == "" || segmentId == "" {
+		return false
+	}
+	for i := range contents {
+		contents[i].ProtocolSegmentId = segmentId
+	}
+	return true
+}
+
+var NotJsonErr = errors.New("not json")
+
+func jsonValueToContentVariable(name string, data []byte) (result models.ContentVariable, err error) {
+	var obj interface{}
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return result, errors.Join(err, NotJsonErr)
+	}
+	return valueToContentVariable(name, obj), nil
+}
+
+func valueToContentVariable(name string, data interface{}) (result models.ContentVariable) {
+	result = models.ContentVariable{
+		Name:                name,
+		IsVoid:              false,
+		SubContentVariables: nil,
+		OmitEmpty:           true,
+	}
+	switch v := data.(type) {
+	case string:
+		result.Type = models.String
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		result.Type = models.Float
+	case bool:
+		result.Type = models.Boolean
+	case []interface{}:
+		result.Type = models.List
+		sub := []models.ContentVariable{}
+		for i, e := range v {
+			sub = append(sub, valueToContentVariable(strconv.Itoa(i), e))
+		}
+		result.SubContentVariables = sub
+	case map[string]interface{}:
+		result.Type = models.Structure
+		sub := []models.ContentVariable{}
+		for k, e := range v {
+			sub = append(sub, valueToContentVariable(k, e))
+		}
+		result.SubContentVariables = sub
+	default:
+		result.Type = models.Type(reflect.TypeOf(v).String())
+	}
+	return result
+}
+
+// TryCreateHomieServices adds an event service for the homie property and a request service if it is settable,
+// if the device-type carries the GenerateServiceAttr and the services do not exist
+func TryCreateHomieServices(config configuration.Config, connector *platform_connector_lib.Connector, device model.Device, property homie.Property) {
+	token := security.JwtToken(client.InternalAdminToken)
+	dt, ok := getGeneratingDeviceType(config, connector, token, device)
+	if !ok {
+		return
+	}
+	newServices := missingHomieServices(dt, property)
+	if len(newServices) == 0 {
+		return
+	}
+	if !setHomieProtocol(dt, newServices) {
+		config.GetLogger().Warn("unable to find protocol-id and protocol-segment-id for new service", "device-type-id", device.DeviceTypeId, "property", property.LocalServiceId())
+		return
+	}
+	dt.Services = append(dt.Services, newServices...)
+	_, err := connector.IotCache.UpdateDeviceType(token, dt)
+	if err != nil {
+		config.GetLogger().Error("unable to update device type with generated homie services", "error", err, "device-type-id", device.DeviceTypeId)
+		return
+	}
+}
+
+// missingHomieServices returns the event service and, if the property is settable, the request service of the property, which dt does not contain
+func missingHomieServices(dt models.DeviceType, property homie.Property) (newServices []models.Service) {
+	name := property.Name
+	if name == "" {
+		name = property.LocalServiceId()
+	}
+	content := func() []models.Content {
+		return []models.Content{{
+			ContentVariable: models.ContentVariable{
+				Name: "value",
+				Type: homieDataTypeToType(property.DataType),
+			},
+			Serialization: models.PlainText,
+		}}
+	}
+	if !slices.ContainsFunc(dt.Services, func(s models.Service) bool { return s.LocalId == property.LocalServiceId() }) {
+		newServices = append(newServices, models.Service{
+			LocalId:     property.LocalServiceId(),
+			Name:        name,
+			Interaction: models.EVENT,
+			Outputs:     content(),
+		})
+	}
+	if property.Settable && !slices.ContainsFunc(dt.Services, func(s models.Service) bool { return s.LocalId == property.CommandLocalServiceId() }) {
+		newServices = append(newServices, models.Service{
+			LocalId:     property.CommandLocalServiceId(),
+			Name:        "set " + name,
+			Interaction: models.REQUEST,
+			Inputs:      content(),
+		})
+	}
+	return newServices
+}
+
+// setHomieProtocol copies the protocol of the services of dt to the services
+func setHomieProtocol(dt models.DeviceType, services []models.Service) bool {
+	for i := range services {
+		service := &services[i]
+		if !setProtocol(dt, &service.ProtocolId, service.Outputs) || !setProtocol(dt, &service.ProtocolId, service.Inputs) {
+			return false
+		}
+	}
+	return true
+}
+
+// HomieDeviceGenerator generates services from homie property descriptions (see TryCreateHomieServices)
+// and creates a device type and a device for descriptions of unknown homie devices, if config.HomieDeviceTypeTemplate is set.
+// device types are created from the template with the GenerateServiceAttr, so services of further properties are added to them.
+type HomieDeviceGenerator struct {
+	config      configuration.Config
+	connector   *platform_connector_lib.Connector
+	topicParser *topic.Topic
+	locks       keyLocks //serializes the generation per owner id and local id
+	mux         sync.Mutex
+	created     map[string]homieCreation //owner id and local id of devices created but not yet known to the topic parser
+	lastSweep   time.Time
+}
+
+type homieCreation struct {
+	deviceTypeId  string
+	deviceCreated bool
+	updated       time.Time
+}
+
+// created devices are expected to be known to the topic parser after homieCreationExpiration
+const homieCreationExpiration = time.Hour
+
+// expired creations are removed at most once per homieCreationSweepInterval, when a creation is stored
+const homieCreationSweepInterval = time.Minute
+
+func NewHomieDeviceGenerator(config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic) *HomieDeviceGenerator {
+	return &HomieDeviceGenerator{config: config, connector: connector, topicParser: topicParser, created: map[string]homieCreation{}, lastSweep: time.Now()}
+}
+
+// Generate is the HomieServiceGenerator of the platform.
+// descriptions of the same device are handled one after another; descriptions of other devices are not blocked by device-repository calls
+func (this *HomieDeviceGenerator) Generate(token security.JwtToken, device model.Device, property homie.Property) {
+	key := device.OwnerId + "/" + device.LocalId
+	unlock := this.locks.lock(key)
+	defer unlock()
+	if device.Id != "" {
+		this.forgetCreation(key)
+	} else {
+		creation, ok := this.getCreation(key)
+		if !ok || !creation.deviceCreated {
+			this.tryCreateDevice(token, device, property, creation)
+			return
+		}
+		//descriptions arrive in quick succession; the created device may not be known to the topic parser yet
+		device.DeviceTypeId = creation.deviceTypeId
+	}
+	TryCreateHomieServices(this.config, this.connector, device, property)
+	this.topicParser.InvalidateDeviceType(device.DeviceTypeId)
+}
+
+func (this *HomieDeviceGenerator) getCreation(key string) (creation homieCreation, ok bool) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	creation, ok = this.created[key]
+	if ok && time.Since(creation.updated) >= homieCreationExpiration {
+		return homieCreation{}, false
+	}
+	return creation, ok
+}
+
+func (this *HomieDeviceGenerator) setCreation(key string, creation homieCreation) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if now.Sub(this.lastSweep) >= homieCreationSweepInterval {
+		this.sweep(now)
+		this.lastSweep = now
+	}
+	creation.updated = now
+	this.created[key] = creation
+}
+
+func (this *HomieDeviceGenerator) forgetCreation(key string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.created, key)
+}
+
+// sweep removes expired creations; callers must hold the lock
+func (this *HomieDeviceGenerator) sweep(now time.Time) {
+	maps.DeleteFunc(this.created, func(_ string, creation homieCreation) bool {
+		return now.Sub(creation.updated) >= homieCreationExpiration
+	})
+}
+
+// keyLocks provides a mutex per key; unused mutexes are removed
+type keyLocks struct {
+	mux   sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	users int
+}
+
+// lock blocks until the mutex of the key is locked; unlock must be called exactly once
+func (this *keyLocks) lock(key string) (unlock func()) {
+	this.mux.Lock()
+	if this.locks == nil {
+		this.locks = map[string]*keyLock{}
+	}
+	l, ok := this.locks[key]
+	if !ok {
+		l = &keyLock{}
+		this.locks[key] = l
+	}
+	l.users++
+	this.mux.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		this.mux.Lock()
+		defer this.mux.Unlock()
+		l.users--
+		if l.users == 0 {
+			delete(this.locks, key)
+		}
+	}
+}
+
+// tryCreateDevice creates the device type with the services of the property (unless creation contains a device type created before) and the device for the owner of the token
+func (this *HomieDeviceGenerator) tryCreateDevice(token security.JwtToken, device model.Device, property homie.Property, creation homieCreation) {
+	if this.config.HomieDeviceTypeTemplate == "" || this.config.HomieDeviceTypeTemplate == "-" {
+		return
+	}
+	key := device.OwnerId + "/" + device.LocalId
+	if creation.deviceTypeId == "" {
+		template, err := this.connector.IotCache.GetDeviceType(security.JwtToken(client.InternalAdminToken), this.config.HomieDeviceTypeTemplate)
+		if err != nil {
+			this.config.GetLogger().Error("unable to get homie device type template", "error", err, "device-type-id", this.config.HomieDeviceTypeTemplate)
+			return
+		}
+		services := missingHomieServices(models.DeviceType{}, property)
+		if !setHomieProtocol(template, services) {
+			this.config.GetLogger().Warn("unable to find protocol-id and protocol-segment-id in homie device type template", "device-type-id", this.config.HomieDeviceTypeTemplate)
+			return
+		}
+		attributes := slices.DeleteFunc(slices.Clone(template.Attributes), func(a models.Attribute) bool { return a.Key == GenerateServiceAttr })
+		dt, err := this.connector.IotCache.CreateDeviceType(token, models.DeviceType{
+			Name:          device.Name,
+			Description:   "generated from the homie description of " + device.LocalId,
+			DeviceClassId: template.DeviceClassId,
+			Attributes:    append(attributes, models.Attribute{Key: GenerateServiceAttr, Value: "true"}),
+			Services:      services,
+		})
+		if err != nil {
+			this.config.GetLogger().Error("unable to create homie device type", "error", err, "local-device-id", device.LocalId)
+			return
+		}
+		creation.deviceTypeId = dt.Id
+		this.setCreation(key, creation)
+	}
+	_, err := this.connector.IotCache.CreateDevice(token, model.Device{LocalId: device.LocalId, Name: device.Name, DeviceTypeId: creation.deviceTypeId})
+	if err != nil {
+		this.config.GetLogger().Error("unable to create homie device", "error", err, "local-device-id", device.LocalId, "device-type-id", creation.deviceTypeId)
+		return
+	}
+	creation.deviceCreated = true
+	this.setCreation(key, creation)
+	this.config.GetLogger().Info("created homie device", "local-device-id", device.LocalId, "device-type-id", creation.deviceTypeId, "owner", device.OwnerId)
+}
+
+func homieDataTypeToType(dataType string) models.Type {
+	switch dataType {
+	case "integer":
+		return models.Integer
+	case "float":
+		return models.Float
+	case "boolean":
+		return models.Boolean
+	default:
+		return models.String
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"encoding/json"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/sparkplug"
+)
+
+// handleSparkplugPublish forwards every metric of birth and data messages as event of the service with the metric name as local id.
+// sparkplug topics are prefixed with the owner id of the device; sparkplug applications of the owner may subscribe to them.
+func (this *Platform) handleSparkplugPublish(req PublishRequest, spTopic sparkplug.Topic) (PublishDecision, error) {
+	decision, token, device, services, err := this.authorizeLocalDevicePublish(req, spTopic.LocalDeviceId())
+	if err != nil || decision.Verdict != Allow || !spTopic.IsData() {
+		return decision, err
+	}
+	payload, err := sparkplug.Decode(req.Payload)
+	if err != nil {
+		this.config.GetLogger().Warn("unable to decode sparkplug payload", "error", err, "topic", req.Topic)
+		return PublishDecision{Verdict: Deny, Topic: req.Topic, Qos: req.Qos, ReasonCode: ReasonPayloadFormatInvalid, Reason: err.Error()}, nil
+	}
+	this.sparkplugAliases.Apply(device.OwnerId, spTopic, &payload)
+	for _, metric := range payload.Metrics {
+		service, ok := services[metric.Name]
+		if !ok {
+			this.config.GetLogger().Debug("no service for sparkplug metric", "metric", metric.Name, "device", device.Id, "topic", req.Topic)
+			continue
+		}
+		event, err := json.Marshal(metric.Event(payload.Timestamp))
+		if err != nil {
+			this.config.GetLogger().Warn("unable to marshal sparkplug metric", "error", err, "metric", metric.Name, "topic", req.Topic)
+			continue
+		}
+		err = this.forward(token, req.Username, device, service, PublishRequest{
+			Username: req.Username,
+			ClientId: req.ClientId,
+			Topic:    req.Topic,
+			Payload:  event,
+			Qos:      req.Qos,
+		}, float64(len(event)))
+		if err == nil {
+			decision.Forwarded = true
+		}
+	}
+	return decision, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package hooks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+)
+
+// cached subscriptions of clients expire after subscriptionCacheExpiration, because they may change on other connector instances
+const subscriptionCacheExpiration = time.Minute
+
+// cached subscriptions that do not match a delivered topic are reloaded, at most once per subscriptionCacheRefreshInterval
+const subscriptionCacheRefreshInterval = time.Second
+
+// subscriptionCache holds the subscriptions of clients loaded from the connection-log, to not query it for every delivered message.
+// entries are invalidated by subscribe, unsubscribe, connect and disconnect of the client
+type subscriptionCache struct {
+	mux       sync.Mutex
+	entries   map[string]subscriptionCacheEntry
+	lastSweep time.Time
+}
+
+type subscriptionCacheEntry struct {
+	subscriptions []connectionlog.Subscription
+	loaded        time.Time
+}
+
+func newSubscriptionCache() *subscriptionCache {
+	return &subscriptionCache{entries: map[string]subscriptionCacheEntry{}}
+}
+
+func (this *subscriptionCache) get(clientId string) (entry subscriptionCacheEntry, ok bool) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	entry, ok = this.entries[clientId]
+	return entry, ok
+}
+
+func (this *subscriptionCache) set(clientId string, subscriptions []connectionlog.Subscription) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if now.Sub(this.lastSweep) >= subscriptionCacheExpiration {
+		this.sweep(now)
+		this.lastSweep = now
+	}
+	this.entries[clientId] = subscriptionCacheEntry{subscriptions: subscriptions, loaded: now}
+}
+
+func (this *subscriptionCache) invalidate(clientId string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.entries, clientId)
+}
+
+// sweep drops expired entries; expects a locked mux
+func (this *subscriptionCache) sweep(now time.Time) {
+	for clientId, entry := range this.entries {
+		if now.Sub(entry.loaded) >= subscriptionCacheExpiration {
+			delete(this.entries, clientId)
+		}
+	}
+}
+
+// clientSubscriptions returns the cached subscriptions of the client, if they are younger than maxAge, else they are loaded from the connection-log.
+// if the connection-log fails, older cached subscriptions are used
+func (this *Platform) clientSubscriptions(clientId string, maxAge time.Duration) ([]connectionlog.Subscription, error) {
+	entry, cached := this.subscriptions.get(clientId)
+	if cached && time.Since(entry.loaded) < maxAge {
+		return entry.subscriptions, nil
+	}
+	subscriptions, err := this.connectionLog.Subscriptions(clientId)
+	if err != nil {
+		if cached {
+			this.config.GetLogger().Warn("unable to load subscriptions, use cached subscriptions", "error", err, "clientId", clientId)
+			return entry.subscriptions, nil
+		}
+		return nil, err
+	}
+	this.subscriptions.set(clientId, subscriptions)
+	return subscriptions, nil
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"errors"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+)
+
+// StartIngestion subscribes config.IngestionSubscription with the connectors own mqtt client.
+// with a shared subscription ($share/<group>/<filter>) multiple connector instances share the message stream.
+// the subscription uses qos 2, so messages are received and forwarded with the qos they were published with.
+func StartIngestion(config configuration.Config, mqtt Mqtt, platform *hooks.Platform) error {
+	config.GetLogger().Info("start ingestion subscription", "topic", config.IngestionSubscription)
+	return mqtt.Subscribe(config.IngestionSubscription, 2, func(mqttTopic string, payload []byte, qos byte) {
+		err := platform.HandleIngestedPublish(mqttTopic, payload, int(qos))
+		if errors.Is(err, hooks.ErrCommandTopic) || errors.Is(err, hooks.ErrIngestedCommandResponse) || errors.Is(err, topic.ErrNoDeviceIdCandidateFound) || errors.Is(err, topic.ErrNoDeviceMatchFound) {
+			config.GetLogger().Debug("ignore ingested message", "reason", err, "topic", mqttTopic)
+			return
+		}
+		if err != nil {
+			config.GetLogger().Error("unable to handle ingested message", "error", err, "topic", mqttTopic)
+		}
+	})
+}
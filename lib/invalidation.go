@@ -0,0 +1,133 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/segmentio/kafka-go"
+)
+
+// changeCommand is the relevant part of the device and device-type change messages of the device-manager
+type changeCommand struct {
+	Command string `json:"command"`
+	Id      string `json:"id"`
+}
+
+const deleteChangeCommand = "DELETE"
+
+// TopicInvalidation is implemented by *topic.Topic
+type TopicInvalidation interface {
+	InvalidateDeviceType(deviceTypeId string)
+	RemoveDeviceType(deviceTypeId string)
+	InvalidateDevice(deviceId string)
+	RemoveDevice(deviceId string)
+}
+
+var _ TopicInvalidation = &topic.Topic{}
+
+// StartTopicInvalidation consumes config.DeviceTypeTopic and config.DeviceTopic to evict the topic parser state of changed device-types and devices.
+// every connector instance has to receive all changes, so all partitions are read from the latest offset without consumer group;
+// changes while the instance is stopped are not needed, because its caches are empty after a restart. empty or "-" topics are not consumed
+func StartTopicInvalidation(ctx context.Context, config configuration.Config, topics TopicInvalidation) error {
+	err := consumeLatest(ctx, config, config.DeviceTypeTopic, func(command changeCommand) {
+		handleDeviceTypeChange(topics, command)
+	})
+	if err != nil {
+		return err
+	}
+	return consumeLatest(ctx, config, config.DeviceTopic, func(command changeCommand) {
+		handleDeviceChange(topics, command)
+	})
+}
+
+// consumeLatest reads every partition of the kafka topic, starting with messages produced after the call, until ctx is done
+func consumeLatest(ctx context.Context, config configuration.Config, kafkaTopic string, handler func(command changeCommand)) error {
+	if kafkaTopic == "" || kafkaTopic == "-" {
+		return nil
+	}
+	maxWait, err := time.ParseDuration(config.KafkaConsumerMaxWait)
+	if err != nil {
+		maxWait = time.Second
+	}
+	conn, err := kafka.DialContext(ctx, "tcp", config.KafkaUrl)
+	if err != nil {
+		return err
+	}
+	partitions, err := conn.ReadPartitions(kafkaTopic)
+	conn.Close()
+	if err != nil {
+		return err
+	}
+	for _, partition := range partitions {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:   []string{config.KafkaUrl},
+			Topic:     kafkaTopic,
+			Partition: partition.ID,
+			MinBytes:  int(config.KafkaConsumerMinBytes),
+			MaxBytes:  int(config.KafkaConsumerMaxBytes),
+			MaxWait:   maxWait,
+		})
+		err = reader.SetOffset(kafka.LastOffset)
+		if err != nil {
+			reader.Close()
+			return err
+		}
+		go func() {
+			defer reader.Close()
+			for {
+				msg, err := reader.ReadMessage(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					config.GetLogger().Error("topic invalidation consumer error", "topic", kafkaTopic, "partition", partition.ID, "error", err)
+					return
+				}
+				command := changeCommand{}
+				err = json.Unmarshal(msg.Value, &command)
+				if err != nil {
+					config.GetLogger().Warn("skip invalid change message", "topic", kafkaTopic, "error", err)
+					continue
+				}
+				handler(command)
+			}
+		}()
+	}
+	return nil
+}
+
+// handleDeviceTypeChange rebuilds the service index on the next use after updates
+func handleDeviceTypeChange(topics TopicInvalidation, command changeCommand) {
+	if command.Command == deleteChangeCommand {
+		topics.RemoveDeviceType(command.Id)
+		return
+	}
+	topics.InvalidateDeviceType(command.Id)
+}
+
+func handleDeviceChange(topics TopicInvalidation, command changeCommand) {
+	if command.Command == deleteChangeCommand {
+		topics.RemoveDevice(command.Id)
+		return
+	}
+	topics.InvalidateDevice(command.Id)
+}
@@ -2,19 +2,29 @@ package lib
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/association"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/commandbuffer"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/embedded"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/homie"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/pendingcommand"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/sparkplug"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/mosquitto"
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
 	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
 	"github.com/SENERGY-Platform/platform-connector-lib/kafka"
 	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
 	"github.com/SENERGY-Platform/platform-connector-lib/statistics"
 	paho "github.com/eclipse/paho.mqtt.golang"
 )
@@ -27,11 +37,6 @@ func Start(basectx context.Context, config configuration.Config) (err error) {
 		}
 	}()
 
-	asyncFlushFrequency, err := time.ParseDuration(config.AsyncFlushFrequency)
-	if err != nil {
-		return err
-	}
-
 	if config.KafkaProducerSlowTimeoutSec != 0 {
 		kafka.SlowProducerTimeout = time.Duration(config.KafkaProducerSlowTimeoutSec) * time.Second
 	}
@@ -62,6 +67,162 @@ func Start(basectx context.Context, config configuration.Config) (err error) {
 		paho.DEBUG = pahoDebugLogger
 	}
 
+	err = topic.ValidateConfig(config)
+	if err != nil {
+		return err
+	}
+	err = hooks.ValidateAmbiguityPolicy(config.AmbiguityPolicy)
+	if err != nil {
+		return err
+	}
+	if config.AmbiguityPolicy == hooks.AmbiguityPolicyRecent {
+		config.GetLogger().Warn("ambiguity_policy recent only knows events forwarded by this connector instance; with multiple instances, ambiguous topics may be resolved to different devices")
+	}
+	_, err = GetCommandMessageTtl(config)
+	if err != nil {
+		return err
+	}
+	if config.Qos > 2 {
+		return fmt.Errorf("invalid qos: %v", config.Qos)
+	}
+
+	connector, err := NewConnector(config)
+	if err != nil {
+		return err
+	}
+
+	err = connector.InitProducer(ctx, []platform_connector_lib.Qos{platform_connector_lib.Async, platform_connector_lib.Sync, platform_connector_lib.SyncIdempotent})
+	if err != nil {
+		return err
+	}
+
+	var logging connectionlog.ConnectionLog = connectionlog.Void
+	if config.SubscriptionDbConStr != "" && config.SubscriptionDbConStr != "-" {
+		producer, err := connector.GetProducer(platform_connector_lib.Sync)
+		if err != nil {
+			return err
+		}
+		logging, err = connectionlog.New(producer, config.SubscriptionDbConStr, config.DeviceLogTopic, config.ConnectionCheckUrl, config.ConnectionCheckHttpTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	buffer, err := commandbuffer.NewFromConfig(ctx, config, logging)
+	if err != nil {
+		return err
+	}
+	if buffer != nil {
+		logging = buffer.ConnectionLog(logging)
+	}
+
+	associations, err := NewAssociationStore(config)
+	if err != nil {
+		return err
+	}
+
+	topics := topic.NewFromConfig(connector.IotCache, config, associations)
+	if topics.CommandResponsesEnabled() {
+		pending, err := NewPendingCommandStore(config)
+		if err != nil {
+			return err
+		}
+		topics.SetPendingCommands(pending)
+	}
+	err = StartTopicInvalidation(ctx, config, topics)
+	if err != nil {
+		return err
+	}
+
+	var mqtt Mqtt
+	if config.BrokerFlavour == "embedded" {
+		platform := hooks.NewFromConnector(config, connector, topics, logging)
+		mqtt, err = embedded.Start(ctx, config, platform)
+		if err != nil {
+			return err
+		}
+		DebugEndpoints(ctx, config, platform, topics)
+	} else {
+		AuthWebhooks(ctx, config, connector, topics, logging)
+
+		if config.StartupDelay != 0 {
+			time.Sleep(time.Duration(config.StartupDelay) * time.Second)
+		}
+
+		for i := 0; i < 10; i++ {
+			mqtt, err = MqttStart(ctx, config)
+			if err == nil {
+				break
+			}
+			time.Sleep(5 * time.Second)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.BrokerFlavour == "mosquitto" {
+		err = mosquitto.StartConnectionEvents(config, mqtt.Subscribe, hooks.NewFromConnector(config, connector, topics, logging))
+		if err != nil {
+			return err
+		}
+	}
+
+	if buffer != nil {
+		buffer.SetPublisher(mqtt.PublishWithOptions)
+	}
+
+	if config.MqttDocuMsg != "" && config.MqttDocuTopic != "" {
+		err = mqtt.PublishRetained(config.MqttDocuTopic, config.MqttDocuMsg)
+		if err != nil {
+			config.GetLogger().Warn("unable to publish mqtt docu", "error", err)
+			err = nil
+		}
+	}
+
+	statistics.Init() //ensure start of prometheus metrics endpoint
+
+	if config.IngestionSubscription != "" && config.IngestionSubscription != "-" {
+		platform := hooks.NewFromConnector(config, connector, topics, logging)
+		err = StartIngestion(config, mqtt, platform)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.CommandWorkerCount > 1 {
+		err = connector.SetAsyncCommandHandler(CreateQueuedCommandHandler(ctx, config, mqtt, topics, buffer)).StartConsumer(ctx)
+	} else {
+		err = connector.SetAsyncCommandHandler(CreateCommandHandler(config, mqtt, topics, buffer)).StartConsumer(ctx)
+	}
+
+	return err
+}
+
+// NewAssociationStore returns a postgres-backed association store if config.SubscriptionDbConStr is set, otherwise an in-memory store
+func NewAssociationStore(config configuration.Config) (association.Store, error) {
+	if config.SubscriptionDbConStr == "" || config.SubscriptionDbConStr == "-" {
+		return association.NewMemory(), nil
+	}
+	return association.NewPostgres(config.SubscriptionDbConStr)
+}
+
+// NewPendingCommandStore returns a postgres-backed store of commands waiting for responses if config.SubscriptionDbConStr is set,
+// so responses may reach any connector instance; otherwise an in-memory store
+func NewPendingCommandStore(config configuration.Config) (pendingcommand.Store, error) {
+	if config.SubscriptionDbConStr == "" || config.SubscriptionDbConStr == "-" {
+		return pendingcommand.NewMemory(), nil
+	}
+	return pendingcommand.NewPostgres(config.SubscriptionDbConStr)
+}
+
+// NewConnector creates the platform-connector-lib connector without starting producers or consumers
+func NewConnector(config configuration.Config) (connector *platform_connector_lib.Connector, err error) {
+	asyncFlushFrequency, err := time.ParseDuration(config.AsyncFlushFrequency)
+	if err != nil {
+		return connector, err
+	}
+
 	libConf := platform_connector_lib.Config{
 		KafkaUrl:                 config.KafkaUrl,
 		KafkaResponseTopic:       config.KafkaResponseTopic,
@@ -127,115 +288,76 @@ func Start(basectx context.Context, config configuration.Config) (err error) {
 		Logger:                               config.GetLogger(),
 	}
 
-	connector, err := platform_connector_lib.New(libConf)
+	connector, err = platform_connector_lib.New(libConf)
 	if err != nil {
-		return err
+		return connector, err
 	}
 
 	if config.Debug {
 		connector.IotCache.Debug = true
 	}
+	return connector, nil
+}
 
-	err = connector.InitProducer(ctx, []platform_connector_lib.Qos{platform_connector_lib.Async, platform_connector_lib.Sync, platform_connector_lib.SyncIdempotent})
+// CreateCommandHandler publishes commands to the topic created by topics.CreateForService, which respects the convention of the device.
+// sparkplug and homie commands, like those of other conventions, are published to the topic of the convention, prefixed with the owner id of the device.
+// if command responses are enabled, every command, including sparkplug and homie commands, waits for a response on its response topic (see topic.Topic.RegisterPendingCommand);
+// sparkplug commands are sent with the content type application/x-protobuf.
+// the qos and retain flag are selected by topic.Topic.CommandDelivery with config.Qos as default; mqtt 5 properties are set by CommandProperties.
+// buffer may be nil; otherwise commands to devices without active subscription are buffered (see commandbuffer.Buffer.Publish)
+func CreateCommandHandler(config configuration.Config, mqtt Mqtt, topics *topic.Topic, buffer *commandbuffer.Buffer) platform_connector_lib.AsyncCommandHandler {
+	ttl, err := GetCommandMessageTtl(config)
 	if err != nil {
-		return err
-	}
-
-	var logging connectionlog.ConnectionLog = connectionlog.Void
-	if config.SubscriptionDbConStr != "" && config.SubscriptionDbConStr != "-" {
-		producer, err := connector.GetProducer(platform_connector_lib.Sync)
-		if err != nil {
-			return err
-		}
-		logging, err = connectionlog.New(producer, config.SubscriptionDbConStr, config.DeviceLogTopic, config.ConnectionCheckUrl, config.ConnectionCheckHttpTimeout)
-		if err != nil {
-			return err
-		}
-	}
-
-	AuthWebhooks(ctx, config, connector, logging)
-
-	if config.StartupDelay != 0 {
-		time.Sleep(time.Duration(config.StartupDelay) * time.Second)
+		config.GetLogger().Error("commands are sent without expiry", "error", err)
 	}
-
-	var mqtt Mqtt
-	for i := 0; i < 10; i++ {
-		mqtt, err = MqttStart(ctx, config)
-		if err == nil {
-			break
+	return func(commandRequest model.ProtocolMsg, requestMsg platform_connector_lib.CommandRequestMsg, t time.Time) (err error) {
+		publish := mqtt.PublishWithOptions
+		if buffer != nil {
+			publish = func(topic string, msg string, options message.Options) error {
+				return buffer.Publish(commandRequest.Metadata.Device.Id, topic, msg, options)
+			}
 		}
-		time.Sleep(5 * time.Second)
-	}
-	if err != nil {
-		return err
-	}
-
-	if config.MqttDocuMsg != "" && config.MqttDocuTopic != "" {
-		err = mqtt.PublishRetained(config.MqttDocuTopic, config.MqttDocuMsg)
-		if err != nil {
-			config.GetLogger().Warn("unable to publish mqtt docu", "error", err)
-			err = nil
+		token := security.JwtToken(client.InternalAdminToken)
+		delivery, deliveryErr := topics.CommandDelivery(token, commandRequest.Metadata.Device, commandRequest.Metadata.Service, topic.Delivery{Qos: config.Qos})
+		if deliveryErr != nil {
+			config.GetLogger().Warn("skip invalid delivery attributes", "error", deliveryErr, "device", commandRequest.Metadata.Device.Id, "service", commandRequest.Metadata.Service.Id)
 		}
-	}
-
-	statistics.Init() //ensure start of prometheus metrics endpoint
-
-	if config.CommandWorkerCount > 1 {
-		err = connector.SetAsyncCommandHandler(CreateQueuedCommandHandler(ctx, config, mqtt)).StartConsumer(ctx)
-	} else {
-		err = connector.SetAsyncCommandHandler(CreateCommandHandler(config, mqtt)).StartConsumer(ctx)
-	}
-
-	return err
-}
-
-type commandQueueValue struct {
-	commandRequest model.ProtocolMsg
-	requestMsg     platform_connector_lib.CommandRequestMsg
-	t              time.Time
-}
-
-func CreateQueuedCommandHandler(ctx context.Context, config configuration.Config, mqtt Mqtt) platform_connector_lib.AsyncCommandHandler {
-	queue := make(chan commandQueueValue, config.CommandWorkerCount)
-	handler := CreateCommandHandler(config, mqtt)
-	for i := int64(0); i < config.CommandWorkerCount; i++ {
-		go func() {
-			for msg := range queue {
-				err := handler(msg.commandRequest, msg.requestMsg, msg.t)
-				if err != nil {
-					config.GetLogger().Error("unable to handle command", "error", err)
-				}
+		options := message.Options{Qos: delivery.Qos, Retain: delivery.Retain}
+		endpoint, payload, contentType := "", commandRequest.Request.Input["payload"], ""
+		switch {
+		case sparkplug.IsLocalDeviceId(commandRequest.Metadata.Device.LocalId):
+			var msg []byte
+			endpoint, msg, err = sparkplug.Command(commandRequest.Metadata.Device.LocalId, commandRequest.Metadata.Service.LocalId, payload)
+			if err != nil {
+				return err
 			}
-		}()
-	}
-	go func() {
-		<-ctx.Done()
-		close(queue)
-	}()
-	return func(commandRequest model.ProtocolMsg, requestMsg platform_connector_lib.CommandRequestMsg, t time.Time) (err error) {
-		defer func() {
-			if r := recover(); r != nil {
-				err = errors.New(fmt.Sprint(r))
+			endpoint, payload, contentType = topic.WithPrefix(commandRequest.Metadata.Device.OwnerId, endpoint), string(msg), "application/x-protobuf"
+		case homie.IsLocalDeviceId(commandRequest.Metadata.Device.LocalId):
+			endpoint, err = homie.CommandTopic(commandRequest.Metadata.Device.LocalId, commandRequest.Metadata.Service.LocalId)
+			if err != nil {
+				return err
+			}
+			endpoint = topic.WithPrefix(commandRequest.Metadata.Device.OwnerId, endpoint)
+		default:
+			endpoint, err = topics.CreateForService(token, commandRequest.Metadata.Device, commandRequest.Metadata.Service)
+			if err != nil {
+				return err
 			}
-		}()
-		queue <- commandQueueValue{
-			commandRequest: commandRequest,
-			requestMsg:     requestMsg,
-			t:              t,
 		}
-		return err
-	}
-}
-
-func CreateCommandHandler(config configuration.Config, mqtt Mqtt) platform_connector_lib.AsyncCommandHandler {
-	return func(commandRequest model.ProtocolMsg, requestMsg platform_connector_lib.CommandRequestMsg, t time.Time) (err error) {
-		endpoint := ""
-		endpoint, err = topic.New(nil, config.ActuatorTopicPattern).Create(commandRequest.Metadata.Device.Id, commandRequest.Metadata.Service.LocalId)
+		pending, err := topics.RegisterPendingCommand(endpoint, commandRequest)
 		if err != nil {
-			return
+			return err
+		}
+		options.Properties, err = CommandProperties(commandRequest, pending, ttl, t)
+		if contentType != "" {
+			options.Properties.ContentType = contentType
+		}
+		if err == nil {
+			err = publish(endpoint, payload, options)
+		}
+		if err != nil && pending.Id != "" {
+			topics.CancelPendingCommand(pending.Id)
 		}
-		err = mqtt.Publish(endpoint, commandRequest.Request.Input["payload"])
 		return
 	}
 }
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lib
+
+import (
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/permissions-v2/pkg/client"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// Lint analyses the device-type or, if deviceTypeId is empty, all device-types of the protocol,
+// like the endpoints GET /lint/device-types/{id} and GET /lint/protocols/{id} of the webhook server.
+// without token, the internal admin token is used
+func Lint(config configuration.Config, token security.JwtToken, deviceTypeId string, protocolId string) (result []topic.Analysis, err error) {
+	connector, err := NewConnector(config)
+	if err != nil {
+		return result, err
+	}
+	if token == "" {
+		token = security.JwtToken(client.InternalAdminToken)
+	}
+	topics := topic.NewFromConfig(connector.IotCache, config, nil)
+	if deviceTypeId == "" {
+		return topics.AnalyseProtocol(token, protocolId)
+	}
+	analysis, err := topics.AnalyseDeviceTypeById(token, deviceTypeId)
+	if err != nil {
+		return result, err
+	}
+	return []topic.Analysis{analysis}, nil
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package message contains the publish options shared by the mqtt clients of the connector and the embedded broker
+package message
+
+// Properties are the mqtt 5 publish properties set by the connector; empty fields are not sent
+type Properties struct {
+	ContentType     string
+	ResponseTopic   string
+	CorrelationData []byte
+	MessageExpiry   *uint32 //seconds
+	UserProperties  []UserProperty
+}
+
+type UserProperty struct {
+	Key   string
+	Value string
+}
+
+// Options of a published message
+type Options struct {
+	Qos        byte
+	Retain     bool
+	Properties Properties //mqtt 5 only
+}
@@ -21,9 +21,13 @@ import (
 	"errors"
 	"log/slog"
 	"net/url"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topicfilter"
 	"github.com/eclipse/paho.golang/autopaho"
 	"github.com/google/uuid"
 
@@ -34,6 +38,15 @@ import (
 type Mqtt interface {
 	Publish(topic, msg string) (err error)
 	PublishRetained(topic, msg string) (err error)
+	// PublishWithOptions works like Publish with the qos, retain flag and mqtt 5 properties of options; clients without mqtt 5 support ignore the properties
+	PublishWithOptions(topic, msg string, options message.Options) (err error)
+	Subscribe(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) (err error)
+}
+
+type subscription struct {
+	topic   string
+	qos     byte
+	handler func(topic string, payload []byte, qos byte)
 }
 
 func MqttStart(ctx context.Context, config configuration.Config) (mqtt Mqtt, err error) {
@@ -45,8 +58,10 @@ func MqttStart(ctx context.Context, config configuration.Config) (mqtt Mqtt, err
 }
 
 type Mqtt4 struct {
-	client paho4.Client
-	Debug  bool
+	client        paho4.Client
+	Debug         bool
+	mux           sync.Mutex
+	subscriptions []subscription
 }
 
 func Mqtt4Start(ctx context.Context, config configuration.Config) (mqtt *Mqtt4, err error) {
@@ -57,6 +72,17 @@ func Mqtt4Start(ctx context.Context, config configuration.Config) (mqtt *Mqtt4,
 		SetAutoReconnect(true).
 		SetCleanSession(true).
 		SetClientID(config.AuthClientId + "_" + uuid.NewString()).
+		SetOnConnectHandler(func(client paho4.Client) {
+			//clean session: subscriptions have to be renewed after reconnects
+			mqtt.mux.Lock()
+			defer mqtt.mux.Unlock()
+			for _, sub := range mqtt.subscriptions {
+				err := mqtt.subscribe(sub)
+				if err != nil {
+					config.GetLogger().Error("unable to renew subscription", "error", err, "topic", sub.topic)
+				}
+			}
+		}).
 		AddBroker(config.MqttBroker)
 
 	mqtt.client = paho4.NewClient(options)
@@ -87,6 +113,21 @@ func (this *Mqtt4) Publish(topic, msg string) (err error) {
 	return err
 }
 
+// PublishWithOptions ignores options.Properties, because mqtt 3.1.1 has no message properties
+func (this *Mqtt4) PublishWithOptions(topic, msg string, options message.Options) (err error) {
+	if !this.client.IsConnected() {
+		slog.Warn("mqtt client not connected")
+		return errors.New("mqtt client not connected")
+	}
+	slog.Debug("mqtt publish", "topic", topic, "msg", msg, "qos", options.Qos, "retain", options.Retain)
+	token := this.client.Publish(topic, options.Qos, options.Retain, msg)
+	if token.Wait() && token.Error() != nil {
+		slog.Error("Error on Client.Publish()", "error", token.Error())
+		return token.Error()
+	}
+	return err
+}
+
 func (this *Mqtt4) PublishRetained(topic, msg string) (err error) {
 	if !this.client.IsConnected() {
 		slog.Warn("mqtt client not connected")
@@ -101,9 +142,34 @@ func (this *Mqtt4) PublishRetained(topic, msg string) (err error) {
 	return err
 }
 
+func (this *Mqtt4) Subscribe(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) (err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	sub := subscription{topic: topic, qos: qos, handler: handler}
+	err = this.subscribe(sub)
+	if err != nil {
+		return err
+	}
+	this.subscriptions = append(this.subscriptions, sub)
+	return nil
+}
+
+func (this *Mqtt4) subscribe(sub subscription) error {
+	token := this.client.Subscribe(sub.topic, sub.qos, func(client paho4.Client, message paho4.Message) {
+		sub.handler(message.Topic(), message.Payload(), message.Qos())
+	})
+	if token.Wait() && token.Error() != nil {
+		slog.Error("Error on Client.Subscribe()", "error", token.Error(), "topic", sub.topic)
+		return token.Error()
+	}
+	return nil
+}
+
 type Mqtt5 struct {
-	client *autopaho.ConnectionManager
-	Debug  bool
+	client        *autopaho.ConnectionManager
+	Debug         bool
+	mux           sync.Mutex
+	subscriptions []*subscription
 }
 
 func Mqtt5Start(ctx context.Context, config configuration.Config) (mqtt *Mqtt5, err error) {
@@ -121,6 +187,7 @@ func Mqtt5Start(ctx context.Context, config configuration.Config) (mqtt *Mqtt5,
 		BrokerUrls: []*url.URL{broker},
 		OnConnectionUp: func(manager *autopaho.ConnectionManager, connack *paho.Connack) {
 			config.GetLogger().Info("mqtt (re)connected")
+			go mqtt.renewSubscriptions(manager)
 		},
 		OnConnectError: func(err error) {
 			config.GetLogger().Error("mqtt connection error", "error", err)
@@ -135,6 +202,7 @@ func Mqtt5Start(ctx context.Context, config configuration.Config) (mqtt *Mqtt5,
 			OnClientError: func(err error) {
 				config.GetLogger().Error("mqtt client error", "error", err)
 			},
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){mqtt.handlePublish},
 		},
 		ConnectPassword: []byte(config.AuthClientSecret),
 		ConnectUsername: config.AuthClientId,
@@ -169,6 +237,34 @@ func (this *Mqtt5) Publish(topic, msg string) (err error) {
 	return err
 }
 
+func (this *Mqtt5) PublishWithOptions(topic, msg string, options message.Options) (err error) {
+	slog.Debug("mqtt publish", "topic", topic, "msg", msg, "qos", options.Qos, "retain", options.Retain, "properties", options.Properties)
+	properties := options.Properties
+	publishProperties := &paho.PublishProperties{
+		ContentType:     properties.ContentType,
+		ResponseTopic:   properties.ResponseTopic,
+		CorrelationData: properties.CorrelationData,
+		MessageExpiry:   properties.MessageExpiry,
+	}
+	for _, property := range properties.UserProperties {
+		publishProperties.User.Add(property.Key, property.Value)
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = this.client.Publish(timeout, &paho.Publish{
+		QoS:        options.Qos,
+		Retain:     options.Retain,
+		Topic:      topic,
+		Payload:    []byte(msg),
+		Properties: publishProperties,
+	})
+	if err != nil {
+		slog.Error("Error on Client.Publish()", "error", err)
+		return err
+	}
+	return err
+}
+
 func (this *Mqtt5) PublishRetained(topic, msg string) (err error) {
 	slog.Debug("mqtt publish", "topic", topic, "msg", msg)
 	timeout, _ := context.WithTimeout(context.Background(), time.Minute)
@@ -184,3 +280,55 @@ func (this *Mqtt5) PublishRetained(topic, msg string) (err error) {
 	}
 	return err
 }
+
+// Subscribe adds the handler before the subscription is sent, so retained messages arriving before the SUBACK are handled;
+// the handler is removed if the subscription fails
+func (this *Mqtt5) Subscribe(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) (err error) {
+	sub := &subscription{topic: topic, qos: qos, handler: handler}
+	this.mux.Lock()
+	this.subscriptions = append(this.subscriptions, sub)
+	this.mux.Unlock()
+	timeout, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = this.client.Subscribe(timeout, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		slog.Error("Error on Client.Subscribe()", "error", err, "topic", topic)
+		this.mux.Lock()
+		this.subscriptions = slices.DeleteFunc(this.subscriptions, func(e *subscription) bool { return e == sub })
+		this.mux.Unlock()
+	}
+	return err
+}
+
+// renewSubscriptions is called on (re)connect, because the connection uses a clean start
+func (this *Mqtt5) renewSubscriptions(manager *autopaho.ConnectionManager) {
+	this.mux.Lock()
+	subscriptions := slices.Clone(this.subscriptions)
+	this.mux.Unlock()
+	for _, sub := range subscriptions {
+		timeout, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err := manager.Subscribe(timeout, &paho.Subscribe{
+			Subscriptions: []paho.SubscribeOptions{{Topic: sub.topic, QoS: sub.qos}},
+		})
+		cancel()
+		if err != nil {
+			slog.Error("unable to renew subscription", "error", err, "topic", sub.topic)
+		}
+	}
+}
+
+func (this *Mqtt5) handlePublish(received paho.PublishReceived) (bool, error) {
+	this.mux.Lock()
+	subscriptions := slices.Clone(this.subscriptions)
+	this.mux.Unlock()
+	handled := false
+	for _, sub := range subscriptions {
+		if topicfilter.Matches(sub.topic, received.Packet.Topic) {
+			sub.handler(received.Packet.Topic, received.Packet.Payload, received.Packet.QoS)
+			handled = true
+		}
+	}
+	return handled, nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pendingcommand
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryCommand struct {
+	Command
+	topics []string
+	done   bool
+}
+
+// Memory stores commands by response topic, in the order they were added; responses have to reach the instance that sent the command
+type Memory struct {
+	mux       sync.Mutex
+	byTopic   map[string][]*memoryCommand
+	byId      map[string]*memoryCommand
+	lastSweep time.Time
+}
+
+var _ Store = &Memory{}
+
+func NewMemory() *Memory {
+	return &Memory{
+		byTopic: map[string][]*memoryCommand{},
+		byId:    map[string]*memoryCommand{},
+	}
+}
+
+func (this *Memory) Add(command Command, topics []string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if now.Sub(this.lastSweep) >= SweepInterval {
+		this.sweep(now)
+		this.lastSweep = now
+	}
+	entry := &memoryCommand{Command: command, topics: topics}
+	this.byId[command.Id] = entry
+	for _, topic := range topics {
+		this.byTopic[topic] = append(this.byTopic[topic], entry)
+	}
+	return nil
+}
+
+func (this *Memory) Find(topic string, accept func(Command) bool, take bool) (result Command, found bool, err error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	for _, entry := range this.byTopic[topic] {
+		if entry.done || now.After(entry.Expires) || !accept(entry.Command) {
+			continue
+		}
+		if take {
+			this.remove(entry)
+		}
+		return entry.Command, true, nil
+	}
+	return result, false, nil
+}
+
+func (this *Memory) Cancel(id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if entry, ok := this.byId[id]; ok {
+		this.remove(entry)
+	}
+	return nil
+}
+
+// remove marks the entry as done and drops it from the topic lists; expects a locked mux
+func (this *Memory) remove(entry *memoryCommand) {
+	entry.done = true
+	delete(this.byId, entry.Id)
+	for _, topic := range entry.topics {
+		entries := slices.DeleteFunc(this.byTopic[topic], func(e *memoryCommand) bool { return e == entry })
+		if len(entries) == 0 {
+			delete(this.byTopic, topic)
+		} else {
+			this.byTopic[topic] = entries
+		}
+	}
+}
+
+// sweep drops expired commands; expects a locked mux
+func (this *Memory) sweep(now time.Time) {
+	for topic, entries := range this.byTopic {
+		entries = slices.DeleteFunc(entries, func(e *memoryCommand) bool {
+			return e.done || now.After(e.Expires)
+		})
+		if len(entries) == 0 {
+			delete(this.byTopic, topic)
+		} else {
+			this.byTopic[topic] = entries
+		}
+	}
+	for id, entry := range this.byId {
+		if now.After(entry.Expires) {
+			delete(this.byId, id)
+		}
+	}
+}
+
+func (this *Memory) size() int {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return len(this.byCorrelation)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pendingcommand
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	store := NewMemory()
+	acceptAll := func(Command) bool { return true }
+	expires := time.Now().Add(time.Minute)
+	_ = store.Add(Command{Id: "c1", CorrelationId: "task", ResponseTopic: "d1/cmd/resp", Expires: expires}, []string{"d1/cmd/resp", "cmd/resp"})
+	_ = store.Add(Command{Id: "c2", CorrelationId: "task", ResponseTopic: "d1/cmd/resp", Expires: expires}, []string{"d1/cmd/resp", "cmd/resp"})
+
+	if _, ok, _ := store.Find("d1/cmd/resp", func(Command) bool { return false }, false); ok {
+		t.Error("rejected command should not be found")
+	}
+	if command, ok, err := store.Find("cmd/resp", acceptAll, false); err != nil || !ok || command.Id != "c1" {
+		t.Error(command, ok, err)
+	}
+	if command, ok, err := store.Find("d1/cmd/resp", acceptAll, true); err != nil || !ok || command.Id != "c1" {
+		t.Error(command, ok, err)
+	}
+	if command, ok, err := store.Find("cmd/resp", acceptAll, false); err != nil || !ok || command.Id != "c2" {
+		t.Error("taken command should be removed from all topics", command, ok, err)
+	}
+	//commands of the same task share the correlation id, but are canceled by id
+	_ = store.Add(Command{Id: "c3", CorrelationId: "task", ResponseTopic: "d1/cmd/resp", Expires: expires}, []string{"d1/cmd/resp"})
+	_ = store.Cancel("c3")
+	if command, ok, err := store.Find("d1/cmd/resp", acceptAll, false); err != nil || !ok || command.Id != "c2" {
+		t.Error(command, ok, err)
+	}
+	_ = store.Cancel("c2")
+	if _, ok, _ := store.Find("d1/cmd/resp", acceptAll, false); ok {
+		t.Error("canceled command should not be found")
+	}
+	if size := store.size(); size != 0 {
+		t.Error(size)
+	}
+}
+
+func TestMemoryExpiration(t *testing.T) {
+	store := NewMemory()
+	_ = store.Add(Command{Id: "c1", Expires: time.Now().Add(10 * time.Millisecond)}, []string{"response/c1"})
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := store.Find("response/c1", func(Command) bool { return true }, false); ok {
+		t.Error("expired command should not be found")
+	}
+	store.lastSweep = time.Time{}
+	_ = store.Add(Command{Id: "c2", Expires: time.Now().Add(time.Minute)}, []string{"response/c2"})
+	if size := store.size(); size != 1 {
+		t.Error("expired command should be removed", size)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package pendingcommand stores commands waiting for the response of their device (see topic.Topic.RegisterPendingCommand).
+// commands are stored under their response topics, so a response published to any connector instance finds its command
+// if the instances share a Postgres store.
+package pendingcommand
+
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+// Command is a command waiting for the response of its device.
+// Id is generated per command; CorrelationId may be shared by commands of the same task, e.g. retries
+type Command struct {
+	Id            string            `json:"id"`
+	CorrelationId string            `json:"correlation_id"`
+	ResponseTopic string            `json:"response_topic"`
+	Request       model.ProtocolMsg `json:"request"`
+	Expires       time.Time         `json:"expires"`
+}
+
+// Store must be safe for concurrent use
+type Store interface {
+	// Add stores the command under each of the topics
+	Add(command Command, topics []string) error
+	// Find returns the oldest unexpired command of the topic accepted by accept; take removes it
+	Find(topic string, accept func(Command) bool, take bool) (result Command, found bool, err error)
+	// Cancel removes the command with the Command.Id
+	Cancel(id string) error
+}
+
+// expired commands are removed at most once per SweepInterval, when a command is added
+const SweepInterval = time.Second
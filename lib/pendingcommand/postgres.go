@@ -0,0 +1,154 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pendingcommand
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+var Timeout = 1 * time.Second
+
+// Postgres stores the commands in the PendingCommand table; instances sharing the database take the responses to commands of each other
+// and pending commands survive restarts. a command is taken by the instance whose delete removes it
+type Postgres struct {
+	db        *sql.DB
+	mux       sync.Mutex
+	lastSweep time.Time
+}
+
+var _ Store = &Postgres{}
+
+func NewPostgres(conStr string) (result *Postgres, err error) {
+	db, err := sql.Open("postgres", conStr)
+	if err != nil {
+		return result, err
+	}
+	_, err = db.Exec(SqlCreatePendingCommandTable)
+	if err != nil {
+		return result, err
+	}
+	return &Postgres{db: db}, nil
+}
+
+func (this *Postgres) Add(command Command, topics []string) error {
+	err := this.sweep(time.Now())
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, topic := range topics {
+		_, err = tx.ExecContext(ctx, SqlInsertPendingCommand, command.Id, topic, value, command.Expires)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Find skips commands taken by other instances while this one checked them
+func (this *Postgres) Find(topic string, accept func(Command) bool, take bool) (result Command, found bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	rows, err := this.db.QueryContext(ctx, SqlSelectPendingCommands, topic, time.Now())
+	if err != nil {
+		return result, false, err
+	}
+	candidates := []Command{}
+	for rows.Next() {
+		var value []byte
+		err = rows.Scan(&value)
+		if err != nil {
+			rows.Close()
+			return result, false, err
+		}
+		command := Command{}
+		err = json.Unmarshal(value, &command)
+		if err != nil {
+			rows.Close()
+			return result, false, err
+		}
+		candidates = append(candidates, command)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return result, false, err
+	}
+	for _, command := range candidates {
+		if !accept(command) {
+			continue
+		}
+		if !take {
+			return command, true, nil
+		}
+		taken, err := this.remove(ctx, command.Id)
+		if err != nil {
+			return result, false, err
+		}
+		if taken {
+			return command, true, nil
+		}
+	}
+	return result, false, nil
+}
+
+func (this *Postgres) Cancel(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.remove(ctx, id)
+	return err
+}
+
+// remove returns false if the command was already removed, e.g. taken by another instance
+func (this *Postgres) remove(ctx context.Context, id string) (removed bool, err error) {
+	result, err := this.db.ExecContext(ctx, SqlDeletePendingCommand, id)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+// sweep removes expired commands at most once per SweepInterval
+func (this *Postgres) sweep(now time.Time) error {
+	this.mux.Lock()
+	if now.Sub(this.lastSweep) < SweepInterval {
+		this.mux.Unlock()
+		return nil
+	}
+	this.lastSweep = now
+	this.mux.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err := this.db.ExecContext(ctx, SqlDeleteExpiredPendingCommands, now)
+	return err
+}
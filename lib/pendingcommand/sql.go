@@ -0,0 +1,35 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pendingcommand
+
+const SqlCreatePendingCommandTable = `CREATE TABLE IF NOT EXISTS PendingCommand (
+	Id				BIGSERIAL PRIMARY KEY,
+	CommandId		VARCHAR(255) NOT NULL,
+	Topic			TEXT NOT NULL,
+	Command			JSONB NOT NULL,
+	Expires			TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS pending_command_topic_index ON PendingCommand (Topic, Id);
+CREATE INDEX IF NOT EXISTS pending_command_command_index ON PendingCommand (CommandId);`
+
+const SqlInsertPendingCommand = `INSERT INTO PendingCommand(CommandId, Topic, Command, Expires) VALUES ($1, $2, $3, $4);`
+
+const SqlSelectPendingCommands = `SELECT Command FROM PendingCommand WHERE Topic = $1 AND Expires > $2 ORDER BY Id;`
+
+const SqlDeletePendingCommand = `DELETE FROM PendingCommand WHERE CommandId = $1;`
+
+const SqlDeleteExpiredPendingCommands = `DELETE FROM PendingCommand WHERE Expires < $1;`
@@ -18,4 +18,18 @@ package shortid
 
 const URN_PREFIX = "urn:infai:ses:"
 
-const DEVICE_PREFIX = URN_PREFIX + "device:"
+const (
+	DEVICE_PREFIX               = URN_PREFIX + "device:"
+	HUB_PREFIX                  = URN_PREFIX + "hub:"
+	DEVICE_TYPE_PREFIX          = URN_PREFIX + "device-type:"
+	SERVICE_PREFIX              = URN_PREFIX + "service:"
+	PROTOCOL_PREFIX             = URN_PREFIX + "protocol:"
+	DEVICE_CLASS_PREFIX         = URN_PREFIX + "device-class:"
+	DEVICE_GROUP_PREFIX         = URN_PREFIX + "device-group:"
+	LOCATION_PREFIX             = URN_PREFIX + "location:"
+	ASPECT_PREFIX               = URN_PREFIX + "aspect:"
+	MEASURING_FUNCTION_PREFIX   = URN_PREFIX + "measuring-function:"
+	CONTROLLING_FUNCTION_PREFIX = URN_PREFIX + "controlling-function:"
+	CONCEPT_PREFIX              = URN_PREFIX + "concept:"
+	CHARACTERISTIC_PREFIX       = URN_PREFIX + "characteristic:"
+)
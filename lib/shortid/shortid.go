@@ -24,31 +24,58 @@ import (
 )
 
 func EnsureLongDeviceId(shortDeviceId string) (longDeviceId string, err error) {
-	if shortDeviceId == "" {
+	return EnsureLongId(DEVICE_PREFIX, shortDeviceId)
+}
+
+func EnsureLongHubId(shortHubId string) (longHubId string, err error) {
+	return EnsureLongId(HUB_PREFIX, shortHubId)
+}
+
+func EnsureLongDeviceTypeId(shortDeviceTypeId string) (longDeviceTypeId string, err error) {
+	return EnsureLongId(DEVICE_TYPE_PREFIX, shortDeviceTypeId)
+}
+
+func EnsureLongServiceId(shortServiceId string) (longServiceId string, err error) {
+	return EnsureLongId(SERVICE_PREFIX, shortServiceId)
+}
+
+// EnsureLongId returns the id with the prefix (e.g. HUB_PREFIX) for a short id; ids with the prefix are returned unchanged
+func EnsureLongId(prefix string, shortId string) (longId string, err error) {
+	if shortId == "" {
 		return "", nil //is empty -> nothing to decode
 	}
-	if strings.HasPrefix(shortDeviceId, DEVICE_PREFIX) {
-		return shortDeviceId, nil //is already long version
+	if strings.HasPrefix(shortId, prefix) {
+		return shortId, nil //is already long version
 	}
 
-	hexByte, err := base64.RawURLEncoding.DecodeString(shortDeviceId)
+	hexByte, err := base64.RawURLEncoding.DecodeString(shortId)
 	if err != nil {
-		return shortDeviceId, err
+		return shortId, err
 	}
 	if len(hexByte) < 10 {
 		return "", errors.New("expected uuid with at least 10 byte")
 	}
 	uuidStr := bytesToUUidString(hexByte)
-	longDeviceId = DEVICE_PREFIX + uuidStr
-	return longDeviceId, nil
+	longId = prefix + uuidStr
+	return longId, nil
 }
 
+// IsShortId returns true if id is a short id of a platform id (22 characters of base64 for the 16 byte uuid)
+func IsShortId(id string) bool {
+	if len(id) != base64.RawURLEncoding.EncodedLen(16) {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(id)
+	return err == nil
+}
+
+// ShortId shortens platform ids of all kinds (URN_PREFIX + "<kind>:" + uuid)
 func ShortId(longId string) (shortId string, err error) {
 	if longId == "" {
 		return "", nil
 	}
-	if !strings.HasPrefix(longId, DEVICE_PREFIX) {
-		return "", errors.New("expected " + DEVICE_PREFIX + " as prefix")
+	if _, ok := Prefix(longId); !ok {
+		return "", errors.New("expected " + URN_PREFIX + "<kind>: as prefix")
 	}
 	parts := strings.Split(longId, ":")
 	uuidStr := parts[len(parts)-1]
@@ -60,6 +87,18 @@ func ShortId(longId string) (shortId string, err error) {
 	return base64.RawURLEncoding.EncodeToString(uuid), nil
 }
 
+// Prefix returns the prefix of a platform id including the kind (e.g. DEVICE_PREFIX)
+func Prefix(longId string) (prefix string, ok bool) {
+	if !strings.HasPrefix(longId, URN_PREFIX) {
+		return "", false
+	}
+	kind, _, found := strings.Cut(strings.TrimPrefix(longId, URN_PREFIX), ":")
+	if !found || kind == "" {
+		return "", false
+	}
+	return URN_PREFIX + kind + ":", true
+}
+
 func bytesToUUidString(u []byte) string {
 	buf := make([]byte, 36)
 	hex.Encode(buf[0:8], u[0:4])
@@ -21,7 +21,39 @@ import "testing"
 func TestShortening(t *testing.T) {
 	t.Run(testShortening("urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "a9B7ddfMShqI26yT9hqnsw"))
 	t.Run(testShorteningExpectError("6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"))
+	t.Run(testShorteningExpectError("urn:infai:ses:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"))
 	t.Run(testShortening("", ""))
+	t.Run(testShortening("urn:infai:ses:hub:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "a9B7ddfMShqI26yT9hqnsw"))
+	t.Run(testShortening("urn:infai:ses:device-type:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "a9B7ddfMShqI26yT9hqnsw"))
+	t.Run(testShortening("urn:infai:ses:service:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "a9B7ddfMShqI26yT9hqnsw"))
+}
+
+func TestEnsureLongId(t *testing.T) {
+	for prefix, ensure := range map[string]func(string) (string, error){
+		HUB_PREFIX:         EnsureLongHubId,
+		DEVICE_TYPE_PREFIX: EnsureLongDeviceTypeId,
+		SERVICE_PREFIX:     EnsureLongServiceId,
+	} {
+		long, err := ensure("a9B7ddfMShqI26yT9hqnsw")
+		if err != nil || long != prefix+"6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3" {
+			t.Error(prefix, long, err)
+		}
+		long, err = ensure(prefix + "6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3")
+		if err != nil || long != prefix+"6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3" {
+			t.Error(prefix, long, err)
+		}
+	}
+}
+
+func TestIsShortId(t *testing.T) {
+	if !IsShortId("a9B7ddfMShqI26yT9hqnsw") {
+		t.Error("short id not detected")
+	}
+	for _, id := range []string{"", "user", "6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", "a9B7ddfMShqI26yT9hqns/"} {
+		if IsShortId(id) {
+			t.Error("unexpected short id", id)
+		}
+	}
 }
 
 func TestEnsureLongDeviceId(t *testing.T) {
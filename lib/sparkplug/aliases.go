@@ -0,0 +1,62 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"sync"
+)
+
+// Aliases remembers the metric aliases announced by NBIRTH and DBIRTH messages.
+// aliases are unique per edge node, a NBIRTH replaces all aliases of the edge node.
+type Aliases struct {
+	mux   sync.RWMutex
+	nodes map[string]map[uint64]string
+}
+
+func NewAliases() *Aliases {
+	return &Aliases{nodes: map[string]map[uint64]string{}}
+}
+
+// Apply stores the aliases of birth messages and sets the name of metrics only referenced by alias.
+// group and edge node names are only unique per user, so aliases are stored per scope (e.g. the device owner).
+func (this *Aliases) Apply(scope string, topic Topic, payload *Payload) {
+	node := scope + "/" + topic.Group + "/" + topic.EdgeNode
+	switch topic.MessageType {
+	case NBIRTH, DBIRTH:
+		this.mux.Lock()
+		defer this.mux.Unlock()
+		aliases, ok := this.nodes[node]
+		if !ok || topic.MessageType == NBIRTH {
+			aliases = map[uint64]string{}
+			this.nodes[node] = aliases
+		}
+		for _, metric := range payload.Metrics {
+			if metric.Alias != nil && metric.Name != "" {
+				aliases[*metric.Alias] = metric.Name
+			}
+		}
+	default:
+		this.mux.RLock()
+		defer this.mux.RUnlock()
+		aliases := this.nodes[node]
+		for i, metric := range payload.Metrics {
+			if metric.Name == "" && metric.Alias != nil {
+				payload.Metrics[i].Name = aliases[*metric.Alias]
+			}
+		}
+	}
+}
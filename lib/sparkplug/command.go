@@ -0,0 +1,117 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// Command creates the NCMD or DCMD message setting the metric to the value of the command payload.
+// the payload may be a MetricEvent like json object ({"value": 42, "datatype": "Int32"}) or a plain json value;
+// without datatype, it is derived from the json value. payloads that are no valid json are sent as String.
+func Command(localDeviceId string, metricName string, payload string) (topic string, msg []byte, err error) {
+	cmdTopic, err := CommandTopic(localDeviceId)
+	if err != nil {
+		return topic, msg, err
+	}
+	metric := commandMetric(payload)
+	metric.Name = metricName
+	msg, err = Encode(Payload{
+		Timestamp: uint64(time.Now().UnixMilli()),
+		Metrics:   []Metric{metric},
+	})
+	return cmdTopic.String(), msg, err
+}
+
+func commandMetric(payload string) (result Metric) {
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	err := decoder.Decode(&value)
+	if err != nil {
+		return Metric{DataType: String, Value: payload}
+	}
+	if obj, ok := value.(map[string]any); ok {
+		if v, ok := obj["value"]; ok {
+			value = v
+			if name, ok := obj["datatype"].(string); ok {
+				if dt, ok := DataTypeFromString(name); ok {
+					result.DataType = dt
+				}
+			}
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		result.IsNull = true
+		if result.DataType == Unknown {
+			result.DataType = String
+		}
+		return result
+	case bool:
+		result.Value = v
+		if result.DataType == Unknown {
+			result.DataType = Boolean
+		}
+	case string:
+		result.Value = v
+		if result.DataType == Unknown {
+			result.DataType = String
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			result.Value = i
+			if result.DataType == Unknown {
+				result.DataType = Int64
+			}
+		} else {
+			f, _ := v.Float64()
+			result.Value = f
+			if result.DataType == Unknown {
+				result.DataType = Double
+			}
+		}
+	default:
+		raw, _ := json.Marshal(v)
+		result.Value = string(bytes.TrimSpace(raw))
+		result.DataType = String
+	}
+	return normalizeValue(result)
+}
+
+// normalizeValue converts json values to the go types expected by Encode for the metric data type
+func normalizeValue(metric Metric) Metric {
+	switch metric.DataType {
+	case Float, Double:
+		if f, ok := toFloat64(metric.Value); ok {
+			metric.Value = f
+		}
+	case Int8, Int16, Int32, Int64, UInt8, UInt16, UInt32, UInt64, DateTime:
+		if f, ok := metric.Value.(float64); ok && f == math.Trunc(f) {
+			metric.Value = int64(f)
+		}
+	case Bytes, File:
+		if s, ok := metric.Value.(string); ok {
+			metric.Value = []byte(s)
+		}
+	}
+	return metric
+}
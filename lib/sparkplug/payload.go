@@ -0,0 +1,354 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+type DataType uint32
+
+const (
+	Unknown  DataType = 0
+	Int8     DataType = 1
+	Int16    DataType = 2
+	Int32    DataType = 3
+	Int64    DataType = 4
+	UInt8    DataType = 5
+	UInt16   DataType = 6
+	UInt32   DataType = 7
+	UInt64   DataType = 8
+	Float    DataType = 9
+	Double   DataType = 10
+	Boolean  DataType = 11
+	String   DataType = 12
+	DateTime DataType = 13
+	Text     DataType = 14
+	UUID     DataType = 15
+	DataSet  DataType = 16
+	Bytes    DataType = 17
+	File     DataType = 18
+	Template DataType = 19
+)
+
+var dataTypeNames = map[DataType]string{
+	Unknown:  "Unknown",
+	Int8:     "Int8",
+	Int16:    "Int16",
+	Int32:    "Int32",
+	Int64:    "Int64",
+	UInt8:    "UInt8",
+	UInt16:   "UInt16",
+	UInt32:   "UInt32",
+	UInt64:   "UInt64",
+	Float:    "Float",
+	Double:   "Double",
+	Boolean:  "Boolean",
+	String:   "String",
+	DateTime: "DateTime",
+	Text:     "Text",
+	UUID:     "UUID",
+	DataSet:  "DataSet",
+	Bytes:    "Bytes",
+	File:     "File",
+	Template: "Template",
+}
+
+func (this DataType) String() string {
+	if name, ok := dataTypeNames[this]; ok {
+		return name
+	}
+	return fmt.Sprintf("DataType(%d)", uint32(this))
+}
+
+func DataTypeFromString(name string) (DataType, bool) {
+	for dt, n := range dataTypeNames {
+		if n == name {
+			return dt, true
+		}
+	}
+	return Unknown, false
+}
+
+var ErrUnsupportedValue = errors.New("unsupported sparkplug metric value")
+
+// Payload is the subset of the sparkplug b payload used by the connector; metadata, properties, data sets and templates are skipped
+type Payload struct {
+	Timestamp uint64
+	Metrics   []Metric
+	Seq       *uint64
+	Uuid      string
+	Body      []byte
+}
+
+// Metric.Value is int64 for signed integer types, uint64 for unsigned integer types and DateTime,
+// float32, float64, bool, string or []byte; nil if IsNull or the value type is not supported
+type Metric struct {
+	Name         string
+	Alias        *uint64
+	Timestamp    uint64
+	DataType     DataType
+	IsHistorical bool
+	IsTransient  bool
+	IsNull       bool
+	Value        any
+}
+
+// MetricEvent is the json representation of a metric forwarded as service event
+type MetricEvent struct {
+	Value     any    `json:"value"`
+	Timestamp uint64 `json:"timestamp"`
+	DataType  string `json:"datatype"`
+}
+
+// Event uses the payload timestamp if the metric has none
+func (this Metric) Event(payloadTimestamp uint64) MetricEvent {
+	timestamp := this.Timestamp
+	if timestamp == 0 {
+		timestamp = payloadTimestamp
+	}
+	value := this.Value
+	if this.IsNull {
+		value = nil
+	}
+	return MetricEvent{Value: value, Timestamp: timestamp, DataType: this.DataType.String()}
+}
+
+func Decode(b []byte) (result Payload, err error) {
+	err = readFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			result.Timestamp = f.value
+		case 2:
+			metric, err := decodeMetric(f.bytes)
+			if err != nil {
+				return err
+			}
+			result.Metrics = append(result.Metrics, metric)
+		case 3:
+			seq := f.value
+			result.Seq = &seq
+		case 4:
+			result.Uuid = string(f.bytes)
+		case 5:
+			result.Body = f.bytes
+		}
+		return nil
+	})
+	return result, err
+}
+
+func decodeMetric(b []byte) (result Metric, err error) {
+	var raw *field
+	err = readFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			result.Name = string(f.bytes)
+		case 2:
+			alias := f.value
+			result.Alias = &alias
+		case 3:
+			result.Timestamp = f.value
+		case 4:
+			result.DataType = DataType(f.value)
+		case 5:
+			result.IsHistorical = f.value != 0
+		case 6:
+			result.IsTransient = f.value != 0
+		case 7:
+			result.IsNull = f.value != 0
+		case 10, 11, 12, 13, 14, 15, 16:
+			raw = &f
+		}
+		return nil
+	})
+	if err != nil || raw == nil {
+		return result, err
+	}
+	switch raw.num {
+	case 10:
+		switch result.DataType {
+		case Int8:
+			result.Value = int64(int8(raw.value))
+		case Int16:
+			result.Value = int64(int16(raw.value))
+		case Int32:
+			result.Value = int64(int32(raw.value))
+		default:
+			result.Value = uint64(uint32(raw.value))
+		}
+	case 11:
+		if result.DataType == Int64 {
+			result.Value = int64(raw.value)
+		} else {
+			result.Value = raw.value
+		}
+	case 12:
+		result.Value = math.Float32frombits(uint32(raw.value))
+	case 13:
+		result.Value = math.Float64frombits(raw.value)
+	case 14:
+		result.Value = raw.value != 0
+	case 15:
+		result.Value = string(raw.bytes)
+	case 16:
+		result.Value = raw.bytes
+	}
+	return result, nil
+}
+
+func Encode(payload Payload) (result []byte, err error) {
+	result = appendVarintField(result, 1, payload.Timestamp)
+	for _, metric := range payload.Metrics {
+		m, err := encodeMetric(metric)
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", err, metric.Name)
+		}
+		result = appendBytesField(result, 2, m)
+	}
+	if payload.Seq != nil {
+		result = appendVarintField(result, 3, *payload.Seq)
+	}
+	if payload.Uuid != "" {
+		result = appendBytesField(result, 4, []byte(payload.Uuid))
+	}
+	if payload.Body != nil {
+		result = appendBytesField(result, 5, payload.Body)
+	}
+	return result, nil
+}
+
+func encodeMetric(metric Metric) (result []byte, err error) {
+	if metric.Name != "" {
+		result = appendBytesField(result, 1, []byte(metric.Name))
+	}
+	if metric.Alias != nil {
+		result = appendVarintField(result, 2, *metric.Alias)
+	}
+	if metric.Timestamp != 0 {
+		result = appendVarintField(result, 3, metric.Timestamp)
+	}
+	result = appendVarintField(result, 4, uint64(metric.DataType))
+	if metric.IsHistorical {
+		result = appendBoolField(result, 5, true)
+	}
+	if metric.IsTransient {
+		result = appendBoolField(result, 6, true)
+	}
+	if metric.IsNull || metric.Value == nil {
+		return appendBoolField(result, 7, true), nil
+	}
+	switch metric.DataType {
+	case Int8, Int16, Int32, UInt8, UInt16, UInt32:
+		v, ok := toUint64(metric.Value)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendVarintField(result, 10, uint64(uint32(v))), nil
+	case Int64, UInt64, DateTime:
+		v, ok := toUint64(metric.Value)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendVarintField(result, 11, v), nil
+	case Float:
+		v, ok := toFloat64(metric.Value)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendFixed32Field(result, 12, math.Float32bits(float32(v))), nil
+	case Double:
+		v, ok := toFloat64(metric.Value)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendFixed64Field(result, 13, math.Float64bits(v)), nil
+	case Boolean:
+		v, ok := metric.Value.(bool)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendBoolField(result, 14, v), nil
+	case String, Text, UUID:
+		v, ok := metric.Value.(string)
+		if !ok {
+			return result, ErrUnsupportedValue
+		}
+		return appendBytesField(result, 15, []byte(v)), nil
+	case Bytes, File:
+		switch v := metric.Value.(type) {
+		case []byte:
+			return appendBytesField(result, 16, v), nil
+		case string:
+			return appendBytesField(result, 16, []byte(v)), nil
+		}
+	}
+	return result, ErrUnsupportedValue
+}
+
+// toUint64 returns the two's complement bits of signed values
+func toUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case int:
+		return uint64(v), true
+	case int8:
+		return uint64(v), true
+	case int16:
+		return uint64(v), true
+	case int32:
+		return uint64(v), true
+	case int64:
+		return uint64(v), true
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		if v < 0 {
+			return uint64(int64(v)), true
+		}
+		return uint64(v), true
+	}
+	return 0, false
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
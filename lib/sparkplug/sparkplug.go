@@ -0,0 +1,143 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sparkplug handles the Sparkplug B topic namespace (spBv1.0/<group>/<message-type>/<edge-node>[/<device>])
+// and its protobuf payload.
+// Edge nodes and devices are mapped to platform devices by their local id:
+//
+//	spBv1.0:<group>:<edge-node>           for the edge node
+//	spBv1.0:<group>:<edge-node>:<device>  for devices of the edge node
+//
+// metrics are mapped to services with the metric name as local id; metrics of the edge node stay on the edge node device.
+// hubs of edge nodes are not managed by the connector.
+package sparkplug
+
+import (
+	"errors"
+	"strings"
+)
+
+const Namespace = "spBv1.0"
+
+type MessageType string
+
+const (
+	NBIRTH MessageType = "NBIRTH"
+	NDEATH MessageType = "NDEATH"
+	DBIRTH MessageType = "DBIRTH"
+	DDEATH MessageType = "DDEATH"
+	NDATA  MessageType = "NDATA"
+	DDATA  MessageType = "DDATA"
+	NCMD   MessageType = "NCMD"
+	DCMD   MessageType = "DCMD"
+)
+
+var ErrNoSparkplugLocalId = errors.New("local id is not a sparkplug local id")
+
+type Topic struct {
+	Group       string
+	MessageType MessageType
+	EdgeNode    string
+	Device      string //empty for edge node messages
+}
+
+// ParseTopic returns false for topics outside the sparkplug namespace or with unknown message types.
+// a wildcard as device of a subscription topic filter references the edge node.
+func ParseTopic(topic string) (result Topic, ok bool) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 4 || len(parts) > 5 || parts[0] != Namespace {
+		return result, false
+	}
+	result = Topic{Group: parts[1], MessageType: MessageType(parts[2]), EdgeNode: parts[3]}
+	switch result.MessageType {
+	case NBIRTH, NDEATH, NDATA, NCMD:
+		if len(parts) != 4 {
+			return result, false
+		}
+	case DBIRTH, DDEATH, DDATA, DCMD:
+		if len(parts) != 5 {
+			return result, false
+		}
+		if parts[4] != "+" && parts[4] != "#" {
+			result.Device = parts[4]
+		}
+	default:
+		return result, false
+	}
+	if result.Group == "" || result.EdgeNode == "" || isWildcard(result.Group) || isWildcard(result.EdgeNode) {
+		return result, false
+	}
+	return result, true
+}
+
+func isWildcard(level string) bool {
+	return level == "+" || level == "#"
+}
+
+func (this Topic) String() string {
+	result := Namespace + "/" + this.Group + "/" + string(this.MessageType) + "/" + this.EdgeNode
+	if this.Device != "" {
+		result = result + "/" + this.Device
+	}
+	return result
+}
+
+// LocalDeviceId returns the local id of the platform device representing the edge node or device of the topic
+func (this Topic) LocalDeviceId() string {
+	result := Namespace + ":" + this.Group + ":" + this.EdgeNode
+	if this.Device != "" {
+		result = result + ":" + this.Device
+	}
+	return result
+}
+
+// IsData is true for messages carrying metric values
+func (this Topic) IsData() bool {
+	switch this.MessageType {
+	case NBIRTH, DBIRTH, NDATA, DDATA:
+		return true
+	}
+	return false
+}
+
+// IsCommand is true for NCMD and DCMD messages
+func (this Topic) IsCommand() bool {
+	return this.MessageType == NCMD || this.MessageType == DCMD
+}
+
+func IsLocalDeviceId(localId string) bool {
+	return strings.HasPrefix(localId, Namespace+":")
+}
+
+// CommandTopic returns the NCMD or DCMD topic for the local id of an edge node or device
+func CommandTopic(localDeviceId string) (result Topic, err error) {
+	if !IsLocalDeviceId(localDeviceId) {
+		return result, ErrNoSparkplugLocalId
+	}
+	parts := strings.Split(strings.TrimPrefix(localDeviceId, Namespace+":"), ":")
+	switch len(parts) {
+	case 2:
+		result = Topic{Group: parts[0], MessageType: NCMD, EdgeNode: parts[1]}
+	case 3:
+		result = Topic{Group: parts[0], MessageType: DCMD, EdgeNode: parts[1], Device: parts[2]}
+	default:
+		return result, ErrNoSparkplugLocalId
+	}
+	if result.Group == "" || result.EdgeNode == "" || (result.MessageType == DCMD && result.Device == "") {
+		return result, ErrNoSparkplugLocalId
+	}
+	return result, nil
+}
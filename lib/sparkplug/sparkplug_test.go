@@ -0,0 +1,157 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseTopic(t *testing.T) {
+	t.Run(testParseTopic("spBv1.0/plant/DDATA/edge1/pump", Topic{Group: "plant", MessageType: DDATA, EdgeNode: "edge1", Device: "pump"}, true))
+	t.Run(testParseTopic("spBv1.0/plant/NBIRTH/edge1", Topic{Group: "plant", MessageType: NBIRTH, EdgeNode: "edge1"}, true))
+	t.Run(testParseTopic("spBv1.0/plant/DCMD/edge1/#", Topic{Group: "plant", MessageType: DCMD, EdgeNode: "edge1"}, true))
+	t.Run(testParseTopic("spBv1.0/plant/NDATA/edge1/pump", Topic{}, false))
+	t.Run(testParseTopic("spBv1.0/plant/DDATA/edge1", Topic{}, false))
+	t.Run(testParseTopic("spBv1.0/+/DDATA/edge1/pump", Topic{}, false))
+	t.Run(testParseTopic("spBv1.0/STATE/host", Topic{}, false))
+	t.Run(testParseTopic("plant/DDATA/edge1/pump", Topic{}, false))
+}
+
+func TestCommandTopic(t *testing.T) {
+	topic, err := CommandTopic(Topic{Group: "plant", MessageType: DDATA, EdgeNode: "edge1", Device: "pump"}.LocalDeviceId())
+	if err != nil || topic.String() != "spBv1.0/plant/DCMD/edge1/pump" {
+		t.Error(topic, err)
+	}
+	topic, err = CommandTopic("spBv1.0:plant:edge1")
+	if err != nil || topic.String() != "spBv1.0/plant/NCMD/edge1" {
+		t.Error(topic, err)
+	}
+	_, err = CommandTopic("plant:edge1")
+	if err != ErrNoSparkplugLocalId {
+		t.Error(err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// timestamp=1, metrics=[{name:"t", datatype:Double, double_value:1.5}, {alias:2, datatype:Int32, int_value:-5}], seq=0
+	msg := []byte{
+		0x08, 0x01,
+		0x12, 0x0e, 0x0a, 0x01, 't', 0x20, 0x0a, 0x69, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f,
+		0x12, 0x0a, 0x10, 0x02, 0x20, 0x03, 0x50, 0xfb, 0xff, 0xff, 0xff, 0x0f,
+		0x18, 0x00,
+	}
+	payload, err := Decode(msg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if payload.Timestamp != 1 || payload.Seq == nil || *payload.Seq != 0 || len(payload.Metrics) != 2 {
+		t.Error(payload)
+		return
+	}
+	if m := payload.Metrics[0]; m.Name != "t" || m.DataType != Double || m.Value != 1.5 {
+		t.Error(m)
+	}
+	if m := payload.Metrics[1]; m.Alias == nil || *m.Alias != 2 || m.DataType != Int32 || m.Value != int64(-5) {
+		t.Error(m)
+	}
+
+	encoded, err := Encode(payload)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !bytes.Equal(encoded, msg) {
+		t.Errorf("%x\n%x", encoded, msg)
+	}
+
+	_, err = Decode([]byte{0x12, 0x0e, 0x0a})
+	if err != ErrInvalidWireFormat {
+		t.Error(err)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	aliases := NewAliases()
+	alias := uint64(2)
+	aliases.Apply("user", Topic{Group: "plant", MessageType: DBIRTH, EdgeNode: "edge1", Device: "pump"}, &Payload{Metrics: []Metric{{Name: "temperature", Alias: &alias}}})
+	data := Payload{Metrics: []Metric{{Alias: &alias, DataType: Double, Value: 20.5}}}
+	aliases.Apply("user", Topic{Group: "plant", MessageType: DDATA, EdgeNode: "edge1", Device: "pump"}, &data)
+	if data.Metrics[0].Name != "temperature" {
+		t.Error(data.Metrics[0])
+	}
+	other := Payload{Metrics: []Metric{{Alias: &alias, DataType: Double, Value: 20.5}}}
+	aliases.Apply("other", Topic{Group: "plant", MessageType: DDATA, EdgeNode: "edge1", Device: "pump"}, &other)
+	if other.Metrics[0].Name != "" {
+		t.Error("aliases of other users should not be applied", other.Metrics[0])
+	}
+	event, err := json.Marshal(data.Metrics[0].Event(42))
+	if err != nil || string(event) != `{"value":20.5,"timestamp":42,"datatype":"Double"}` {
+		t.Error(string(event), err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Run(testCommand("42", Metric{Name: "setpoint", DataType: Int64, Value: int64(42)}))
+	t.Run(testCommand("21.5", Metric{Name: "setpoint", DataType: Double, Value: 21.5}))
+	t.Run(testCommand(`{"value": 42, "datatype": "Float"}`, Metric{Name: "setpoint", DataType: Float, Value: float32(42)}))
+	t.Run(testCommand(`{"value": -3, "datatype": "Int16"}`, Metric{Name: "setpoint", DataType: Int16, Value: int64(-3)}))
+	t.Run(testCommand("true", Metric{Name: "setpoint", DataType: Boolean, Value: true}))
+	t.Run(testCommand("on", Metric{Name: "setpoint", DataType: String, Value: "on"}))
+	t.Run(testCommand(`{"mode": "auto"}`, Metric{Name: "setpoint", DataType: String, Value: `{"mode":"auto"}`}))
+}
+
+func testParseTopic(topic string, expected Topic, expectedOk bool) (string, func(t *testing.T)) {
+	return topic, func(t *testing.T) {
+		actual, ok := ParseTopic(topic)
+		if ok != expectedOk {
+			t.Error(ok, expectedOk)
+			return
+		}
+		if ok && actual != expected {
+			t.Error(actual, expected)
+		}
+	}
+}
+
+func testCommand(payload string, expected Metric) (string, func(t *testing.T)) {
+	return payload, func(t *testing.T) {
+		topic, msg, err := Command("spBv1.0:plant:edge1:pump", "setpoint", payload)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if topic != "spBv1.0/plant/DCMD/edge1/pump" {
+			t.Error(topic)
+		}
+		decoded, err := Decode(msg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if decoded.Timestamp == 0 || len(decoded.Metrics) != 1 {
+			t.Error(decoded)
+			return
+		}
+		if !reflect.DeepEqual(decoded.Metrics[0], expected) {
+			t.Error(decoded.Metrics[0], expected)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sparkplug
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// minimal protobuf wire format support for the sparkplug b payload schema
+
+var ErrInvalidWireFormat = errors.New("invalid protobuf wire format")
+
+type wireType uint8
+
+const (
+	wireVarint  wireType = 0
+	wireFixed64 wireType = 1
+	wireBytes   wireType = 2
+	wireFixed32 wireType = 5
+)
+
+type field struct {
+	num   uint64
+	typ   wireType
+	value uint64 //varint and fixed values
+	bytes []byte //length delimited values
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+func appendTag(b []byte, num uint64, typ wireType) []byte {
+	return appendVarint(b, num<<3|uint64(typ))
+}
+
+func appendVarintField(b []byte, num uint64, v uint64) []byte {
+	return appendVarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendBytesField(b []byte, num uint64, v []byte) []byte {
+	b = appendVarint(appendTag(b, num, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendFixed32Field(b []byte, num uint64, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(appendTag(b, num, wireFixed32), v)
+}
+
+func appendFixed64Field(b []byte, num uint64, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(appendTag(b, num, wireFixed64), v)
+}
+
+func appendBoolField(b []byte, num uint64, v bool) []byte {
+	if v {
+		return appendVarintField(b, num, 1)
+	}
+	return appendVarintField(b, num, 0)
+}
+
+// readFields calls handler for every field of the message; groups are not supported
+func readFields(b []byte, handler func(f field) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrInvalidWireFormat
+		}
+		b = b[n:]
+		f := field{num: tag >> 3, typ: wireType(tag & 7)}
+		switch f.typ {
+		case wireVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				return ErrInvalidWireFormat
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return ErrInvalidWireFormat
+			}
+			f.value = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return ErrInvalidWireFormat
+			}
+			f.value = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return ErrInvalidWireFormat
+			}
+			f.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			return ErrInvalidWireFormat
+		}
+		err := handler(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+var ErrMissingDeviceTypeList = errors.New("device-type list not configured")
+
+// DeviceTypes is implemented by *devicerepo.Client
+type DeviceTypes interface {
+	ListDeviceTypesByProtocol(token security.JwtToken, protocolId string) ([]model.DeviceType, error)
+}
+
+// kinds of Finding
+const (
+	FindingOverlap               = "overlap"                 //topics of the longer local id also match the shorter one; the longer one is selected
+	FindingUnreachable           = "unreachable"             //every topic of the first service also matches the second, which is always selected
+	FindingAmbiguous             = "ambiguous"               //equally long local ids match the same topics; the selected service is undefined
+	FindingCommandTopicCollision = "command_topic_collision" //Create returns the same command topic for the services
+	FindingInvalidCommandTopic   = "invalid_command_topic"   //Create fails for the service
+	FindingInvalidDeliveryAttr   = "invalid_delivery_attr"   //QosAttr or RetainAttr of the device-type or service is invalid and ignored
+)
+
+type Finding struct {
+	Kind       string   `json:"kind"`
+	ServiceIds []string `json:"service_ids"`
+	LocalIds   []string `json:"local_ids"`
+	Topic      string   `json:"topic,omitempty"` //example topic matching the services or the colliding command topic
+	Message    string   `json:"message"`
+}
+
+type Analysis struct {
+	DeviceTypeId   string    `json:"device_type_id"`
+	DeviceTypeName string    `json:"device_type_name"`
+	Findings       []Finding `json:"findings"`
+}
+
+// AnalyseProtocol analyses all device-types with services of the protocol
+func (this *Topic) AnalyseProtocol(token security.JwtToken, protocolId string) (result []Analysis, err error) {
+	if this.deviceTypes == nil {
+		return result, ErrMissingDeviceTypeList
+	}
+	deviceTypes, err := this.deviceTypes.ListDeviceTypesByProtocol(token, protocolId)
+	if err != nil {
+		return result, err
+	}
+	result = []Analysis{}
+	for _, deviceType := range deviceTypes {
+		result = append(result, this.AnalyseDeviceType(deviceType))
+	}
+	return result, nil
+}
+
+func (this *Topic) AnalyseDeviceTypeById(token security.JwtToken, deviceTypeId string) (result Analysis, err error) {
+	deviceType, err := this.getDeviceType(token, deviceTypeId)
+	if err != nil {
+		return result, err
+	}
+	return this.AnalyseDeviceType(deviceType), nil
+}
+
+// AnalyseDeviceType reports services which collide in the service matching of Parse (see serviceMatchesTopic)
+// and services with colliding or invalid command topics of Create or invalid delivery attributes (see CommandDelivery).
+// services of device-types with a convention are matched exactly; only their command topics are checked
+func (this *Topic) AnalyseDeviceType(deviceType model.DeviceType) (result Analysis) {
+	result = Analysis{DeviceTypeId: deviceType.Id, DeviceTypeName: deviceType.Name, Findings: []Finding{}}
+	if getConventionAttr(deviceType.Attributes) == "" {
+		result.Findings = append(result.Findings, analyseServiceMatches(deviceType.Services)...)
+	}
+	result.Findings = append(result.Findings, this.analyseCommandTopics(deviceType)...)
+	result.Findings = append(result.Findings, analyseDeliveryAttributes(deviceType)...)
+	return result
+}
+
+func analyseDeliveryAttributes(deviceType model.DeviceType) (findings []Finding) {
+	if _, err := applyDeliveryAttributes(Delivery{}, deviceType.Attributes); err != nil {
+		findings = append(findings, newFinding(FindingInvalidDeliveryAttr, "", err.Error()))
+	}
+	for _, service := range deviceType.Services {
+		if _, err := applyDeliveryAttributes(Delivery{}, service.Attributes); err != nil {
+			findings = append(findings, newFinding(FindingInvalidDeliveryAttr, "", err.Error(), service))
+		}
+	}
+	return findings
+}
+
+// analyseServiceMatches compares the local ids as topic level sequences, like serviceMatchesTopic.
+// local ids with placeholders are command templates and are skipped
+func analyseServiceMatches(services []model.Service) (findings []Finding) {
+	services = slices.DeleteFunc(slices.Clone(services), func(service model.Service) bool {
+		return strings.Contains(service.LocalId, "{{")
+	})
+	levels := make([][]string, len(services))
+	for i, service := range services {
+		levels[i] = localIdLevels(service.LocalId)
+	}
+	for i := range services {
+		for j := i + 1; j < len(services); j++ {
+			iInJ := containsLevels(levels[j], levels[i])
+			jInI := containsLevels(levels[i], levels[j])
+			if !iInJ && !jInI {
+				continue
+			}
+			inner, outer := services[i], services[j]
+			if jInI && !iInJ {
+				inner, outer = services[j], services[i]
+			}
+			example := exampleTemplateValues["DeviceId"] + "/" + strings.Trim(outer.LocalId, "/")
+			switch {
+			case len(inner.LocalId) == len(outer.LocalId):
+				findings = append(findings, newFinding(FindingAmbiguous, example, "topics match both services with equally long local ids; the selected service is undefined", inner, outer))
+			case iInJ && jInI:
+				shorter, longer := inner, outer
+				if len(shorter.LocalId) > len(longer.LocalId) {
+					shorter, longer = longer, shorter
+				}
+				findings = append(findings, newFinding(FindingUnreachable, example, fmt.Sprintf("%v is unreachable: every topic also matches the longer %v", shorter.LocalId, longer.LocalId), shorter, longer))
+			case len(inner.LocalId) > len(outer.LocalId):
+				findings = append(findings, newFinding(FindingUnreachable, example, fmt.Sprintf("%v is unreachable: every topic also matches the longer %v", outer.LocalId, inner.LocalId), outer, inner))
+			default:
+				findings = append(findings, newFinding(FindingOverlap, example, fmt.Sprintf("topics of %v also match %v; the longer %v is selected", outer.LocalId, inner.LocalId, outer.LocalId), inner, outer))
+			}
+		}
+	}
+	return findings
+}
+
+// localIdLevels returns the topic levels matched by serviceMatchesTopic; empty for local ids matching every topic
+func localIdLevels(localId string) []string {
+	trimmed := strings.Trim(localId, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// containsLevels is true if inner is a sequence of outer
+func containsLevels(outer []string, inner []string) bool {
+	for start := 0; start+len(inner) <= len(outer); start++ {
+		if slices.Equal(outer[start:start+len(inner)], inner) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyseCommandTopics creates the command topic of every service with example values for a device of the device-type
+func (this *Topic) analyseCommandTopics(deviceType model.DeviceType) (findings []Finding) {
+	byTopic := map[string][]model.Service{}
+	topics := []string{}
+	for _, service := range deviceType.Services {
+		topic, err := this.exampleCommandTopic(deviceType, service)
+		if err != nil {
+			findings = append(findings, newFinding(FindingInvalidCommandTopic, "", err.Error(), service))
+			continue
+		}
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], service)
+	}
+	for _, topic := range topics {
+		if services := byTopic[topic]; len(services) > 1 {
+			findings = append(findings, newFinding(FindingCommandTopicCollision, topic, "services share the command topic", services...))
+		}
+	}
+	return findings
+}
+
+func (this *Topic) exampleCommandTopic(deviceType model.DeviceType, service model.Service) (string, error) {
+	if name := getConventionAttr(deviceType.Attributes); name != "" {
+		convention, ok := GetConvention(name)
+		if !ok {
+			return "", errors.Join(ErrUnknownConvention, errors.New(name))
+		}
+		return convention.Create(exampleTemplateValues["LocalDeviceId"], service.LocalId)
+	}
+	values := maps.Clone(exampleTemplateValues)
+	values["DeviceTypeId"] = deviceType.Id
+	values["ServiceId"] = service.Id
+	values["LocalServiceId"] = service.LocalId
+	for _, key := range []string{"DeviceTypeId", "ServiceId"} {
+		short, err := shortid.ShortId(values[key])
+		if err != nil {
+			delete(values, "Short"+key) //like templateValues
+			continue
+		}
+		values["Short"+key] = short
+	}
+	return this.createFromValues(values, "", executeTopicTemplate)
+}
+
+func newFinding(kind string, topic string, message string, services ...model.Service) Finding {
+	result := Finding{Kind: kind, Topic: topic, Message: message, ServiceIds: []string{}, LocalIds: []string{}}
+	for _, service := range services {
+		result.ServiceIds = append(result.ServiceIds, service.Id)
+		result.LocalIds = append(result.LocalIds, service.LocalId)
+	}
+	return result
+}
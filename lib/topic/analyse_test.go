@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestAnalyseDeviceType(t *testing.T) {
+	topic := New(nil, "{{.DeviceId}}/cmnd/{{.LocalServiceId}}")
+	deviceType := model.DeviceType{
+		Id: "dt",
+		Services: []model.Service{
+			{Id: "s1", LocalId: "temp"},
+			{Id: "s2", LocalId: "room/temp"},
+			{Id: "s3", LocalId: "power"},
+			{Id: "s4", LocalId: "/power/"},
+			{Id: "s5", LocalId: "x/y"},
+			{Id: "s6", LocalId: "x/y"},
+			{Id: "s7", LocalId: "{{.LocalDeviceId}}/set"},
+			{Id: "s8", LocalId: "{{.LocalDeviceId | upper | lower}}/set"},
+			{Id: "s9", LocalId: "{{.Unknown}}/set"},
+			{Id: "s10", LocalId: "humidity"},
+		},
+	}
+	expected := []string{
+		"ambiguous x/y,x/y",
+		"command_topic_collision x/y,x/y",
+		"command_topic_collision {{.LocalDeviceId}}/set,{{.LocalDeviceId | upper | lower}}/set",
+		"invalid_command_topic {{.Unknown}}/set",
+		"overlap temp,room/temp",
+		"unreachable power,/power/",
+	}
+	t.Run("heuristic", testAnalyseDeviceType(topic, deviceType, expected))
+
+	//services of device-types with conventions are matched exactly
+	deviceType.Attributes = []models.Attribute{{Key: ConventionAttr, Value: "zigbee2mqtt"}}
+	t.Run("convention", testAnalyseDeviceType(topic, deviceType, []string{
+		"command_topic_collision x/y,x/y",
+	}))
+}
+
+func testAnalyseDeviceType(topic *Topic, deviceType model.DeviceType, expected []string) func(t *testing.T) {
+	return func(t *testing.T) {
+		actual := []string{}
+		for _, finding := range topic.AnalyseDeviceType(deviceType).Findings {
+			actual = append(actual, finding.Kind+" "+strings.Join(finding.LocalIds, ","))
+		}
+		slices.Sort(actual)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("\n%#v\n%#v", actual, expected)
+		}
+	}
+}
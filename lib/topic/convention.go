@@ -0,0 +1,232 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// ConventionAttr selects the Convention of a device (device attribute) or of all devices of a device-type (device-type attribute)
+const ConventionAttr = "senergy/mqtt-convention"
+
+var ErrUnknownConvention = errors.New("unknown mqtt convention")
+
+// Reference is a device and service referenced by a topic of a Convention
+type Reference struct {
+	LocalDeviceId  string
+	LocalServiceId string
+}
+
+// Convention knows the topic layout of a device family
+type Convention interface {
+	// Parse returns the possible references of the topic; none if the topic does not belong to the convention
+	Parse(topic string) []Reference
+	// Create returns the command topic for the service of the device
+	Create(localDeviceId string, localServiceId string) (string, error)
+}
+
+var conventionsMux sync.RWMutex
+var conventions = map[string]Convention{
+	"zigbee2mqtt": Zigbee2Mqtt{BaseTopic: "zigbee2mqtt"},
+	"tasmota":     Tasmota{},
+}
+
+// RegisterConvention adds or replaces the convention selectable by ConventionAttr
+func RegisterConvention(name string, convention Convention) {
+	conventionsMux.Lock()
+	defer conventionsMux.Unlock()
+	conventions[name] = convention
+}
+
+func GetConvention(name string) (convention Convention, ok bool) {
+	conventionsMux.RLock()
+	defer conventionsMux.RUnlock()
+	convention, ok = conventions[name]
+	return convention, ok
+}
+
+// MatchesConvention returns true if the topic may reference a device of a registered convention
+func MatchesConvention(topic string) bool {
+	conventionsMux.RLock()
+	defer conventionsMux.RUnlock()
+	for _, convention := range conventions {
+		if len(convention.Parse(topic)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ConventionName returns the name of the convention selected by the device or its device-type; empty if none is selected
+func (this *Topic) ConventionName(token security.JwtToken, device model.Device) string {
+	if name := getConventionAttr(device.Attributes); name != "" {
+		return name
+	}
+	if this.iotCache == nil {
+		return ""
+	}
+	deviceType, err := this.getDeviceType(token, device.DeviceTypeId)
+	if err != nil {
+		return ""
+	}
+	return getConventionAttr(deviceType.Attributes)
+}
+
+func getConventionAttr(attributes []models.Attribute) string {
+	for _, attr := range attributes {
+		if attr.Key == ConventionAttr {
+			return strings.ToLower(strings.TrimSpace(attr.Value))
+		}
+	}
+	return ""
+}
+
+// CreateForDevice uses the convention of the device if one is selected, else Create
+func (this *Topic) CreateForDevice(token security.JwtToken, device model.Device, localServiceId string) (topic string, err error) {
+	return this.CreateForService(token, device, model.Service{LocalId: localServiceId})
+}
+
+// CreateForService uses the convention of the device if one is selected, prefixed with the owner id of the device,
+// because local ids of convention devices are only unique per user;
+// else the topic is created like by Create, but with all placeholders of the device and service and, if the device is in a hub, of the hub
+func (this *Topic) CreateForService(token security.JwtToken, device model.Device, service model.Service) (topic string, err error) {
+	if name := this.ConventionName(token, device); name != "" {
+		convention, ok := GetConvention(name)
+		if !ok {
+			return topic, errors.Join(ErrUnknownConvention, errors.New(name))
+		}
+		topic, err = convention.Create(device.LocalId, service.LocalId)
+		if err != nil {
+			return topic, err
+		}
+		return WithPrefix(device.OwnerId, topic), nil
+	}
+	return this.create(token, device, service)
+}
+
+// parseByConvention returns ok=false if no device using a convention is referenced by the topic
+func (this *Topic) parseByConvention(token security.JwtToken, topic string) (device model.Device, service model.Service, ok bool, err error) {
+	conventionsMux.RLock()
+	references := map[string][]Reference{}
+	localIds := []string{}
+	for name, convention := range conventions {
+		for _, ref := range convention.Parse(topic) {
+			references[name] = append(references[name], ref)
+			localIds = append(localIds, ref.LocalDeviceId)
+		}
+	}
+	conventionsMux.RUnlock()
+	if len(localIds) == 0 {
+		return device, service, false, nil
+	}
+	devices, err := this.getDevicesByLocalIds(token, localIds)
+	if err != nil {
+		return device, service, true, err
+	}
+	matches := []model.Device{}
+	matchingServices := []model.Service{}
+	for _, d := range devices {
+		for _, ref := range references[this.ConventionName(token, d)] {
+			if ref.LocalDeviceId != d.LocalId {
+				continue
+			}
+			deviceType, err := this.getDeviceType(token, d.DeviceTypeId)
+			if err != nil {
+				return device, service, true, err
+			}
+			s := model.Service{}
+			for _, candidate := range deviceType.Services {
+				if candidate.LocalId == ref.LocalServiceId {
+					s = candidate
+					break
+				}
+			}
+			matches = append(matches, d)
+			matchingServices = append(matchingServices, s)
+			break
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return device, service, false, nil
+	case len(matches) > 1:
+		return device, service, true, ErrMultipleMatchingDevicesFound
+	case matchingServices[0].Id == "":
+		return matches[0], service, true, ErrNoServiceMatchFound
+	default:
+		return matches[0], matchingServices[0], true, nil
+	}
+}
+
+// Zigbee2Mqtt maps <BaseTopic>/<friendly_name>[/<sub topic>] to the device with the friendly name as local id.
+// the state topic is mapped to the service "state", sub topics to services with the sub topic as local id (e.g. "availability", "set", "set/brightness").
+type Zigbee2Mqtt struct {
+	BaseTopic string
+}
+
+func (this Zigbee2Mqtt) Parse(topic string) (result []Reference) {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 2 || parts[0] != this.BaseTopic || parts[1] == "bridge" {
+		return nil
+	}
+	//friendly names may contain slashes
+	for i := 2; i <= len(parts); i++ {
+		service := strings.Join(parts[i:], "/")
+		if service == "" {
+			service = "state"
+		}
+		result = append(result, Reference{LocalDeviceId: strings.Join(parts[1:i], "/"), LocalServiceId: service})
+	}
+	return result
+}
+
+func (this Zigbee2Mqtt) Create(localDeviceId string, localServiceId string) (string, error) {
+	if localServiceId == "state" || localServiceId == "" {
+		return this.BaseTopic + "/" + localDeviceId, nil
+	}
+	return this.BaseTopic + "/" + localDeviceId + "/" + localServiceId, nil
+}
+
+// Tasmota maps <prefix>/<topic>/<command> (prefix = cmnd, stat or tele) to the device with the tasmota topic as local id
+// and the service with the local id <prefix>/<command> (e.g. "tele/SENSOR", "stat/POWER", "cmnd/POWER").
+type Tasmota struct{}
+
+var tasmotaPrefixes = []string{"cmnd", "stat", "tele"}
+
+func (this Tasmota) Parse(topic string) []Reference {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 3 || !slices.Contains(tasmotaPrefixes, parts[0]) {
+		return nil
+	}
+	return []Reference{{LocalDeviceId: parts[1], LocalServiceId: parts[0] + "/" + strings.Join(parts[2:], "/")}}
+}
+
+// Create uses the cmnd prefix for services without tasmota prefix
+func (this Tasmota) Create(localDeviceId string, localServiceId string) (string, error) {
+	prefix, command, found := strings.Cut(localServiceId, "/")
+	if !found || !slices.Contains(tasmotaPrefixes, prefix) {
+		prefix, command = "cmnd", localServiceId
+	}
+	return prefix + "/" + localDeviceId + "/" + command, nil
+}
@@ -17,56 +17,107 @@
 package topic
 
 import (
-	"bytes"
 	"strings"
-	"text/template"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
 )
 
+// Create uses the local service id as template or, if it contains no placeholder, the default actuator pattern.
+// only the DeviceId, ShortDeviceId and LocalServiceId placeholders are known; others render as "<no value>" instead of failing,
+// so the default pattern may reference LocalDeviceId. use CreateForService for the other placeholders
 func (this *Topic) Create(deviceId string, localServiceId string) (topic string, err error) {
-	shortDeviceId, err := shortid.ShortId(deviceId)
+	values, err := this.templateValues("", model.Device{Id: deviceId}, model.Service{LocalId: localServiceId}, false)
 	if err != nil {
 		return topic, err
 	}
-	topic, err = this.createTopicFromLocalServiceId(deviceId, shortDeviceId, localServiceId)
+	return this.createFromValues(values, "", executeLenientTopicTemplate)
+}
+
+func (this *Topic) create(token security.JwtToken, device model.Device, service model.Service) (topic string, err error) {
+	values, err := this.templateValues(token, device, service, this.createReferencesHub(service.LocalId))
+	if err != nil {
+		return topic, err
+	}
+	return this.createFromValues(values, device.OwnerId, executeTopicTemplate)
+}
+
+// createReferencesHub returns true if the template used by createFromValues for the local service id references hub placeholders
+func (this *Topic) createReferencesHub(localServiceId string) bool {
+	tmpl, err := getTopicTemplate(localServiceId)
+	if err != nil {
+		return false
+	}
+	if hasPlaceholders(tmpl) {
+		return templateReferences(tmpl, hubPlaceholders)
+	}
+	tmpl, err = getTopicTemplate(this.defaultActuatorPattern)
+	return err == nil && templateReferences(tmpl, hubPlaceholders)
+}
+
+// createFromValues uses values["LocalServiceId"] as template or the default actuator pattern,
+// applies the command topic rewrite rules of the owner and adds the values["DeviceId"] prefix
+func (this *Topic) createFromValues(values map[string]string, ownerId string, execute func(text string, values map[string]string) (string, error)) (topic string, err error) {
+	localServiceId := values["LocalServiceId"]
+	topic, err = execute(localServiceId, values)
 	if err != nil {
 		return topic, err
 	}
 	if topic == localServiceId {
-		topic, err = this.createTopicFromDefaultPattern(deviceId, shortDeviceId, localServiceId)
+		topic, err = execute(this.defaultActuatorPattern, values)
 		if err != nil {
 			return topic, err
 		}
 	}
-	prefix := deviceId + "/"
-	if !strings.HasPrefix(topic, prefix) {
-		topic = prefix + topic
-	}
-	return topic, nil
+	topic = this.commandRewriter.Rewrite(ownerId, topic)
+	return WithPrefix(values["DeviceId"], topic), nil
 }
 
-func (this *Topic) createTopicFromLocalServiceId(deviceId string, shortDeviceId, localServiceId string) (topic string, err error) {
-	var temp bytes.Buffer
-	err = template.Must(template.New("").Parse(localServiceId)).Execute(&temp, map[string]string{
-		"DeviceId":      deviceId,
-		"ShortDeviceId": shortDeviceId,
-	})
-	if err != nil {
-		return
+// WithPrefix adds prefix as first topic level, if the topic does not start with it.
+// commands are published to topics prefixed with the device id or, for topics of conventions, with the id of the device owner
+func WithPrefix(prefix string, topic string) string {
+	if strings.HasPrefix(topic, prefix+"/") {
+		return topic
 	}
-	return temp.String(), nil
+	return prefix + "/" + topic
 }
 
-func (this *Topic) createTopicFromDefaultPattern(deviceId string, shortDeviceId string, localServiceId string) (result string, err error) {
-	var temp bytes.Buffer
-	err = template.Must(template.New("").Parse(this.defaultActuatorPattern)).Execute(&temp, map[string]string{
-		"DeviceId":       deviceId,
-		"ShortDeviceId":  shortDeviceId,
-		"LocalServiceId": localServiceId,
-	})
+// templateValues returns the placeholder values known for the device and service.
+// if withHub is set, hub placeholders are set for devices in a hub (see getHubOfDevice); unknown placeholders fail the template execution
+func (this *Topic) templateValues(token security.JwtToken, device model.Device, service model.Service, withHub bool) (values map[string]string, err error) {
+	shortDeviceId, err := shortid.ShortId(device.Id)
 	if err != nil {
-		return
+		return values, err
+	}
+	values = map[string]string{
+		"DeviceId":       device.Id,
+		"ShortDeviceId":  shortDeviceId,
+		"LocalServiceId": service.LocalId,
+	}
+	setIfKnown := func(key string, value string) {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	setIfKnown("LocalDeviceId", device.LocalId)
+	setIfKnown("DeviceTypeId", device.DeviceTypeId)
+	setIfKnown("ServiceId", service.Id)
+	if short, err := shortid.ShortId(device.DeviceTypeId); err == nil {
+		setIfKnown("ShortDeviceTypeId", short)
+	}
+	if short, err := shortid.ShortId(service.Id); err == nil {
+		setIfKnown("ShortServiceId", short)
+	}
+	if !withHub {
+		return values, nil
+	}
+	if hub, ok := this.getHubOfDevice(token, device); ok {
+		setIfKnown("HubId", hub.Id)
+		setIfKnown("HubLocalId", hub.Name)
+		if short, err := shortid.ShortId(hub.Id); err == nil {
+			setIfKnown("ShortHubId", short)
+		}
 	}
-	return temp.String(), nil
+	return values, nil
 }
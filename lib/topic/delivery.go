@@ -0,0 +1,81 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// QosAttr selects the mqtt qos (0, 1 or 2) of commands to a service (service attribute) or to all services of a device-type (device-type attribute)
+const QosAttr = "senergy/mqtt-qos"
+
+// RetainAttr selects if commands to a service (service attribute) or to all services of a device-type (device-type attribute) are retained ("true" or "false")
+const RetainAttr = "senergy/mqtt-retain"
+
+var ErrInvalidDeliveryAttr = errors.New("invalid mqtt delivery attribute")
+
+// Delivery is the qos and retain flag of a command
+type Delivery struct {
+	Qos    byte
+	Retain bool
+}
+
+// CommandDelivery returns the delivery of commands to the service. service attributes take precedence over device-type attributes,
+// which take precedence over defaults. invalid attributes are skipped and returned as error together with the remaining result
+func (this *Topic) CommandDelivery(token security.JwtToken, device model.Device, service model.Service, defaults Delivery) (result Delivery, err error) {
+	result = defaults
+	if this.iotCache != nil && device.DeviceTypeId != "" {
+		deviceType, dtErr := this.getDeviceType(token, device.DeviceTypeId)
+		if dtErr == nil {
+			result, err = applyDeliveryAttributes(result, deviceType.Attributes)
+		}
+	}
+	result, serviceErr := applyDeliveryAttributes(result, service.Attributes)
+	return result, errors.Join(err, serviceErr)
+}
+
+func applyDeliveryAttributes(delivery Delivery, attributes []models.Attribute) (result Delivery, err error) {
+	result = delivery
+	errs := []error{}
+	for _, attr := range attributes {
+		value := strings.ToLower(strings.TrimSpace(attr.Value))
+		switch attr.Key {
+		case QosAttr:
+			qos, parseErr := strconv.ParseUint(value, 10, 8)
+			if parseErr != nil || qos > 2 {
+				errs = append(errs, fmt.Errorf("%w: %v=%v", ErrInvalidDeliveryAttr, attr.Key, attr.Value))
+				continue
+			}
+			result.Qos = byte(qos)
+		case RetainAttr:
+			retain, parseErr := strconv.ParseBool(value)
+			if parseErr != nil {
+				errs = append(errs, fmt.Errorf("%w: %v=%v", ErrInvalidDeliveryAttr, attr.Key, attr.Value))
+				continue
+			}
+			result.Retain = retain
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestApplyDeliveryAttributes(t *testing.T) {
+	deviceTypeAttributes := []models.Attribute{{Key: QosAttr, Value: "1"}, {Key: RetainAttr, Value: "true"}}
+	result, err := applyDeliveryAttributes(Delivery{Qos: 2}, deviceTypeAttributes)
+	if err != nil || result != (Delivery{Qos: 1, Retain: true}) {
+		t.Error(err, result)
+	}
+	result, err = applyDeliveryAttributes(result, []models.Attribute{{Key: QosAttr, Value: " 0 "}, {Key: RetainAttr, Value: "False"}})
+	if err != nil || result != (Delivery{Qos: 0, Retain: false}) {
+		t.Error(err, result)
+	}
+	result, err = applyDeliveryAttributes(Delivery{Qos: 2}, []models.Attribute{{Key: QosAttr, Value: "3"}, {Key: RetainAttr, Value: "yes"}, {Key: "other", Value: "x"}})
+	if !errors.Is(err, ErrInvalidDeliveryAttr) || result != (Delivery{Qos: 2}) {
+		t.Error(err, result)
+	}
+}
+
+func TestCommandDelivery(t *testing.T) {
+	topic := New(nil, "")
+	service := model.Service{Id: "s", Attributes: []models.Attribute{{Key: QosAttr, Value: "0"}}}
+	result, err := topic.CommandDelivery("", model.Device{Id: "d"}, service, Delivery{Qos: 2, Retain: true})
+	if err != nil || result != (Delivery{Qos: 0, Retain: true}) {
+		t.Error(err, result)
+	}
+}
+
+func TestAnalyseDeliveryAttributes(t *testing.T) {
+	topic := New(nil, "{{.LocalServiceId}}")
+	analysis := topic.AnalyseDeviceType(model.DeviceType{
+		Id:         "dt",
+		Attributes: []models.Attribute{{Key: RetainAttr, Value: "maybe"}},
+		Services: []model.Service{
+			{Id: "s1", LocalId: "set", Attributes: []models.Attribute{{Key: QosAttr, Value: "1"}}},
+			{Id: "s2", LocalId: "get", Attributes: []models.Attribute{{Key: QosAttr, Value: "high"}}},
+		},
+	})
+	if len(analysis.Findings) != 2 {
+		t.Errorf("%#v", analysis.Findings)
+		return
+	}
+	for _, finding := range analysis.Findings {
+		if finding.Kind != FindingInvalidDeliveryAttr {
+			t.Errorf("%#v", finding)
+		}
+	}
+	if len(analysis.Findings[1].ServiceIds) != 1 || analysis.Findings[1].ServiceIds[0] != "s2" {
+		t.Errorf("%#v", analysis.Findings[1])
+	}
+}
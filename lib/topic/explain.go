@@ -0,0 +1,270 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"slices"
+
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// Explanation describes the steps of Parse for a topic; steps skipped by Parse are not set
+type Explanation struct {
+	Topic           string                  `json:"topic"`
+	RewrittenFrom   string                  `json:"rewritten_from,omitempty"` //requested topic, if Topic is the result of the topic rewrite rules
+	Convention      *ExplainedMatch         `json:"convention,omitempty"`     //set if the topic was matched by a convention (ConventionAttr)
+	Pattern         *ExplainedMatch         `json:"pattern,omitempty"`        //set if the topic was matched by a global event topic pattern
+	IdCandidates    []ExplainedIdCandidate  `json:"id_candidates"`
+	HubLookup       *ExplainedHubLookup     `json:"hub_lookup,omitempty"`      //set if no device was found by IdCandidates and hubs are configured
+	LocalIdLookup   *ExplainedLocalIdLookup `json:"local_id_lookup,omitempty"` //set if no device was found by IdCandidates or HubLookup
+	Candidates      []ExplainedCandidate    `json:"candidates"`
+	CandidatesError string                  `json:"candidates_error,omitempty"`
+	Result          ExplainedResult         `json:"result"`
+}
+
+type ExplainedMatch struct {
+	Device  *ExplainedDevice  `json:"device,omitempty"`
+	Service *ExplainedService `json:"service,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type ExplainedIdCandidate struct {
+	Id      string `json:"id,omitempty"`
+	ShortId string `json:"short_id,omitempty"`
+	Found   bool   `json:"found"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ExplainedHubLookup struct {
+	Hubs    []ExplainedIdCandidate `json:"hubs"`
+	Devices []ExplainedDevice      `json:"devices"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+type ExplainedLocalIdLookup struct {
+	LocalIds []string          `json:"local_ids"`
+	Devices  []ExplainedDevice `json:"devices"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type ExplainedCandidate struct {
+	Device                ExplainedDevice    `json:"device"`
+	Rank                  int                `json:"rank"`  //position in ParseForCandidates; 0 is preferred, -1 if the candidate was dropped in favour of exact matches
+	Exact                 bool               `json:"exact"` //services matched by an event topic pattern of the device-type
+	MatchedServices       []ExplainedService `json:"matched_services"`
+	FilteredByAssociation []ExplainedService `json:"filtered_by_association"`
+	Error                 string             `json:"error,omitempty"`
+}
+
+type ExplainedResult struct {
+	Device  *ExplainedDevice  `json:"device,omitempty"`
+	Service *ExplainedService `json:"service,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type ExplainedDevice struct {
+	Id           string `json:"id"`
+	LocalId      string `json:"local_id"`
+	Name         string `json:"name"`
+	DeviceTypeId string `json:"device_type_id"`
+}
+
+type ExplainedService struct {
+	Id              string `json:"id"`
+	LocalId         string `json:"local_id"`
+	Name            string `json:"name"`
+	AssociatedTopic string `json:"associated_topic,omitempty"`
+}
+
+// Explain records the steps of Parse; in contrast to Parse, no service topic association is stored
+func (this *Topic) Explain(token security.JwtToken, topic string) (result Explanation) {
+	result.Topic = topic
+	result.IdCandidates = []ExplainedIdCandidate{}
+	result.Candidates = []ExplainedCandidate{}
+	device, service, _, err := this.parse(token, topic, &result)
+	if device.Id != "" {
+		explained := explainDevice(device)
+		result.Result.Device = &explained
+	}
+	if service.Id != "" {
+		explained := explainService(service)
+		result.Result.Service = &explained
+	}
+	if err != nil {
+		result.Result.Error = err.Error()
+	}
+	return result
+}
+
+// the following methods are called by parse to record its steps; Parse passes a nil *Explanation, which records nothing
+
+func (this *Explanation) setConvention(device model.Device, service model.Service, err error) {
+	if this != nil {
+		this.Convention = explainMatch(device, service, err)
+	}
+}
+
+func (this *Explanation) setPattern(device model.Device, service model.Service, err error) {
+	if this != nil {
+		this.Pattern = explainMatch(device, service, err)
+	}
+}
+
+func (this *Explanation) setCandidatesError(err error) {
+	if this != nil {
+		this.CandidatesError = err.Error()
+	}
+}
+
+// addIdCandidate records a device id or, if shortId is set, the device id decoded from the short id
+func (this *Explanation) addIdCandidate(id string, shortId string, err error) {
+	if this == nil {
+		return
+	}
+	candidate := ExplainedIdCandidate{Id: id, ShortId: shortId}
+	if err != nil {
+		candidate = ExplainedIdCandidate{ShortId: shortId, Error: err.Error()}
+	}
+	this.IdCandidates = append(this.IdCandidates, candidate)
+}
+
+func (this *Explanation) setIdCandidateResult(id string, err error) {
+	if this == nil {
+		return
+	}
+	for i, candidate := range this.IdCandidates {
+		if candidate.Id == id && !candidate.Found && candidate.Error == "" {
+			if err != nil {
+				this.IdCandidates[i].Error = err.Error()
+			} else {
+				this.IdCandidates[i].Found = true
+			}
+		}
+	}
+}
+
+func (this *Explanation) startHubLookup() {
+	if this != nil {
+		this.HubLookup = &ExplainedHubLookup{Hubs: []ExplainedIdCandidate{}, Devices: []ExplainedDevice{}}
+	}
+}
+
+func (this *Explanation) addHub(hubId string, err error) {
+	if this == nil || this.HubLookup == nil {
+		return
+	}
+	candidate := ExplainedIdCandidate{Id: hubId, Found: err == nil}
+	if err != nil {
+		candidate.Error = err.Error()
+	}
+	this.HubLookup.Hubs = append(this.HubLookup.Hubs, candidate)
+}
+
+func (this *Explanation) addHubDevices(devices []model.Device, err error) {
+	if this == nil || this.HubLookup == nil {
+		return
+	}
+	for _, device := range devices {
+		this.HubLookup.Devices = append(this.HubLookup.Devices, explainDevice(device))
+	}
+	if err != nil {
+		this.HubLookup.Error = err.Error()
+	}
+}
+
+func (this *Explanation) setLocalIdLookup(localIds []string, devices []model.Device, err error) {
+	if this == nil {
+		return
+	}
+	this.LocalIdLookup = &ExplainedLocalIdLookup{LocalIds: localIds, Devices: []ExplainedDevice{}}
+	for _, device := range devices {
+		this.LocalIdLookup.Devices = append(this.LocalIdLookup.Devices, explainDevice(device))
+	}
+	if err != nil {
+		this.LocalIdLookup.Error = err.Error()
+	}
+}
+
+// addCandidate records the matched services of a candidate device and the services filtered by association
+func (this *Explanation) addCandidate(device model.Device, services []model.Service, filtered []filteredService, exact bool, err error) {
+	if this == nil {
+		return
+	}
+	result := ExplainedCandidate{
+		Device:                explainDevice(device),
+		Rank:                  -1,
+		Exact:                 exact,
+		MatchedServices:       []ExplainedService{},
+		FilteredByAssociation: []ExplainedService{},
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for _, service := range services {
+		result.MatchedServices = append(result.MatchedServices, explainService(service))
+	}
+	for _, f := range filtered {
+		explained := explainService(f.service)
+		explained.AssociatedTopic = f.associatedTopic
+		result.FilteredByAssociation = append(result.FilteredByAssociation, explained)
+	}
+	this.Candidates = append(this.Candidates, result)
+}
+
+// rankCandidates sets the position of the recorded candidates in the result of ParseForCandidates
+func (this *Explanation) rankCandidates(ranked []candidate) {
+	if this == nil {
+		return
+	}
+	for i, explained := range this.Candidates {
+		this.Candidates[i].Rank = slices.IndexFunc(ranked, func(c candidate) bool { return c.device.Id == explained.Device.Id })
+	}
+}
+
+func explainMatch(device model.Device, service model.Service, err error) *ExplainedMatch {
+	result := &ExplainedMatch{}
+	if device.Id != "" {
+		explained := explainDevice(device)
+		result.Device = &explained
+	}
+	if service.Id != "" {
+		explained := explainService(service)
+		result.Service = &explained
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func explainDevice(device model.Device) ExplainedDevice {
+	return ExplainedDevice{
+		Id:           device.Id,
+		LocalId:      device.LocalId,
+		Name:         device.Name,
+		DeviceTypeId: device.DeviceTypeId,
+	}
+}
+
+func explainService(service model.Service) ExplainedService {
+	return ExplainedService{
+		Id:      service.Id,
+		LocalId: service.LocalId,
+		Name:    service.Name,
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// Hubs is implemented by *devicerepo.Client
+type Hubs interface {
+	GetHub(token security.JwtToken, id string) (models.Hub, error)
+	GetHubOfDevice(token security.JwtToken, deviceId string, localDeviceId string) (models.Hub, error)
+	InvalidateDevice(deviceId string)
+}
+
+// deviceHubs remembers the hub of devices found by hub-scoped topics or by getHubOfDevice for the hub placeholders of created topics.
+// entries expire like the devices in the iot cache, so hub changes without invalidation are noticed
+type deviceHubs struct {
+	mux       sync.RWMutex
+	hubs      map[string]deviceHub
+	lastSweep time.Time
+}
+
+type deviceHub struct {
+	hub   models.Hub
+	until time.Time
+}
+
+// set removes expired entries at most once per expiration
+func (this *deviceHubs) set(deviceId string, hub models.Hub, expiration time.Duration) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if this.hubs == nil {
+		this.hubs = map[string]deviceHub{}
+	}
+	if now.Sub(this.lastSweep) > expiration {
+		this.lastSweep = now
+		for key, entry := range this.hubs {
+			if now.After(entry.until) {
+				delete(this.hubs, key)
+			}
+		}
+	}
+	this.hubs[deviceId] = deviceHub{hub: hub, until: now.Add(expiration)}
+}
+
+func (this *deviceHubs) remove(deviceId string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.hubs, deviceId)
+}
+
+func (this *deviceHubs) get(deviceId string) (hub models.Hub, ok bool) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	entry, ok := this.hubs[deviceId]
+	if !ok || time.Now().After(entry.until) {
+		return hub, false
+	}
+	return entry.hub, true
+}
+
+// InvalidateDevice removes cached hubs of the device; should be called after device updates.
+// until the iot cache entry expires, the device is read from the device-repository
+func (this *Topic) InvalidateDevice(deviceId string) {
+	this.invalidated.add(deviceId, this.deviceExpiration)
+	this.deviceHubs.remove(deviceId)
+	if this.hubs != nil {
+		this.hubs.InvalidateDevice(deviceId)
+	}
+}
+
+// RemoveDevice removes cached data and service topic associations of the device; should be called after device deletes
+func (this *Topic) RemoveDevice(deviceId string) {
+	this.InvalidateDevice(deviceId)
+	this.removeDeviceTopicAssociations(deviceId)
+}
+
+// getHub returns ErrNoDeviceMatchFound if no hub client is configured or the hub is not accessible
+func (this *Topic) getHub(token security.JwtToken, id string) (hub models.Hub, err error) {
+	if this.hubs == nil {
+		return hub, ErrNoDeviceMatchFound
+	}
+	hub, err = this.hubs.GetHub(token, id)
+	if errors.Is(err, devicerepo.ErrNotFound) {
+		return hub, ErrNoDeviceMatchFound
+	}
+	return hub, err
+}
+
+// getHubOfDevice returns the remembered hub of the device or looks it up in the device-repository, if a token is given;
+// ok is false if the device is in no hub. lookup errors are logged and handled like devices without hub
+func (this *Topic) getHubOfDevice(token security.JwtToken, device model.Device) (hub models.Hub, ok bool) {
+	if hub, ok = this.deviceHubs.get(device.Id); ok || this.hubs == nil || token == "" {
+		return hub, ok
+	}
+	hub, err := this.hubs.GetHubOfDevice(token, device.Id, device.LocalId)
+	if errors.Is(err, devicerepo.ErrNotFound) {
+		return hub, false
+	}
+	if err != nil {
+		slog.Error("unable to get hub of device", "device", device.Id, "error", err)
+		return hub, false
+	}
+	this.deviceHubs.set(device.Id, hub, this.deviceExpiration)
+	return hub, true
+}
+
+var hubIdRegex = regexp.MustCompile(`^urn:infai:ses:hub:[\w-]*$`)
+
+// findHubIdCandidates returns hub ids and short ids of the topic; short ids are returned as long hub ids
+func findHubIdCandidates(topic string) (candidates []string) {
+	for _, part := range strings.Split(topic, "/") {
+		if hubIdRegex.MatchString(part) {
+			candidates = append(candidates, part)
+		}
+	}
+	for _, shortCandidate := range findShortDeviceIdCandidates(topic) {
+		candidate, err := shortid.EnsureLongHubId(shortCandidate)
+		if err == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// findDeviceCandidatesByHub returns the devices of hubs referenced by the topic, whose local id is a level of the topic
+func (this *Topic) findDeviceCandidatesByHub(token security.JwtToken, topic string, trace *Explanation) (result []model.Device, err error) {
+	if this.hubs == nil {
+		return nil, nil
+	}
+	trace.startHubLookup()
+	levels := strings.Split(topic, "/")
+	for _, hubId := range findHubIdCandidates(topic) {
+		hub, err := this.getHub(token, hubId)
+		trace.addHub(hubId, err)
+		if errors.Is(err, ErrNoDeviceMatchFound) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		devices, err := this.getHubDevicesByLocalIds(token, hub, levels)
+		trace.addHubDevices(devices, err)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, devices...)
+	}
+	return result, nil
+}
+
+// getHubDevicesByLocalIds returns devices of the hub with one of the local ids and remembers the hub for them
+func (this *Topic) getHubDevicesByLocalIds(token security.JwtToken, hub models.Hub, localIds []string) (result []model.Device, err error) {
+	localIds = slices.DeleteFunc(slices.Clone(localIds), func(localId string) bool {
+		return !slices.Contains(hub.DeviceLocalIds, localId)
+	})
+	if len(localIds) == 0 {
+		return nil, nil
+	}
+	devices, err := this.getDevicesByLocalIds(token, localIds)
+	if err != nil {
+		return result, err
+	}
+	for _, device := range devices {
+		if slices.Contains(hub.DeviceIds, device.Id) {
+			this.deviceHubs.set(device.Id, hub, this.deviceExpiration)
+			result = append(result, device)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+const hubTestDeviceId = "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"
+
+func TestCreateWithHubOfDevice(t *testing.T) {
+	hubs := &testDeviceHubs{hub: models.Hub{Id: "urn:infai:ses:hub:1", Name: "gateway", DeviceIds: []string{hubTestDeviceId}}}
+	topic := New(nil, "{{.HubLocalId}}/{{.LocalDeviceId}}/{{.LocalServiceId}}")
+	topic.hubs = hubs
+	device := model.Device{Id: hubTestDeviceId, LocalId: "lamp"}
+	for i := 0; i < 2; i++ { //second call uses the remembered hub
+		actual, err := topic.CreateForService("token", device, model.Service{LocalId: "set"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if actual != hubTestDeviceId+"/gateway/lamp/set" {
+			t.Error(actual)
+		}
+	}
+	if hubs.lookups != 1 {
+		t.Error(hubs.lookups)
+	}
+
+	//the hub is only looked up for templates with hub placeholders
+	topic.defaultActuatorPattern = "{{.LocalDeviceId}}/{{.LocalServiceId}}"
+	for _, localServiceId := range []string{"set", "{{.LocalDeviceId}}/{{.HubId | len}}", "{{.LocalDeviceId}}/set"} {
+		_, _ = topic.CreateForService("token", model.Device{Id: "urn:infai:ses:device:8bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", LocalId: "other"}, model.Service{LocalId: localServiceId})
+	}
+	if hubs.lookups != 2 {
+		t.Error(hubs.lookups)
+	}
+	topic.defaultActuatorPattern = "{{.HubLocalId}}/{{.LocalDeviceId}}/{{.LocalServiceId}}"
+
+	//devices without hub have no hub placeholders
+	_, err := topic.CreateForService("token", model.Device{Id: "urn:infai:ses:device:7bd07b75-d7cc-4a1a-88db-ac93f61aa7b3", LocalId: "other"}, model.Service{LocalId: "set"})
+	if err == nil {
+		t.Error("missing error")
+	}
+}
+
+func TestDeviceHubsExpire(t *testing.T) {
+	hubs := deviceHubs{}
+	hubs.set("d1", models.Hub{Id: "hub"}, 10*time.Millisecond)
+	if _, ok := hubs.get("d1"); !ok {
+		t.Error("missing hub")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := hubs.get("d1"); ok {
+		t.Error("hub should be expired")
+	}
+	hubs.set("d2", models.Hub{Id: "hub"}, 10*time.Millisecond)
+	if _, ok := hubs.hubs["d1"]; ok {
+		t.Error("expired hub should be removed")
+	}
+}
+
+type testDeviceHubs struct {
+	hub     models.Hub
+	lookups int
+}
+
+func (this *testDeviceHubs) GetHub(token security.JwtToken, id string) (models.Hub, error) {
+	return models.Hub{}, devicerepo.ErrNotFound
+}
+
+func (this *testDeviceHubs) GetHubOfDevice(token security.JwtToken, deviceId string, localDeviceId string) (models.Hub, error) {
+	this.lookups++
+	if !slices.Contains(this.hub.DeviceIds, deviceId) {
+		return models.Hub{}, devicerepo.ErrNotFound
+	}
+	return this.hub, nil
+}
+
+func (this *testDeviceHubs) InvalidateDevice(deviceId string) {}
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+const defaultServiceIndexTtl = time.Minute
+
+// serviceIndex is a topic-segment trie of the service local ids of a device-type.
+// a service matches a topic if its local id segments are a sequence of complete segments of the topic, but not the whole topic (see serviceMatchesTopic)
+type serviceIndex struct {
+	created       time.Time
+	serviceCount  int
+	services      []model.Service
+	root          *serviceIndexNode
+	unindexed     []int //services with local ids the trie can not represent (empty, leading or trailing "/"); matched by serviceMatchesTopic
+	patternValues []string
+	patterns      []*EventTopicPattern //compiled EventTopicPatternAttr attributes of the device-type
+}
+
+type serviceIndexNode struct {
+	children map[string]*serviceIndexNode
+	services []int
+}
+
+func newServiceIndex(services []model.Service) *serviceIndex {
+	result := &serviceIndex{
+		created:      time.Now(),
+		serviceCount: len(services),
+		services:     services,
+		root:         &serviceIndexNode{},
+	}
+	for i, service := range services {
+		if service.LocalId == "" || strings.HasPrefix(service.LocalId, "/") || strings.HasSuffix(service.LocalId, "/") {
+			result.unindexed = append(result.unindexed, i)
+			continue
+		}
+		node := result.root
+		for _, segment := range strings.Split(service.LocalId, "/") {
+			if node.children == nil {
+				node.children = map[string]*serviceIndexNode{}
+			}
+			child, ok := node.children[segment]
+			if !ok {
+				child = &serviceIndexNode{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+		node.services = append(node.services, i)
+	}
+	return result
+}
+
+// match returns the matching services in device-type order
+func (this *serviceIndex) match(topic string, serviceMatchesTopic func(topic string, service model.Service) bool) (result []model.Service) {
+	segments := strings.Split(topic, "/")
+	matches := []int{}
+	for start := range segments {
+		node := this.root
+		for end := start; end < len(segments); end++ {
+			node = node.children[segments[end]]
+			if node == nil {
+				break
+			}
+			if start == 0 && end == len(segments)-1 {
+				continue //the whole topic is no match
+			}
+			matches = append(matches, node.services...)
+		}
+	}
+	for _, i := range this.unindexed {
+		if serviceMatchesTopic(topic, this.services[i]) {
+			matches = append(matches, i)
+		}
+	}
+	slices.Sort(matches)
+	for _, i := range slices.Compact(matches) {
+		result = append(result, this.services[i])
+	}
+	return result
+}
+
+// getServiceIndex returns the cached index of the device-type; indexes are rebuilt after serviceIndexTtl,
+// if the service count or the event topic pattern attributes changed or after InvalidateDeviceType.
+// associations of services removed from the device-type are removed on rebuild
+func (this *Topic) getServiceIndex(deviceType model.DeviceType) *serviceIndex {
+	this.serviceIndexMux.Lock()
+	index, ok := this.serviceIndexes[deviceType.Id]
+	patternValues := eventTopicPatternValues(deviceType)
+	if ok && index.serviceCount == len(deviceType.Services) && slices.Equal(index.patternValues, patternValues) && time.Since(index.created) <= this.serviceIndexTtl {
+		this.serviceIndexMux.Unlock()
+		return index
+	}
+	removed := []string{}
+	if ok {
+		for _, service := range index.services {
+			if !slices.ContainsFunc(deviceType.Services, func(s model.Service) bool { return s.Id == service.Id }) {
+				removed = append(removed, service.Id)
+			}
+		}
+	}
+	index = newServiceIndex(deviceType.Services)
+	index.patternValues = patternValues
+	index.patterns = this.compileDeviceTypeEventTopicPatterns(deviceType.Id, patternValues)
+	this.serviceIndexes[deviceType.Id] = index
+	this.serviceIndexMux.Unlock()
+	for _, serviceId := range removed {
+		err := this.associations.RemoveService(serviceId)
+		if err != nil {
+			slog.Error("unable to remove service topic association", "service_id", serviceId, "error", err)
+		}
+	}
+	return index
+}
+
+// InvalidateDeviceType marks cached data of the device-type as outdated; should be called after device-type updates.
+// until the iot cache entry expires, the device-type is read from the device-repository
+func (this *Topic) InvalidateDeviceType(deviceTypeId string) {
+	this.invalidated.add(deviceTypeId, this.serviceIndexTtl)
+	this.serviceIndexMux.Lock()
+	defer this.serviceIndexMux.Unlock()
+	if index, ok := this.serviceIndexes[deviceTypeId]; ok {
+		index.created = time.Time{}
+	}
+}
+
+// RemoveDeviceType removes cached data of the device-type and the associations of its services; should be called after device-type deletes
+func (this *Topic) RemoveDeviceType(deviceTypeId string) {
+	this.invalidated.add(deviceTypeId, this.serviceIndexTtl)
+	this.serviceIndexMux.Lock()
+	index, ok := this.serviceIndexes[deviceTypeId]
+	delete(this.serviceIndexes, deviceTypeId)
+	this.serviceIndexMux.Unlock()
+	if !ok {
+		return
+	}
+	for _, service := range index.services {
+		err := this.associations.RemoveService(service.Id)
+		if err != nil {
+			slog.Error("unable to remove service topic association", "service_id", service.Id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+func TestServiceIndexMatchesHeuristic(t *testing.T) {
+	services := []model.Service{
+		{Id: "1", LocalId: "poweron"},
+		{Id: "2", LocalId: "void/poweron"},
+		{Id: "3", LocalId: "tele/SENSOR"},
+		{Id: "4", LocalId: "SENSOR"},
+		{Id: "5", LocalId: "a/b/c"},
+		{Id: "6", LocalId: "/leading"},
+		{Id: "7", LocalId: "trailing/"},
+		{Id: "8", LocalId: ""},
+		{Id: "9", LocalId: "b"},
+	}
+	topics := []string{
+		"poweron",
+		"device/poweron",
+		"poweron/device",
+		"cmd/device/void/poweron",
+		"void/poweron/device",
+		"tele/SENSOR",
+		"x/tele/SENSOR",
+		"tele/SENSOR/x",
+		"x/a/b/c/y",
+		"a/b/c",
+		"a/b",
+		"x/leading",
+		"/leading/x",
+		"trailing/x",
+		"x/trailing/",
+		"/poweron",
+		"poweron/",
+		"",
+		"/",
+		"x//y",
+	}
+	topic := New(nil, "")
+	index := newServiceIndex(services)
+	for _, mqttTopic := range topics {
+		var expected []model.Service
+		for _, service := range services {
+			if topic.serviceMatchesTopic(mqttTopic, service) {
+				expected = append(expected, service)
+			}
+		}
+		actual := index.match(mqttTopic, topic.serviceMatchesTopic)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error(mqttTopic, actual, expected)
+		}
+	}
+}
+
+func TestServiceIndexInvalidation(t *testing.T) {
+	topic := New(nil, "")
+	dt := model.DeviceType{Id: "dt", Services: []model.Service{{Id: "1", LocalId: "a"}}}
+	index := topic.getServiceIndex(dt)
+	if topic.getServiceIndex(dt) != index {
+		t.Error("index should be cached")
+	}
+	dt.Services = append(dt.Services, model.Service{Id: "2", LocalId: "b"})
+	if topic.getServiceIndex(dt) == index {
+		t.Error("index should be rebuilt for changed service count")
+	}
+	index = topic.getServiceIndex(dt)
+	topic.InvalidateDeviceType(dt.Id)
+	if topic.getServiceIndex(dt) == index {
+		t.Error("index should be rebuilt after invalidation")
+	}
+}
+
+func TestServiceIndexPatterns(t *testing.T) {
+	topic := New(nil, "")
+	dt := model.DeviceType{Id: "dt", Services: []model.Service{{Id: "1", LocalId: "a"}}, Attributes: []models.Attribute{
+		{Key: EventTopicPatternAttr, Value: "{{.LocalDeviceId}}/{{.LocalServiceId}}"},
+		{Key: EventTopicPatternAttr, Value: "{{.Unknown}}"},
+	}}
+	index := topic.getServiceIndex(dt)
+	if len(index.patterns) != 1 {
+		t.Error("invalid patterns should be skipped", index.patterns)
+	}
+	dt.Attributes = dt.Attributes[:1]
+	if topic.getServiceIndex(dt) == index {
+		t.Error("index should be rebuilt for changed event topic patterns")
+	}
+	index = topic.getServiceIndex(dt)
+	topic.serviceIndexTtl = 0
+	time.Sleep(time.Millisecond)
+	if topic.getServiceIndex(dt) == index {
+		t.Error("index and patterns should expire")
+	}
+}
+
+func TestInvalidationReadsFromRepository(t *testing.T) {
+	topic := New(nil, "")
+	repository := &testRepository{deviceType: model.DeviceType{Id: "dt", Services: []model.Service{{Id: "1", LocalId: "renamed"}}}, device: model.Device{Id: "device", LocalId: "renamed"}}
+	topic.repository = repository
+	topic.InvalidateDeviceType("dt")
+	topic.InvalidateDevice("device")
+	//the iot cache (nil) is not used until its entries read before the invalidation are expired
+	deviceType, err := topic.getDeviceType("token", "dt")
+	if err != nil || deviceType.Services[0].LocalId != "renamed" {
+		t.Error(deviceType, err)
+	}
+	device, err := topic.getDevice("token", "device")
+	if err != nil || device.LocalId != "renamed" {
+		t.Error(device, err)
+	}
+	if !topic.invalidated.contains("dt") || topic.invalidated.contains("other") {
+		t.Error("unexpected invalidated ids")
+	}
+	topic.invalidated.add("expired", -time.Second)
+	if topic.invalidated.contains("expired") {
+		t.Error("expired invalidation should be ignored")
+	}
+}
+
+type testRepository struct {
+	device     model.Device
+	deviceType model.DeviceType
+}
+
+func (this *testRepository) GetDevice(token security.JwtToken, id string) (model.Device, error) {
+	if id != this.device.Id {
+		return model.Device{}, security.ErrorNotFound
+	}
+	return this.device, nil
+}
+
+func (this *testRepository) GetDeviceType(token security.JwtToken, id string) (model.DeviceType, error) {
+	if id != this.deviceType.Id {
+		return model.DeviceType{}, security.ErrorNotFound
+	}
+	return this.deviceType, nil
+}
+
+func TestServiceIndexRemovesAssociations(t *testing.T) {
+	topic := New(nil, "")
+	dt := model.DeviceType{Id: "dt", Services: []model.Service{{Id: "1", LocalId: "a"}, {Id: "2", LocalId: "b"}}}
+	topic.getServiceIndex(dt)
+	_ = topic.associations.Set("device", "1", "foo/a")
+	_ = topic.associations.Set("device", "2", "foo/b")
+	dt.Services = dt.Services[1:]
+	topic.InvalidateDeviceType(dt.Id)
+	topic.getServiceIndex(dt)
+	if _, ok := topic.getServiceTopicAssociation("device", "1"); ok {
+		t.Error("association of removed service should be removed")
+	}
+	if _, ok := topic.getServiceTopicAssociation("device", "2"); !ok {
+		t.Error("association of remaining service should be kept")
+	}
+}
+
+func TestRemoveDeviceTypeAndDevice(t *testing.T) {
+	topic := New(nil, "")
+	dt := model.DeviceType{Id: "dt", Services: []model.Service{{Id: "1", LocalId: "a"}}}
+	topic.getServiceIndex(dt)
+	_ = topic.associations.Set("device", "1", "foo/a")
+	_ = topic.associations.Set("device2", "2", "foo/b")
+	topic.deviceHubs.set("device2", models.Hub{Id: "hub"}, time.Minute)
+
+	topic.RemoveDeviceType(dt.Id)
+	if _, ok := topic.serviceIndexes[dt.Id]; ok {
+		t.Error("index of removed device-type should be removed")
+	}
+	if _, ok := topic.getServiceTopicAssociation("device", "1"); ok {
+		t.Error("association of removed device-type should be removed")
+	}
+
+	topic.RemoveDevice("device2")
+	if _, ok := topic.getServiceTopicAssociation("device2", "2"); ok {
+		t.Error("association of removed device should be removed")
+	}
+	if _, ok := topic.deviceHubs.get("device2"); ok {
+		t.Error("hub of removed device should be removed")
+	}
+}
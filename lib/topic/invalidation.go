@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// Repository is implemented by *devicerepo.Client; reads devices and device-types without cache
+type Repository interface {
+	GetDevice(token security.JwtToken, id string) (model.Device, error)
+	GetDeviceType(token security.JwtToken, id string) (model.DeviceType, error)
+}
+
+// defaultDeviceExpiration is used if the expiration of devices in the iot cache is unknown
+const defaultDeviceExpiration = time.Minute
+
+// invalidatedIds remembers invalidated devices and device-types until the iot cache entries read before the invalidation are expired
+type invalidatedIds struct {
+	mux   sync.Mutex
+	until map[string]time.Time
+}
+
+func (this *invalidatedIds) add(id string, expiration time.Duration) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	if this.until == nil {
+		this.until = map[string]time.Time{}
+	}
+	for key, until := range this.until {
+		if now.After(until) {
+			delete(this.until, key)
+		}
+	}
+	this.until[id] = now.Add(expiration)
+}
+
+func (this *invalidatedIds) contains(id string) bool {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	until, ok := this.until[id]
+	return ok && time.Now().Before(until)
+}
+
+// getDevice reads invalidated devices from the repository, because the iot cache may still return the old version
+func (this *Topic) getDevice(token security.JwtToken, id string) (model.Device, error) {
+	if this.repository != nil && this.invalidated.contains(id) {
+		return this.repository.GetDevice(token, id)
+	}
+	return this.iotCache.WithToken(token).GetDevice(id)
+}
+
+// getDeviceType reads invalidated device-types from the repository, because the iot cache may still return the old version
+func (this *Topic) getDeviceType(token security.JwtToken, id string) (model.DeviceType, error) {
+	if this.repository != nil && this.invalidated.contains(id) {
+		return this.repository.GetDeviceType(token, id)
+	}
+	return this.iotCache.WithToken(token).GetDeviceType(id)
+}
+
+// getDevicesByLocalIds reads invalidated devices of the lookup again and drops those whose local id is no longer one of localIds
+func (this *Topic) getDevicesByLocalIds(token security.JwtToken, localIds []string) (result []model.Device, err error) {
+	devices, err := this.iotCache.GetDevicesByLocalIdList(token, localIds)
+	if err != nil || this.repository == nil {
+		return devices, err
+	}
+	for _, device := range devices {
+		if this.invalidated.contains(device.Id) {
+			device, err = this.repository.GetDevice(token, device.Id)
+			if errors.Is(err, security.ErrorNotFound) || errors.Is(err, security.ErrorAccessDenied) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+			if !slices.Contains(localIds, device.LocalId) {
+				continue
+			}
+		}
+		result = append(result, device)
+	}
+	return result, nil
+}
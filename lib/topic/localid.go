@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// ParseLocalDevice returns the device with the local id and the services of its device-type by local id.
+// used for topic conventions with a fixed local id scheme (e.g. sparkplug.Topic.LocalDeviceId)
+func (this *Topic) ParseLocalDevice(token security.JwtToken, localDeviceId string) (device model.Device, services map[string]model.Service, err error) {
+	devices, err := this.getDevicesByLocalIds(token, []string{localDeviceId})
+	if err != nil {
+		return device, services, err
+	}
+	if len(devices) == 0 {
+		return device, services, ErrNoDeviceMatchFound
+	}
+	if len(devices) > 1 {
+		return device, services, ErrMultipleMatchingDevicesFound
+	}
+	device = devices[0]
+	deviceType, err := this.getDeviceType(token, device.DeviceTypeId)
+	if err != nil {
+		return device, services, err
+	}
+	services = map[string]model.Service{}
+	for _, service := range deviceType.Services {
+		services[service.LocalId] = service
+	}
+	return device, services, nil
+}
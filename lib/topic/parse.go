@@ -18,7 +18,6 @@ package topic
 
 import (
 	"errors"
-	"fmt"
 	"log/slog"
 	"regexp"
 	"slices"
@@ -36,53 +35,111 @@ var ErrNoDeviceIdCandidateFound = errors.New("no device id candidate found")
 var ErrNoServiceMatchFound = errors.New("no service match found")
 var ErrMultipleMatchingDevicesFound = errors.New("multiple matching devices found")
 
+// Match is a candidate device with its preferred service; the service is empty if no service matches
+type Match struct {
+	Device  model.Device
+	Service model.Service
+}
+
+// MultipleMatchesError is returned by Parse if multiple devices match the topic; errors.Is(err, ErrMultipleMatchingDevicesFound) is true.
+// Matches are ordered by preference (see ParseForCandidates)
+type MultipleMatchesError struct {
+	Matches []Match
+}
+
+func (this *MultipleMatchesError) Error() string {
+	return ErrMultipleMatchingDevicesFound.Error()
+}
+
+func (this *MultipleMatchesError) Is(target error) bool {
+	return target == ErrMultipleMatchingDevicesFound
+}
+
 // if no service is fount, ErrNoServiceMatchFound will be returned as error. but the device will be set if one was found
+// topics of devices with a convention (ConventionAttr) and topics matching an event topic pattern (global or EventTopicPatternAttr)
+// are matched exactly before the heuristics are used
 func (this *Topic) Parse(token security.JwtToken, topic string) (device model.Device, service model.Service, err error) {
-	candidates, err := this.ParseForCandidates(token, topic)
+	device, service, heuristic, err := this.parse(token, topic, nil)
+	if err == nil && heuristic {
+		this.storeServiceTopicAssociation(token, device, service.Id, topic)
+	}
+	return device, service, err
+}
+
+// parse is Parse without storing service topic associations; heuristic is true if the result was found by ParseForCandidates.
+// the steps are recorded in trace, if it is not nil (see Explain)
+func (this *Topic) parse(token security.JwtToken, topic string, trace *Explanation) (device model.Device, service model.Service, heuristic bool, err error) {
+	if device, service, ok, err := this.parseByConvention(token, topic); ok {
+		trace.setConvention(device, service, err)
+		return device, service, false, err
+	}
+	if device, service, ok, err := this.parseByPattern(token, topic); ok {
+		trace.setPattern(device, service, err)
+		return device, service, false, err
+	}
+	candidates, err := this.parseForCandidates(token, topic, trace)
 	if err != nil {
-		return device, service, err
+		trace.setCandidatesError(err)
+		return device, service, false, err
 	}
 	if len(candidates) == 0 {
-		return device, service, ErrNoDeviceMatchFound
+		return device, service, false, ErrNoDeviceMatchFound
 	}
 	if len(candidates) > 1 {
-		return device, service, ErrMultipleMatchingDevicesFound
+		multiple := &MultipleMatchesError{}
+		for _, c := range candidates {
+			match := Match{Device: c.device}
+			if len(c.services) > 0 {
+				match.Service = c.services[0]
+			}
+			multiple.Matches = append(multiple.Matches, match)
+		}
+		return device, service, false, multiple
 	}
 	device = candidates[0].device
 	if len(candidates[0].services) == 0 {
-		return device, service, ErrNoServiceMatchFound
+		return device, service, false, ErrNoServiceMatchFound
 	}
-	service = candidates[0].services[0]
-	this.storeServiceTopicAssociation(token, device, service.Id, topic)
-	return device, service, nil
+	return device, candidates[0].services[0], true, nil
 }
 
 // not exported, no one should care
 type candidate struct {
 	device   model.Device
 	services []model.Service
+	exact    bool //services matched by an event topic pattern of the device-type
 }
 
 func (this *Topic) ParseForCandidates(token security.JwtToken, topic string) (candidates []candidate, err error) {
-	devices, err := this.findDeviceCandidates(token, topic)
+	return this.parseForCandidates(token, topic, nil)
+}
+
+func (this *Topic) parseForCandidates(token security.JwtToken, topic string, trace *Explanation) (candidates []candidate, err error) {
+	defer func() { trace.rankCandidates(candidates) }()
+	devices, err := this.findDeviceCandidates(token, topic, trace)
 	if err != nil {
 		return candidates, err
 	}
 	for _, device := range devices {
-		services, err := this.findMatchingServices(token, device.DeviceTypeId, topic)
+		services, exact, err := this.findMatchingServices(token, device, topic)
 		if err != nil {
+			trace.addCandidate(device, nil, nil, false, err)
 			return candidates, err
 		}
+		if exact {
+			trace.addCandidate(device, services, nil, true, nil)
+			candidates = append(candidates, candidate{
+				device:   device,
+				services: services,
+				exact:    true,
+			})
+			continue
+		}
 
+		filtered := []filteredService{}
 		services = slices.DeleteFunc(services, func(service model.Service) bool {
-			associatedTopic, ok := this.getServiceTopicAssociation(device.Id, service.Id)
-			if !ok {
-				return false
-			}
-			if associatedTopic == topic {
-				return false
-			}
-			if strings.Contains(topic, associatedTopic) {
+			if associatedTopic, ok := this.filteringAssociation(device.Id, service.Id, topic); ok {
+				filtered = append(filtered, filteredService{service: service, associatedTopic: associatedTopic})
 				return true
 			}
 			return false
@@ -92,12 +149,18 @@ func (this *Topic) ParseForCandidates(token security.JwtToken, topic string) (ca
 		sort.Slice(services, func(i, j int) bool {
 			return len(services[i].LocalId) > len(services[j].LocalId)
 		})
+		trace.addCandidate(device, services, filtered, false, nil)
 		candidates = append(candidates, candidate{
 			device:   device,
 			services: services,
 		})
 	}
 
+	//devices with exact matches are preferred over heuristic matches
+	if slices.ContainsFunc(candidates, func(c candidate) bool { return c.exact }) {
+		candidates = slices.DeleteFunc(candidates, func(c candidate) bool { return !c.exact })
+	}
+
 	//first sort by service count and then by service.LocalId to prefer devices with the longest service match and the most matching services if 2 have the same length of localID
 	//this allows devices as result without matching services but prefers devices with matches
 
@@ -123,17 +186,53 @@ func (this *Topic) ParseForCandidates(token security.JwtToken, topic string) (ca
 	return
 }
 
-func (this *Topic) findMatchingServices(token security.JwtToken, deviceTypeId string, topic string) (services []model.Service, err error) {
-	deviceType, err := this.iotCache.WithToken(token).GetDeviceType(deviceTypeId)
+// filteredService is a service dropped by parseForCandidates because of its associated topic
+type filteredService struct {
+	service         model.Service
+	associatedTopic string
+}
+
+// filteringAssociation returns the associated topic of the service, if it is contained in, but not equal to the topic
+func (this *Topic) filteringAssociation(deviceId string, serviceId string, topic string) (associatedTopic string, filtered bool) {
+	associatedTopic, ok := this.getServiceTopicAssociation(deviceId, serviceId)
+	if !ok {
+		return "", false
+	}
+	if associatedTopic == topic {
+		return "", false
+	}
+	if strings.Contains(topic, associatedTopic) {
+		return associatedTopic, true
+	}
+	return "", false
+}
+
+// findMatchingServices returns exact=true if an event topic pattern of the device-type matches the topic and a service
+func (this *Topic) findMatchingServices(token security.JwtToken, device model.Device, topic string) (services []model.Service, exact bool, err error) {
+	deviceType, err := this.getDeviceType(token, device.DeviceTypeId)
 	if err != nil {
-		return services, err
+		return services, false, err
 	}
-	for _, service := range deviceType.Services {
-		if this.serviceMatchesTopic(topic, service) {
-			services = append(services, service)
+	index := this.getServiceIndex(deviceType)
+	for _, pattern := range index.patterns {
+		values, ok := pattern.Match(topic)
+		if !ok || !pattern.references(values, device) {
+			continue
+		}
+		inHub, err := this.referencesHub(token, values, device)
+		if err != nil {
+			return services, false, err
+		}
+		if !inHub {
+			continue
+		}
+		for _, service := range deviceType.Services {
+			if service.LocalId == values["LocalServiceId"] {
+				return []model.Service{service}, true, nil
+			}
 		}
 	}
-	return services, nil
+	return index.match(topic, this.serviceMatchesTopic), false, nil
 }
 
 func (this *Topic) serviceMatchesTopic(topic string, service model.Service) bool {
@@ -161,34 +260,51 @@ func (this *Topic) serviceMatchesTopic(topic string, service model.Service) bool
 	return false
 }
 
-func (this *Topic) findDeviceCandidates(token security.JwtToken, topic string) (candidates []model.Device, err error) {
-	candidateIds, err := this.findDeviceIdCandidates(topic)
+func (this *Topic) findDeviceCandidates(token security.JwtToken, topic string, trace *Explanation) (candidates []model.Device, err error) {
+	candidateIds, err := this.findDeviceIdCandidates(topic, trace)
 	if err != nil {
 		return candidates, err
 	}
 	if len(candidateIds) == 0 {
-		return this.findDeviceCandidatesByLocalIdPrefix(token, topic)
+		return this.findDeviceCandidatesByHubOrLocalIdPrefix(token, topic, trace)
 	}
 	for _, id := range candidateIds {
-		device, err := this.iotCache.WithToken(token).GetDevice(id)
+		device, err := this.getDevice(token, id)
+		trace.setIdCandidateResult(id, err)
 		if err == nil {
 			candidates = append(candidates, device)
 		} else {
 			if !errors.Is(err, security.ErrorNotFound) && !errors.Is(err, security.ErrorAccessDenied) {
 				return candidates, err
 			}
+			if errors.Is(err, security.ErrorNotFound) {
+				this.removeDeviceTopicAssociations(id)
+			}
 		}
 	}
 	if len(candidates) == 0 {
-		return this.findDeviceCandidatesByLocalIdPrefix(token, topic)
+		return this.findDeviceCandidatesByHubOrLocalIdPrefix(token, topic, trace)
 	}
 	return candidates, nil
 }
 
-func (this *Topic) findDeviceIdCandidates(topic string) (candidates []string, err error) {
+// findDeviceCandidatesByHubOrLocalIdPrefix uses hub-scoped topics (see findDeviceCandidatesByHub) before the local id lookup of all topic levels
+func (this *Topic) findDeviceCandidatesByHubOrLocalIdPrefix(token security.JwtToken, topic string, trace *Explanation) (candidates []model.Device, err error) {
+	candidates, err = this.findDeviceCandidatesByHub(token, topic, trace)
+	if err != nil || len(candidates) > 0 {
+		return candidates, err
+	}
+	return this.findDeviceCandidatesByLocalIdPrefix(token, topic, trace)
+}
+
+func (this *Topic) findDeviceIdCandidates(topic string, trace *Explanation) (candidates []string, err error) {
 	candidates = findDeviceIdCandidates(topic)
+	for _, candidate := range candidates {
+		trace.addIdCandidate(candidate, "", nil)
+	}
 	for _, shortCandidate := range findShortDeviceIdCandidates(topic) {
 		candidate, err := shortid.EnsureLongDeviceId(shortCandidate)
+		trace.addIdCandidate(candidate, shortCandidate, err)
 		if err == nil {
 			candidates = append(candidates, candidate)
 		}
@@ -196,9 +312,12 @@ func (this *Topic) findDeviceIdCandidates(topic string) (candidates []string, er
 	return candidates, nil
 }
 
+var deviceIdRegex = regexp.MustCompile(`^urn:infai:ses:device:[\w-]*$`)
+var shortDeviceIdRegex = regexp.MustCompile(`^[\w\-_]{22}$`)
+
 func findDeviceIdCandidates(topic string) (candidates []string) {
 	for _, part := range strings.Split(topic, "/") {
-		if regexp.MustCompile(`^urn:infai:ses:device:[\w-]*$`).MatchString(part) {
+		if deviceIdRegex.MatchString(part) {
 			candidates = append(candidates, part)
 		}
 	}
@@ -207,15 +326,17 @@ func findDeviceIdCandidates(topic string) (candidates []string) {
 
 func findShortDeviceIdCandidates(topic string) (candidates []string) {
 	for _, part := range strings.Split(topic, "/") {
-		if regexp.MustCompile(`^[\w\-_]{22}$`).MatchString(part) {
+		if shortDeviceIdRegex.MatchString(part) {
 			candidates = append(candidates, part)
 		}
 	}
 	return candidates
 }
 
-func (this *Topic) findDeviceCandidatesByLocalIdPrefix(token security.JwtToken, topic string) (result []model.Device, err error) {
-	result, err = this.iotCache.GetDevicesByLocalIdList(token, strings.Split(topic, "/"))
+func (this *Topic) findDeviceCandidatesByLocalIdPrefix(token security.JwtToken, topic string, trace *Explanation) (result []model.Device, err error) {
+	localIds := strings.Split(topic, "/")
+	result, err = this.getDevicesByLocalIds(token, localIds)
+	trace.setLocalIdLookup(localIds, result, err)
 	if err != nil {
 		return result, err
 	}
@@ -228,7 +349,7 @@ func (this *Topic) findDeviceCandidatesByLocalIdPrefix(token security.JwtToken,
 const GenerateServiceAttr = "senergy/mqtt-generate-services"
 
 func (this *Topic) storeServiceTopicAssociation(token security.JwtToken, device model.Device, serviceId string, topic string) {
-	dt, err := this.iotCache.GetDeviceType(token, device.DeviceTypeId)
+	dt, err := this.getDeviceType(token, device.DeviceTypeId)
 	if err != nil {
 		slog.Error("unable to get device-type", "device_type_id", device.DeviceTypeId, "error", err)
 		err = nil
@@ -240,11 +361,21 @@ func (this *Topic) storeServiceTopicAssociation(token security.JwtToken, device
 	if slices.ContainsFunc(dt.Attributes, func(a models.Attribute) bool {
 		return a.Key == GenerateServiceAttr && strings.ToLower(strings.TrimSpace(a.Value)) == "true"
 	}) {
-		this.associatedTopics[fmt.Sprintf("%s/%s", device.Id, serviceId)] = topic
+		err = this.associations.Set(device.Id, serviceId, topic)
+		if err != nil {
+			slog.Error("unable to store service topic association", "device_id", device.Id, "service_id", serviceId, "error", err)
+		}
 	}
 }
 
 func (this *Topic) getServiceTopicAssociation(deviceId string, serviceId string) (topic string, found bool) {
-	topic, found = this.associatedTopics[fmt.Sprintf("%s/%s", deviceId, serviceId)]
-	return topic, found
+	return this.associations.Get(deviceId, serviceId)
+}
+
+// removeDeviceTopicAssociations is called for deleted devices
+func (this *Topic) removeDeviceTopicAssociations(deviceId string) {
+	err := this.associations.RemoveDevice(deviceId)
+	if err != nil {
+		slog.Error("unable to remove device topic associations", "device_id", deviceId, "error", err)
+	}
 }
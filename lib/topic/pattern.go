@@ -0,0 +1,315 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+// EventTopicPatternAttr adds an event topic pattern for the devices of a device-type; the attribute may be repeated
+const EventTopicPatternAttr = "senergy/mqtt-event-topic-pattern"
+
+var ErrInvalidEventTopicPattern = errors.New("invalid event topic pattern")
+
+// placeholders usable in event topic patterns and the regex of their values
+var eventTopicPlaceholders = map[string]string{
+	"DeviceId":       `urn:infai:ses:device:[^/]+`,
+	"ShortDeviceId":  `[\w-]{22}`,
+	"LocalDeviceId":  `[^/]+`,
+	"LocalServiceId": `.+?`,
+	"HubId":          `urn:infai:ses:hub:[^/]+`,
+	"ShortHubId":     `[\w-]{22}`,
+}
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*\.(\w+)\s*\}\}`)
+
+// EventTopicPattern matches topics like "devices/{{.LocalDeviceId}}/{{.LocalServiceId}}" exactly;
+// "+" matches one topic level, a trailing "#" any remaining levels
+type EventTopicPattern struct {
+	pattern string
+	regex   *regexp.Regexp
+}
+
+func CompileEventTopicPattern(pattern string) (result *EventTopicPattern, err error) {
+	expr := "^"
+	used := map[string]bool{}
+	rest := pattern
+	first := true
+	for rest != "" {
+		loc := placeholderRegex.FindStringSubmatchIndex(rest)
+		literal := rest
+		if loc != nil {
+			literal = rest[:loc[0]]
+		}
+		literalExpr, err := compileLiteral(literal, first, loc == nil)
+		if err != nil {
+			return result, fmt.Errorf("%w: %v: %v", ErrInvalidEventTopicPattern, pattern, err)
+		}
+		expr = expr + literalExpr
+		if loc == nil {
+			break
+		}
+		name := rest[loc[2]:loc[3]]
+		valueExpr, ok := eventTopicPlaceholders[name]
+		if !ok {
+			return result, fmt.Errorf("%w: %v: unknown placeholder %v", ErrInvalidEventTopicPattern, pattern, name)
+		}
+		if used[name] {
+			return result, fmt.Errorf("%w: %v: placeholder %v is used more than once", ErrInvalidEventTopicPattern, pattern, name)
+		}
+		used[name] = true
+		expr = expr + "(?P<" + name + ">" + valueExpr + ")"
+		rest = rest[loc[1]:]
+		first = false
+	}
+	if !used["LocalServiceId"] {
+		return result, fmt.Errorf("%w: %v: missing {{.LocalServiceId}}", ErrInvalidEventTopicPattern, pattern)
+	}
+	regex, err := regexp.Compile(expr + "$")
+	if err != nil {
+		return result, fmt.Errorf("%w: %v: %v", ErrInvalidEventTopicPattern, pattern, err)
+	}
+	return &EventTopicPattern{pattern: pattern, regex: regex}, nil
+}
+
+// compileLiteral translates the mqtt wildcards of a literal pattern part; first and last mark the start and end of the pattern.
+// wildcards must be complete topic levels and "#" is only allowed at the end of the pattern.
+func compileLiteral(literal string, first bool, last bool) (string, error) {
+	levels := strings.Split(literal, "/")
+	for i, level := range levels {
+		completeLevel := (i > 0 || first) && (i < len(levels)-1 || last)
+		switch {
+		case level == "+" && completeLevel:
+			levels[i] = `[^/]+`
+		case level == "#" && completeLevel && last && i == len(levels)-1:
+			levels[i] = `.*`
+		case strings.ContainsAny(level, "+#"):
+			return "", errors.New("wildcards must be complete topic levels")
+		default:
+			levels[i] = regexp.QuoteMeta(level)
+		}
+	}
+	return strings.Join(levels, "/"), nil
+}
+
+func (this *EventTopicPattern) String() string {
+	return this.pattern
+}
+
+// Match returns the placeholder values of a matching topic
+func (this *EventTopicPattern) Match(topic string) (values map[string]string, ok bool) {
+	match := this.regex.FindStringSubmatch(topic)
+	if match == nil {
+		return nil, false
+	}
+	values = map[string]string{}
+	for i, name := range this.regex.SubexpNames() {
+		if name != "" {
+			values[name] = match[i]
+		}
+	}
+	return values, true
+}
+
+// references returns true if all device placeholder values reference the device
+func (this *EventTopicPattern) references(values map[string]string, device model.Device) bool {
+	if id, ok := values["DeviceId"]; ok && id != device.Id {
+		return false
+	}
+	if short, ok := values["ShortDeviceId"]; ok {
+		long, err := shortid.EnsureLongDeviceId(short)
+		if err != nil || long != device.Id {
+			return false
+		}
+	}
+	if localId, ok := values["LocalDeviceId"]; ok && localId != device.LocalId {
+		return false
+	}
+	return true
+}
+
+func CompileEventTopicPatterns(patterns []string) (result []*EventTopicPattern, err error) {
+	for _, pattern := range patterns {
+		compiled, err := CompileEventTopicPattern(pattern)
+		if err != nil {
+			return result, err
+		}
+		if !compiled.hasDevicePlaceholder() {
+			return result, fmt.Errorf("%w: %v: global patterns need {{.DeviceId}}, {{.ShortDeviceId}} or {{.LocalDeviceId}}", ErrInvalidEventTopicPattern, pattern)
+		}
+		result = append(result, compiled)
+	}
+	return result, nil
+}
+
+func (this *EventTopicPattern) hasDevicePlaceholder() bool {
+	for _, name := range this.regex.SubexpNames() {
+		if name == "DeviceId" || name == "ShortDeviceId" || name == "LocalDeviceId" {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTopicPatternValues returns the EventTopicPatternAttr attribute values of the device-type
+func eventTopicPatternValues(deviceType model.DeviceType) (result []string) {
+	for _, attr := range deviceType.Attributes {
+		if attr.Key == EventTopicPatternAttr {
+			result = append(result, attr.Value)
+		}
+	}
+	return result
+}
+
+// compileDeviceTypeEventTopicPatterns logs and skips invalid patterns
+func (this *Topic) compileDeviceTypeEventTopicPatterns(deviceTypeId string, values []string) (result []*EventTopicPattern) {
+	for _, value := range values {
+		compiled, err := CompileEventTopicPattern(value)
+		if err != nil {
+			this.logger.Warn("invalid event topic pattern in device-type attribute", "device-type-id", deviceTypeId, "error", err)
+			continue
+		}
+		result = append(result, compiled)
+	}
+	return result
+}
+
+// parseByPattern returns ok=false if no global event topic pattern matches a topic of an existing device
+func (this *Topic) parseByPattern(token security.JwtToken, topic string) (device model.Device, service model.Service, ok bool, err error) {
+	for _, pattern := range this.eventTopicPatterns {
+		values, match := pattern.Match(topic)
+		if !match {
+			continue
+		}
+		device, err = this.getPatternDevice(token, pattern, values)
+		if errors.Is(err, ErrNoDeviceMatchFound) {
+			continue
+		}
+		if err != nil {
+			return device, service, true, err
+		}
+		deviceType, err := this.getDeviceType(token, device.DeviceTypeId)
+		if err != nil {
+			return device, service, true, err
+		}
+		for _, s := range deviceType.Services {
+			if s.LocalId == values["LocalServiceId"] {
+				return device, s, true, nil
+			}
+		}
+		return device, service, true, ErrNoServiceMatchFound
+	}
+	return device, service, false, nil
+}
+
+// getPatternHub returns ok=false if the pattern has no hub placeholder
+func (this *Topic) getPatternHub(token security.JwtToken, values map[string]string) (hub models.Hub, ok bool, err error) {
+	id, ok := values["HubId"]
+	if short, isShort := values["ShortHubId"]; isShort {
+		long, err := shortid.EnsureLongHubId(short)
+		if err != nil || (ok && long != id) {
+			return hub, true, ErrNoDeviceMatchFound
+		}
+		id, ok = long, true
+	}
+	if !ok {
+		return hub, false, nil
+	}
+	hub, err = this.getHub(token, id)
+	return hub, true, err
+}
+
+// referencesHub returns true if the pattern has no hub placeholder or the referenced hub contains the device
+func (this *Topic) referencesHub(token security.JwtToken, values map[string]string, device model.Device) (bool, error) {
+	hub, ok, err := this.getPatternHub(token, values)
+	if errors.Is(err, ErrNoDeviceMatchFound) {
+		return false, nil
+	}
+	if err != nil || !ok {
+		return err == nil, err
+	}
+	if !slices.Contains(hub.DeviceIds, device.Id) {
+		return false, nil
+	}
+	this.deviceHubs.set(device.Id, hub, this.deviceExpiration)
+	return true, nil
+}
+
+func (this *Topic) getPatternDevice(token security.JwtToken, pattern *EventTopicPattern, values map[string]string) (device model.Device, err error) {
+	hub, hasHub, err := this.getPatternHub(token, values)
+	if err != nil {
+		return device, err
+	}
+	id, ok := values["DeviceId"]
+	if !ok {
+		if short, ok := values["ShortDeviceId"]; ok {
+			id, err = shortid.EnsureLongDeviceId(short)
+			if err != nil {
+				return device, ErrNoDeviceMatchFound
+			}
+		}
+	}
+	if id != "" {
+		device, err = this.getDevice(token, id)
+		if errors.Is(err, security.ErrorNotFound) || errors.Is(err, security.ErrorAccessDenied) {
+			return device, ErrNoDeviceMatchFound
+		}
+		if err != nil {
+			return device, err
+		}
+	} else if hasHub {
+		devices, err := this.getHubDevicesByLocalIds(token, hub, []string{values["LocalDeviceId"]})
+		if err != nil {
+			return device, err
+		}
+		if len(devices) == 0 {
+			return device, ErrNoDeviceMatchFound
+		}
+		device = devices[0]
+	} else {
+		devices, err := this.getDevicesByLocalIds(token, []string{values["LocalDeviceId"]})
+		if err != nil {
+			return device, err
+		}
+		if len(devices) == 0 {
+			return device, ErrNoDeviceMatchFound
+		}
+		if len(devices) > 1 {
+			return device, ErrMultipleMatchingDevicesFound
+		}
+		device = devices[0]
+	}
+	if !pattern.references(values, device) {
+		return device, ErrNoDeviceMatchFound
+	}
+	if hasHub {
+		if !slices.Contains(hub.DeviceIds, device.Id) {
+			return device, ErrNoDeviceMatchFound
+		}
+		this.deviceHubs.set(device.Id, hub, this.deviceExpiration)
+	}
+	return device, nil
+}
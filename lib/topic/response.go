@@ -0,0 +1,152 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/pendingcommand"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/google/uuid"
+)
+
+const defaultCommandResponseTimeout = 30 * time.Second
+
+// PendingCommand is a command waiting for the response of its device
+type PendingCommand = pendingcommand.Command
+
+// SetPendingCommands replaces the in-memory store of pending commands, e.g. with a store shared by all connector instances
+func (this *Topic) SetPendingCommands(store pendingcommand.Store) *Topic {
+	this.pendingCommands = store
+	return this
+}
+
+// CommandResponsesEnabled returns true if config.CommandResponseTopicPattern is set
+func (this *Topic) CommandResponsesEnabled() bool {
+	return this.responseTopicPattern != "" && this.responseTopicPattern != "-"
+}
+
+// RegisterPendingCommand creates the response topic of a command published to commandTopic and remembers the command
+// with a generated id and the correlation id of the platform (task id) or, if missing, the generated id
+// until a response is taken by TakePendingCommand or the response timeout expires.
+// returns an empty PendingCommand if command responses are disabled
+func (this *Topic) RegisterPendingCommand(commandTopic string, commandRequest model.ProtocolMsg) (result PendingCommand, err error) {
+	if !this.CommandResponsesEnabled() {
+		return result, nil
+	}
+	device := commandRequest.Metadata.Device
+	//without token, only remembered hubs are used
+	values, err := this.templateValues("", device, commandRequest.Metadata.Service, true)
+	if err != nil {
+		return result, err
+	}
+	id := uuid.NewString()
+	values["CommandTopic"] = commandTopic
+	values["CorrelationId"] = commandRequest.TaskInfo.TaskId
+	if values["CorrelationId"] == "" {
+		values["CorrelationId"] = id
+	}
+	responseTopic, err := executeTopicTemplate(this.responseTopicPattern, values)
+	if err != nil {
+		return result, err
+	}
+	if responseTopic == commandTopic {
+		return result, fmt.Errorf("%w: response topic %v equals the command topic", ErrInvalidTopicTemplate, responseTopic)
+	}
+	result = PendingCommand{
+		Id:            id,
+		CorrelationId: values["CorrelationId"],
+		ResponseTopic: responseTopic,
+		Request:       commandRequest,
+		Expires:       time.Now().Add(this.responseTimeout),
+	}
+	err = this.pendingCommands.Add(result, pendingCommandTopics(result))
+	if err != nil {
+		return PendingCommand{}, err
+	}
+	return result, nil
+}
+
+// pendingCommandTopics returns the response topic with and without the device id prefix
+// or, for topics of conventions, the owner id prefix (see WithPrefix)
+func pendingCommandTopics(command PendingCommand) []string {
+	topics := []string{command.ResponseTopic}
+	device := command.Request.Metadata.Device
+	for _, prefix := range []string{device.Id, device.OwnerId} {
+		if prefix == "" {
+			continue
+		}
+		if withoutPrefix := strings.TrimPrefix(command.ResponseTopic, prefix+"/"); withoutPrefix != command.ResponseTopic && withoutPrefix != "" {
+			return append(topics, withoutPrefix)
+		}
+	}
+	return topics
+}
+
+// RestorePendingCommand remembers a command taken by TakePendingCommand again, e.g. if its response could not be handled;
+// expired commands are not restored
+func (this *Topic) RestorePendingCommand(command PendingCommand) {
+	if time.Now().After(command.Expires) {
+		return
+	}
+	err := this.pendingCommands.Add(command, pendingCommandTopics(command))
+	if err != nil {
+		slog.Error("unable to restore pending command", "id", command.Id, "correlation_id", command.CorrelationId, "error", err)
+	}
+}
+
+// CancelPendingCommand forgets a registered command by its PendingCommand.Id, e.g. if it could not be published
+func (this *Topic) CancelPendingCommand(id string) {
+	err := this.pendingCommands.Cancel(id)
+	if err != nil {
+		slog.Error("unable to cancel pending command", "id", id, "error", err)
+	}
+}
+
+// FindPendingCommand returns the oldest unexpired command waiting for a response on mqttTopic that is accepted by accept
+func (this *Topic) FindPendingCommand(mqttTopic string, accept func(PendingCommand) bool) (PendingCommand, bool) {
+	return this.findPendingCommand(mqttTopic, accept, false)
+}
+
+// TakePendingCommand works like FindPendingCommand but removes the returned command
+func (this *Topic) TakePendingCommand(mqttTopic string, accept func(PendingCommand) bool) (PendingCommand, bool) {
+	return this.findPendingCommand(mqttTopic, accept, true)
+}
+
+// findPendingCommand logs store errors and handles them like a missing command
+func (this *Topic) findPendingCommand(mqttTopic string, accept func(PendingCommand) bool, take bool) (PendingCommand, bool) {
+	result, found, err := this.pendingCommands.Find(mqttTopic, accept, take)
+	if err != nil {
+		slog.Error("unable to find pending command", "topic", mqttTopic, "error", err)
+		return result, false
+	}
+	return result, found
+}
+
+// CanAccessDevice returns false if the device is not found or not accessible with the token
+func (this *Topic) CanAccessDevice(token security.JwtToken, deviceId string) (bool, error) {
+	_, err := this.getDevice(token, deviceId)
+	if errors.Is(err, security.ErrorNotFound) || errors.Is(err, security.ErrorAccessDenied) {
+		return false, nil
+	}
+	return err == nil, err
+}
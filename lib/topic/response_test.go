@@ -0,0 +1,125 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+const testResponseDeviceId = "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"
+
+func TestPendingCommands(t *testing.T) {
+	topic := NewFromConfig(nil, configuration.Config{CommandResponseTopicPattern: "{{.CommandTopic}}/resp", CommandResponseTimeout: "1m"}, nil)
+	acceptAll := func(PendingCommand) bool { return true }
+	commandTopic := testResponseDeviceId + "/lamp/set"
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testResponseDeviceId}, Service: model.Service{LocalId: "set"}}}
+
+	first, err := topic.RegisterPendingCommand(commandTopic, request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if first.ResponseTopic != commandTopic+"/resp" || first.CorrelationId == "" || first.Id == "" {
+		t.Errorf("%#v", first)
+	}
+	//commands of the same task share the correlation id, but not the id
+	request.TaskInfo.TaskId = "task"
+	second, err := topic.RegisterPendingCommand(commandTopic, request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	third, err := topic.RegisterPendingCommand(commandTopic, request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if second.CorrelationId != "task" || third.CorrelationId != "task" || second.Id == third.Id {
+		t.Errorf("%#v %#v", second, third)
+	}
+
+	if _, ok := topic.FindPendingCommand(commandTopic+"/resp", func(PendingCommand) bool { return false }); ok {
+		t.Error("rejected command should not be found")
+	}
+	//responses are correlated in command order; the topic without device id prefix is known too
+	if pending, ok := topic.TakePendingCommand("lamp/set/resp", acceptAll); !ok || pending.Id != first.Id {
+		t.Errorf("%#v", pending)
+	}
+	topic.CancelPendingCommand(second.Id)
+	if pending, ok := topic.TakePendingCommand(commandTopic+"/resp", acceptAll); !ok || pending.Id != third.Id {
+		t.Error("canceled command should not be found", pending)
+	}
+	//taken commands are removed from all their topics
+	if _, ok := topic.TakePendingCommand(commandTopic+"/resp", acceptAll); ok {
+		t.Error("taken command should not be found")
+	}
+}
+
+func TestPendingCommandsOfConventions(t *testing.T) {
+	topic := NewFromConfig(nil, configuration.Config{CommandResponseTopicPattern: "{{.CommandTopic}}/resp"}, nil)
+	commandTopic := WithPrefix("owner", "homie/lamp/light/power/set")
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testResponseDeviceId, LocalId: "homie:lamp", OwnerId: "owner"}, Service: model.Service{LocalId: "light/power"}}}
+	pending, err := topic.RegisterPendingCommand(commandTopic, request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	//devices of conventions publish without the owner id prefix
+	if result, ok := topic.TakePendingCommand("homie/lamp/light/power/set/resp", func(PendingCommand) bool { return true }); !ok || result.Id != pending.Id {
+		t.Errorf("%#v", result)
+	}
+}
+
+func TestPendingCommandTimeout(t *testing.T) {
+	topic := NewFromConfig(nil, configuration.Config{CommandResponseTopicPattern: "response/{{.CorrelationId}}", CommandResponseTimeout: "10ms"}, nil)
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: testResponseDeviceId}}}
+	pending, err := topic.RegisterPendingCommand(testResponseDeviceId+"/cmd", request)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.HasPrefix(pending.ResponseTopic, "response/") {
+		t.Error(pending.ResponseTopic)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := topic.FindPendingCommand(pending.ResponseTopic, func(PendingCommand) bool { return true }); ok {
+		t.Error("expired command should not be found")
+	}
+}
+
+func TestPendingCommandsDisabled(t *testing.T) {
+	topic := NewFromConfig(nil, configuration.Config{CommandResponseTopicPattern: "-"}, nil)
+	pending, err := topic.RegisterPendingCommand(testResponseDeviceId+"/cmd", model.ProtocolMsg{})
+	if err != nil || pending.ResponseTopic != "" || topic.CommandResponsesEnabled() {
+		t.Error(err, pending)
+	}
+
+	err = ValidateConfig(configuration.Config{CommandResponseTopicPattern: "{{.CommandTopic}}/{{.Unknown}}"})
+	if !errors.Is(err, ErrInvalidTopicTemplate) {
+		t.Error(err)
+	}
+	err = ValidateConfig(configuration.Config{CommandResponseTopicPattern: "{{.CommandTopic}}/resp", CommandResponseTimeout: "soon"})
+	if err == nil {
+		t.Error("expected invalid timeout error")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+)
+
+var ErrInvalidRewriteRule = errors.New("invalid topic rewrite rule")
+
+// Rewriter applies configuration.TopicRewriteRule lists in order; every rule rewrites the result of the previous one.
+// a nil Rewriter does not change topics
+type Rewriter struct {
+	rules        []rewriteRule
+	hasUserRules bool
+}
+
+type rewriteRule struct {
+	regex       *regexp.Regexp
+	replacement string
+	userId      string
+}
+
+// CompileRewriteRules returns the valid rules and an error describing the invalid ones
+func CompileRewriteRules(rules []configuration.TopicRewriteRule) (result *Rewriter, err error) {
+	result = &Rewriter{}
+	for _, rule := range rules {
+		regex, compileErr := regexp.Compile(rule.Pattern)
+		if compileErr != nil {
+			err = errors.Join(err, fmt.Errorf("%w: %v: %v", ErrInvalidRewriteRule, rule.Pattern, compileErr))
+			continue
+		}
+		result.rules = append(result.rules, rewriteRule{regex: regex, replacement: rule.Replacement, userId: rule.UserId})
+		result.hasUserRules = result.hasUserRules || rule.UserId != ""
+	}
+	return result, err
+}
+
+// Rewrite applies the global rules and the rules of the user
+func (this *Rewriter) Rewrite(userId string, topic string) string {
+	if this == nil {
+		return topic
+	}
+	for _, rule := range this.rules {
+		if rule.userId != "" && rule.userId != userId {
+			continue
+		}
+		topic = rule.regex.ReplaceAllString(topic, rule.replacement)
+	}
+	return topic
+}
+
+// HasUserRules is true if Rewrite depends on the user id
+func (this *Rewriter) HasUserRules() bool {
+	return this != nil && this.hasUserRules
+}
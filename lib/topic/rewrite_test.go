@@ -0,0 +1,71 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestRewriter(t *testing.T) {
+	rewriter, err := CompileRewriteRules([]configuration.TopicRewriteRule{
+		{Pattern: `^acme/v1/`, Replacement: ""},
+		{Pattern: `^([^/]+)/cmd$`, Replacement: "$1/command", UserId: "user"},
+		{Pattern: `(`, Replacement: ""},
+	})
+	if !errors.Is(err, ErrInvalidRewriteRule) {
+		t.Error(err)
+	}
+	for _, test := range []struct{ userId, topic, expected string }{
+		{"user", "acme/v1/lamp/cmd", "lamp/command"},
+		{"other", "acme/v1/lamp/cmd", "lamp/cmd"},
+		{"", "lamp/state", "lamp/state"},
+	} {
+		if actual := rewriter.Rewrite(test.userId, test.topic); actual != test.expected {
+			t.Error(test, actual)
+		}
+	}
+	if !rewriter.HasUserRules() {
+		t.Error("expected user rules")
+	}
+	var none *Rewriter
+	if none.Rewrite("user", "foo") != "foo" || none.HasUserRules() {
+		t.Error("nil rewriter should not change topics")
+	}
+}
+
+func TestCreateWithCommandRewriteRules(t *testing.T) {
+	topic := NewFromConfig(nil, configuration.Config{
+		ActuatorTopicPattern: "{{.LocalDeviceId}}/{{.LocalServiceId}}",
+		CommandTopicRewriteRules: []configuration.TopicRewriteRule{
+			{Pattern: `^(.+)/set$`, Replacement: "acme/v1/$1/set", UserId: "owner"},
+		},
+	}, nil)
+	device := model.Device{Id: templateTestDeviceId, LocalId: "lamp", OwnerId: "owner"}
+	actual, err := topic.CreateForService("", device, model.Service{LocalId: "set"})
+	if err != nil || actual != templateTestDeviceId+"/acme/v1/lamp/set" {
+		t.Error(actual, err)
+	}
+	device.OwnerId = "other"
+	actual, err = topic.CreateForService("", device, model.Service{LocalId: "set"})
+	if err != nil || actual != templateTestDeviceId+"/lamp/set" {
+		t.Error(actual, err)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/shortid"
+)
+
+var ErrInvalidTopicTemplate = errors.New("invalid topic template")
+
+// maxValidatedTemplateLength limits the texts accepted by ValidateTemplate
+const maxValidatedTemplateLength = 1024
+
+// maxTopicLength is the maximal length of mqtt topics; template executions writing more fail
+const maxTopicLength = 65535
+
+var errTopicTooLong = fmt.Errorf("%w: topic longer than %v bytes", ErrInvalidTopicTemplate, maxTopicLength)
+
+// kinds of templates checked by ValidateTemplate
+const (
+	TemplateKindLocalServiceId       = "local_service_id"
+	TemplateKindActuatorTopicPattern = "actuator_topic_pattern"
+	TemplateKindEventTopicPattern    = "event_topic_pattern"
+	TemplateKindResponseTopicPattern = "command_response_topic_pattern"
+)
+
+// templateFuncs are usable in local service ids and the actuator topic pattern, e.g. {{.LocalDeviceId | lower}} or {{short .HubId}}
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"replace": func(old string, new string, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"trimPrefix": func(prefix string, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"short": shortid.ShortId,
+}
+
+// exampleTemplateValues contains every placeholder of command topics; used to check templates by ValidateTemplate
+var exampleTemplateValues = map[string]string{
+	"DeviceId":          "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3",
+	"ShortDeviceId":     "a9B7ddfMShqI26yT9hqnsw",
+	"LocalDeviceId":     "example-device",
+	"DeviceTypeId":      "urn:infai:ses:device-type:5b2e7c1a-3d4f-4e8b-9a6c-1f0e2d3c4b5a",
+	"ShortDeviceTypeId": "Wy58Gj1PTouabB8OLTxLWg",
+	"ServiceId":         "urn:infai:ses:service:7e1d2c3b-4a5f-4b6e-8d7c-9f0a1b2c3d4e",
+	"ShortServiceId":    "fh0sO0pfS26NfJ8KGyw9Tg",
+	"LocalServiceId":    "example-service",
+	"HubId":             "urn:infai:ses:hub:0d6c1f4e-8b3a-4f2e-9c1d-5a7b3e2f1c0a",
+	"ShortHubId":        "DWwfTos6Ty6cHVp7Pi8cCg",
+	"HubLocalId":        "example-hub",
+}
+
+// exampleResponseTemplateValues extends exampleTemplateValues with the placeholders of response topics
+var exampleResponseTemplateValues = func() map[string]string {
+	result := map[string]string{
+		"CommandTopic":  "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3/something/example-device/example-service",
+		"CorrelationId": "3f0c5e2a-9b1d-4c7e-8a6f-2d4b1e0c9a7f",
+	}
+	for key, value := range exampleTemplateValues {
+		result[key] = value
+	}
+	return result
+}()
+
+// hubPlaceholders are only set for templates referencing them, because the hub of the device may have to be looked up (see templateValues)
+var hubPlaceholders = []string{"HubId", "ShortHubId", "HubLocalId"}
+
+type cachedTemplate struct {
+	template *template.Template
+	err      error
+}
+
+// topicTemplates caches the parsed templates by text; parse errors are cached too.
+// lenientTopicTemplates caches the templates executed by Create, which renders missing placeholders as "<no value>"
+var topicTemplates = sync.Map{}
+var lenientTopicTemplates = sync.Map{}
+
+func parseTopicTemplate(text string) (*template.Template, error) {
+	return parseTemplate(text, "missingkey=error")
+}
+
+func parseTemplate(text string, missingKey string) (*template.Template, error) {
+	result, err := template.New("").Option(missingKey).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTopicTemplate, err)
+	}
+	return result, nil
+}
+
+func getTopicTemplate(text string) (*template.Template, error) {
+	return getCachedTemplate(&topicTemplates, text, "missingkey=error")
+}
+
+func getCachedTemplate(cache *sync.Map, text string, missingKey string) (*template.Template, error) {
+	cached, ok := cache.Load(text)
+	if !ok {
+		result := cachedTemplate{}
+		result.template, result.err = parseTemplate(text, missingKey)
+		cached, _ = cache.LoadOrStore(text, result)
+	}
+	return cached.(cachedTemplate).template, cached.(cachedTemplate).err
+}
+
+func executeTopicTemplate(text string, values map[string]string) (result string, err error) {
+	tmpl, err := getTopicTemplate(text)
+	if err != nil {
+		return result, err
+	}
+	return execute(tmpl, values)
+}
+
+func executeLenientTopicTemplate(text string, values map[string]string) (result string, err error) {
+	tmpl, err := getCachedTemplate(&lenientTopicTemplates, text, "missingkey=default")
+	if err != nil {
+		return result, err
+	}
+	return execute(tmpl, values)
+}
+
+func execute(tmpl *template.Template, values map[string]string) (result string, err error) {
+	temp := &limitedBuffer{limit: maxTopicLength}
+	err = tmpl.Execute(temp, values)
+	if err != nil {
+		return result, err
+	}
+	return temp.String(), nil
+}
+
+// hasPlaceholders returns false for templates of plain text, which createFromValues replaces by the default actuator pattern
+func hasPlaceholders(tmpl *template.Template) bool {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return false
+	}
+	return slices.ContainsFunc(tmpl.Tree.Root.Nodes, func(node parse.Node) bool {
+		return node.Type() != parse.NodeText
+	})
+}
+
+// templateReferences returns true if the template uses one of the placeholders or the values as a whole (e.g. {{index . "HubId"}})
+func templateReferences(tmpl *template.Template, placeholders []string) bool {
+	if tmpl.Tree == nil {
+		return false
+	}
+	return nodeReferences(tmpl.Tree.Root, placeholders)
+}
+
+func nodeReferences(node parse.Node, placeholders []string) bool {
+	references := func(nodes ...parse.Node) bool {
+		return slices.ContainsFunc(nodes, func(node parse.Node) bool { return nodeReferences(node, placeholders) })
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		return n != nil && references(n.Nodes...)
+	case *parse.ActionNode:
+		return references(n.Pipe)
+	case *parse.PipeNode:
+		return n != nil && slices.ContainsFunc(n.Cmds, func(cmd *parse.CommandNode) bool { return references(cmd) })
+	case *parse.CommandNode:
+		return references(n.Args...)
+	case *parse.IfNode:
+		return references(n.Pipe, n.List, n.ElseList)
+	case *parse.RangeNode:
+		return references(n.Pipe, n.List, n.ElseList)
+	case *parse.WithNode:
+		return references(n.Pipe, n.List, n.ElseList)
+	case *parse.TemplateNode:
+		return references(n.Pipe)
+	case *parse.ChainNode:
+		return references(n.Node)
+	case *parse.FieldNode:
+		return slices.Contains(placeholders, n.Ident[0])
+	case *parse.VariableNode:
+		return n.Ident[0] == "$" && (len(n.Ident) == 1 || slices.Contains(placeholders, n.Ident[1]))
+	case *parse.DotNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// limitedBuffer fails writes exceeding limit, which stops the template execution
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (this *limitedBuffer) Write(p []byte) (n int, err error) {
+	if this.Len()+len(p) > this.limit {
+		return 0, errTopicTooLong
+	}
+	return this.Buffer.Write(p)
+}
+
+// validateTopicTemplate parses and executes text without the template cache
+func validateTopicTemplate(text string, values map[string]string) (example string, err error) {
+	tmpl, err := parseTopicTemplate(text)
+	if err != nil {
+		return example, err
+	}
+	example, err = execute(tmpl, values)
+	if err != nil && !errors.Is(err, ErrInvalidTopicTemplate) {
+		err = fmt.Errorf("%w: %v", ErrInvalidTopicTemplate, err)
+	}
+	return example, err
+}
+
+// ValidateTemplate checks a local service id, actuator topic pattern, event topic pattern or response topic pattern (see TemplateKind constants).
+// for command and response topic templates, the topic created with example values is returned.
+// checked templates are not added to the template cache; texts longer than 1024 bytes are rejected
+func ValidateTemplate(kind string, text string) (example string, err error) {
+	if len(text) > maxValidatedTemplateLength {
+		return "", fmt.Errorf("%w: longer than %v bytes", ErrInvalidTopicTemplate, maxValidatedTemplateLength)
+	}
+	switch kind {
+	case TemplateKindEventTopicPattern:
+		_, err = CompileEventTopicPattern(text)
+		return "", err
+	case TemplateKindLocalServiceId, TemplateKindActuatorTopicPattern:
+		return validateTopicTemplate(text, exampleTemplateValues)
+	case TemplateKindResponseTopicPattern:
+		return validateTopicTemplate(text, exampleResponseTemplateValues)
+	default:
+		return "", fmt.Errorf("%w: unknown kind %v", ErrInvalidTopicTemplate, kind)
+	}
+}
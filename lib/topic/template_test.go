@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const templateTestDeviceId = "urn:infai:ses:device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3"
+
+func TestCreateWithTemplateFunctions(t *testing.T) {
+	topic := New(nil, "{{.DeviceId}}/cmnd/{{.LocalServiceId | upper}}")
+	for localServiceId, expected := range map[string]string{
+		"power":                          templateTestDeviceId + "/cmnd/POWER",
+		"{{.ShortDeviceId | lower}}/set": templateTestDeviceId + "/a9b7ddfmshqi26yt9hqnsw/set",
+		"{{replace \"urn:infai:ses:\" \"\" .DeviceId}}":          templateTestDeviceId + "/device:6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3",
+		"{{trimPrefix \"urn:infai:ses:device:\" .DeviceId}}/set": templateTestDeviceId + "/6bd07b75-d7cc-4a1a-88db-ac93f61aa7b3/set",
+		"{{short .DeviceId}}/set":                                templateTestDeviceId + "/a9B7ddfMShqI26yT9hqnsw/set",
+	} {
+		actual, err := topic.Create(templateTestDeviceId, localServiceId)
+		if err != nil {
+			t.Error(localServiceId, err)
+			continue
+		}
+		if actual != expected {
+			t.Error(localServiceId, actual, expected)
+		}
+	}
+}
+
+func TestCreateWithDefaultPattern(t *testing.T) {
+	//Create knows no local device id, the missing placeholder renders as before strict templates
+	actual, err := New(nil, "something/{{.LocalDeviceId}}/{{.LocalServiceId}}").Create(templateTestDeviceId, "set")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if expected := templateTestDeviceId + "/something/<no value>/set"; actual != expected {
+		t.Error(actual, expected)
+	}
+}
+
+func TestCreateWithInvalidTemplate(t *testing.T) {
+	topic := New(nil, "{{.DeviceId}}/cmnd/{{.LocalServiceId}}")
+	for _, localServiceId := range []string{"{{.DeviceId", "{{foo .DeviceId}}/set", "{{short .LocalServiceId}}"} {
+		for i := 0; i < 2; i++ { //second call uses the cached template
+			_, err := topic.Create(templateTestDeviceId, localServiceId)
+			if err == nil {
+				t.Error("missing error", localServiceId)
+			}
+		}
+	}
+	_, err := New(nil, "{{.DeviceId").Create(templateTestDeviceId, "set")
+	if !errors.Is(err, ErrInvalidTopicTemplate) {
+		t.Error(err)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	valid := map[string]string{
+		TemplateKindLocalServiceId:       "{{.ShortHubId}}/{{.LocalDeviceId | upper}}/set",
+		TemplateKindActuatorTopicPattern: "cmnd/{{.LocalServiceId}}",
+		TemplateKindEventTopicPattern:    "devices/{{.LocalDeviceId}}/{{.LocalServiceId}}",
+	}
+	for kind, text := range valid {
+		_, err := ValidateTemplate(kind, text)
+		if err != nil {
+			t.Error(kind, text, err)
+		}
+	}
+	example, err := ValidateTemplate(TemplateKindLocalServiceId, "{{.LocalDeviceId}}/set")
+	if err != nil || example != "example-device/set" {
+		t.Error(example, err)
+	}
+
+	invalid := map[string]string{
+		TemplateKindLocalServiceId:       "{{.Unknown}}/set",
+		TemplateKindActuatorTopicPattern: "cmnd/{{.LocalServiceId",
+		TemplateKindEventTopicPattern:    "devices/{{.LocalDeviceId}}",
+		"unknown":                        "set",
+		TemplateKindResponseTopicPattern: "{{range 2000000000}}x{{end}}",
+	}
+	for kind, text := range invalid {
+		_, err := ValidateTemplate(kind, text)
+		if !errors.Is(err, ErrInvalidTopicTemplate) && !errors.Is(err, ErrInvalidEventTopicPattern) {
+			t.Error(kind, text, err)
+		}
+	}
+}
+
+func TestValidateTemplateIsNotCached(t *testing.T) {
+	text := "{{.LocalDeviceId}}/not-cached"
+	_, err := ValidateTemplate(TemplateKindLocalServiceId, text)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := topicTemplates.Load(text); ok {
+		t.Error("validated template is cached")
+	}
+	_, err = ValidateTemplate(TemplateKindLocalServiceId, strings.Repeat("a", maxValidatedTemplateLength+1))
+	if !errors.Is(err, ErrInvalidTopicTemplate) {
+		t.Error(err)
+	}
+}
+
+func TestTemplateReferences(t *testing.T) {
+	for text, expected := range map[string]bool{
+		"set":                                    false,
+		"{{.LocalDeviceId}}/{{.LocalServiceId}}": false,
+		"{{.HubLocalId}}/set":                    true,
+		"{{short .HubId}}/set":                   true,
+		"{{if .LocalDeviceId}}{{.ShortHubId}}{{end}}": true,
+		"{{with .LocalDeviceId}}{{.}}{{end}}":         true,
+		`{{index . "HubId"}}`:                         true,
+		"{{$.HubId}}":                                 true,
+		"{{$.DeviceId}}":                              false,
+	} {
+		tmpl, err := parseTopicTemplate(text)
+		if err != nil {
+			t.Error(text, err)
+			continue
+		}
+		if actual := templateReferences(tmpl, hubPlaceholders); actual != expected {
+			t.Error(text, actual, expected)
+		}
+	}
+}
@@ -17,15 +17,125 @@
 package topic
 
 import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/association"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/devicerepo"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/pendingcommand"
 	"github.com/SENERGY-Platform/platform-connector-lib/iot"
 )
 
 type Topic struct {
 	iotCache               *iot.PreparedCache
 	defaultActuatorPattern string
-	associatedTopics       map[string]string
+	eventTopicPatterns     []*EventTopicPattern
+	associations           association.Store
+	serviceIndexes         map[string]*serviceIndex
+	serviceIndexMux        sync.Mutex
+	serviceIndexTtl        time.Duration
+	hubs                   Hubs        //optional; enables hub-scoped topics and hub placeholders of devices in a hub
+	deviceTypes            DeviceTypes //optional; enables AnalyseProtocol
+	commandRewriter        *Rewriter   //optional; rewrites command topics before the device id prefix is added
+	deviceHubs             deviceHubs
+	responseTopicPattern   string //optional; enables RegisterPendingCommand
+	responseTimeout        time.Duration
+	pendingCommands        pendingcommand.Store
+	repository             Repository //optional; reads devices and device-types after invalidations
+	invalidated            invalidatedIds
+	deviceExpiration       time.Duration
+	logger                 *slog.Logger
 }
 
 func New(iotCache *iot.PreparedCache, defaultActuatorPattern string) *Topic {
-	return &Topic{iotCache: iotCache, defaultActuatorPattern: defaultActuatorPattern, associatedTopics: map[string]string{}}
+	return &Topic{
+		iotCache:               iotCache,
+		defaultActuatorPattern: defaultActuatorPattern,
+		associations:           association.NewMemory(),
+		serviceIndexes:         map[string]*serviceIndex{},
+		serviceIndexTtl:        defaultServiceIndexTtl,
+		responseTimeout:        defaultCommandResponseTimeout,
+		pendingCommands:        pendingcommand.NewMemory(),
+		deviceExpiration:       defaultDeviceExpiration,
+		logger:                 slog.Default(),
+	}
+}
+
+// NewFromConfig uses config.ActuatorTopicPattern and config.EventTopicPatterns; invalid event topic patterns are logged and skipped (see ValidateConfig).
+// associations may be nil to use an in-memory store
+func NewFromConfig(iotCache *iot.PreparedCache, config configuration.Config, associations association.Store) *Topic {
+	result := New(iotCache, config.ActuatorTopicPattern)
+	result.logger = config.GetLogger()
+	if associations != nil {
+		result.associations = associations
+	}
+	commandRewriter, err := CompileRewriteRules(config.CommandTopicRewriteRules)
+	if err != nil {
+		config.GetLogger().Error("skip command topic rewrite rules", "error", err)
+	}
+	result.commandRewriter = commandRewriter
+	result.responseTopicPattern = config.CommandResponseTopicPattern
+	if config.CommandResponseTimeout != "" {
+		responseTimeout, err := time.ParseDuration(config.CommandResponseTimeout)
+		if err != nil {
+			config.GetLogger().Error("invalid command_response_timeout; use default", "error", err, "default", defaultCommandResponseTimeout.String())
+		} else {
+			result.responseTimeout = responseTimeout
+		}
+	}
+	deviceRepo := devicerepo.New(config.DeviceRepoUrl, time.Duration(config.DeviceExpiration)*time.Second)
+	result.hubs = deviceRepo
+	result.deviceTypes = deviceRepo
+	result.repository = deviceRepo
+	if config.DeviceTypeExpiration > 0 {
+		result.serviceIndexTtl = time.Duration(config.DeviceTypeExpiration) * time.Second
+	}
+	if config.DeviceExpiration > 0 {
+		result.deviceExpiration = time.Duration(config.DeviceExpiration) * time.Second
+	}
+	for _, pattern := range config.EventTopicPatterns {
+		compiled, err := CompileEventTopicPatterns([]string{pattern})
+		if err != nil {
+			config.GetLogger().Error("skip event topic pattern", "error", err)
+			continue
+		}
+		result.eventTopicPatterns = append(result.eventTopicPatterns, compiled...)
+	}
+	return result
+}
+
+// ValidateConfig checks config.EventTopicPatterns, config.ActuatorTopicPattern, the topic rewrite rules and the command response settings
+func ValidateConfig(config configuration.Config) error {
+	_, err := CompileEventTopicPatterns(config.EventTopicPatterns)
+	if err != nil {
+		return err
+	}
+	_, err = ValidateTemplate(TemplateKindActuatorTopicPattern, config.ActuatorTopicPattern)
+	if err != nil {
+		return err
+	}
+	_, err = CompileRewriteRules(config.TopicRewriteRules)
+	if err != nil {
+		return err
+	}
+	_, err = CompileRewriteRules(config.CommandTopicRewriteRules)
+	if err != nil {
+		return err
+	}
+	if config.CommandResponseTopicPattern != "" && config.CommandResponseTopicPattern != "-" {
+		_, err = ValidateTemplate(TemplateKindResponseTopicPattern, config.CommandResponseTopicPattern)
+		if err != nil {
+			return err
+		}
+	}
+	if config.CommandResponseTimeout != "" {
+		_, err = time.ParseDuration(config.CommandResponseTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid command_response_timeout: %w", err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,38 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topicfilter
+
+import "testing"
+
+func TestMatches(t *testing.T) {
+	t.Run(testMatches("a/b/c", "a/b/c", true))
+	t.Run(testMatches("a/+/c", "a/b/c", true))
+	t.Run(testMatches("a/#", "a/b/c", true))
+	t.Run(testMatches("a/b/c/#", "a/b/c", true))
+	t.Run(testMatches("$share/group/a/+/c", "a/b/c", true))
+	t.Run(testMatches("a/+", "a/b/c", false))
+	t.Run(testMatches("a/b/c/d", "a/b/c", false))
+	t.Run(testMatches("x/#", "a/b/c", false))
+}
+
+func testMatches(filter string, topic string, expected bool) (string, func(t *testing.T)) {
+	return filter + " " + topic, func(t *testing.T) {
+		if Matches(filter, topic) != expected {
+			t.Error(filter, topic, expected)
+		}
+	}
+}
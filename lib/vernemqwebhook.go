@@ -18,12 +18,57 @@ package lib
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/emqx"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/explain"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/lint"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/mosquitto"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/validate"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/vernemqtt"
 	"github.com/SENERGY-Platform/platform-connector-lib"
 )
 
-func AuthWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, connectionLog connectionlog.ConnectionLog) {
-	vernemqtt.InitWebhooks(ctx, config, connector, connectionLog)
+func AuthWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+	switch config.BrokerFlavour {
+	case "emqx":
+		emqx.InitWebhooks(ctx, config, connector, topicParser, connectionLog)
+	case "mosquitto":
+		mosquitto.InitWebhooks(ctx, config, connector, topicParser, connectionLog)
+	default:
+		vernemqtt.InitWebhooks(ctx, config, connector, topicParser, connectionLog)
+	}
+}
+
+// DebugEndpoints serves the explain, validate and lint endpoints of the webhook servers on config.WebhookPort;
+// used by the embedded broker, which needs no webhooks
+func DebugEndpoints(ctx context.Context, config configuration.Config, platform *hooks.Platform, topicParser *topic.Topic) {
+	router := http.NewServeMux()
+	explain.InitEndpoint(config, router, platform)
+	validate.InitEndpoint(config, router)
+	lint.InitEndpoints(config, router, topicParser)
+
+	var handler http.Handler = router
+	if config.Debug {
+		handler = vernemqtt.Logger(router)
+	}
+	server := &http.Server{Addr: ":" + config.WebhookPort, Handler: handler, WriteTimeout: 10 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
+	go func() {
+		config.GetLogger().Info("debug endpoints started", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			config.GetLogger().Error("FATAL: api server error", "error", err)
+			log.Fatal(err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		config.GetLogger().Info("debug endpoints shutdown", "result", server.Shutdown(context.Background()))
+	}()
 }
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// authn godoc
+// @Summary      emqx authentication
+// @Description  checks auth; all responses are with code=200, AuthnResponse.Result is allow or deny
+// @Accept       json
+// @Produce      json
+// @Param        message body AuthnMsg true "login infos"
+// @Success      200 {object}  AuthnResponse
+// @Router       /authn [POST]
+func authn(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
+	msg := AuthnMsg{}
+	err := json.NewDecoder(request.Body).Decode(&msg)
+	if err != nil {
+		logger.Error("unable to decode authn message", "err", err)
+		sendAuthnResult(writer, ResultDeny, false)
+		return
+	}
+
+	if config.MqttAuthMethod == "certificate" {
+		logger.Info("login", "action", "login", "peerAddr", msg.PeerHost, "loginType", "cert", "clientId", msg.ClientId)
+	} else {
+		logger.Info("login", "action", "login", "peerAddr", msg.PeerHost, "loginType", "pw", "username", msg.Username, "clientId", msg.ClientId)
+	}
+
+	decision, err := platform.Authenticate(hooks.AuthRequest{
+		Username: msg.Username,
+		Password: msg.Password,
+		ClientId: msg.ClientId,
+		PeerAddr: msg.PeerHost,
+	})
+	if err != nil {
+		logger.Error("unable to get user token", "error", err, "username", msg.Username, "clientId", msg.ClientId)
+	}
+	if decision.Verdict != hooks.Allow {
+		sendAuthnResult(writer, ResultDeny, false)
+		return
+	}
+	sendAuthnResult(writer, ResultAllow, decision.Superuser)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// authz godoc
+// @Summary      emqx authorization
+// @Description  checks if the user may access the device referenced by the topic; subscriptions must use the device-id prefix, because emqx can not rewrite topics; all responses are with code=200, AuthzResponse.Result is allow or deny
+// @Accept       json
+// @Produce      json
+// @Param        message body AuthzMsg true "authorization infos"
+// @Success      200 {object}  AuthzResponse
+// @Router       /authz [POST]
+func authz(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	msg := AuthzMsg{}
+	err := json.NewDecoder(request.Body).Decode(&msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode authz message", "error", err)
+		sendAuthzResult(writer, ResultDeny, err.Error(), config.Debug)
+		return
+	}
+	if msg.Action == ActionSubscribe {
+		decision, err := platform.AuthorizeSubscribe(hooks.SubscribeRequest{Username: msg.Username, ClientId: msg.ClientId, Topics: []hooks.TopicRequest{{Topic: msg.Topic}}})
+		if err != nil {
+			sendAuthzResult(writer, ResultDeny, err.Error(), config.Debug)
+			return
+		}
+		if len(decision.Topics) != 1 || decision.Topics[0].Verdict != hooks.Allow {
+			sendAuthzResult(writer, ResultDeny, topicReason(decision), config.Debug)
+			return
+		}
+		if decision.Topics[0].Rewritten() {
+			//vernemqtt would rewrite the subscription to the prefixed topic; emqx can not
+			sendAuthzResult(writer, ResultDeny, "commands are published with device-id or owner-id prefix; subscribe to "+decision.Topics[0].Topic, config.Debug)
+			return
+		}
+		sendAuthzResult(writer, ResultAllow, "", false)
+		return
+	}
+	decision, err := platform.AuthorizePublish(hooks.PublishRequest{Username: msg.Username, ClientId: msg.ClientId, Topic: msg.Topic})
+	if err != nil {
+		sendAuthzResult(writer, ResultDeny, err.Error(), config.Debug)
+		return
+	}
+	if decision.Verdict != hooks.Allow {
+		sendAuthzResult(writer, ResultDeny, decision.Reason, config.Debug)
+		return
+	}
+	sendAuthzResult(writer, ResultAllow, "", false)
+}
+
+func topicReason(decision hooks.SubscribeDecision) string {
+	if len(decision.Topics) == 0 {
+		return ""
+	}
+	return decision.Topics[0].Reason
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/explain"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/lint"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/validate"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/vernemqtt"
+	"github.com/SENERGY-Platform/platform-connector-lib"
+)
+
+// InitWebhooks starts the http endpoints for emqx (v5):
+//   - /authn for the http authentication (body: {"username":"${username}","password":"${password}","clientid":"${clientid}","peerhost":"${peerhost}"})
+//   - /authz for the http authorization (body: {"username":"${username}","clientid":"${clientid}","topic":"${topic}","action":"${action}","qos":"${qos}"})
+//   - /webhook for a data-bridge/webhook with the events client.connected, client.disconnected, session.subscribed, session.unsubscribed and message.publish
+//     (default event body; payloads are expected as raw strings)
+//
+// emqx can not rewrite topics like the vernemqtt redirect modifiers. because of that
+// subscriptions have to use the device-id prefix that topic.Topic.Create enforces for command topics.
+// subscriptions that hooks.Hooks would rewrite are denied; the authz response names the expected topic in AuthzResponse.Reason.
+func InitWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+	platform := hooks.NewFromConnector(config, connector, topicParser, connectionLog)
+	router := http.NewServeMux()
+
+	logger := config.GetLogger()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		logger = logger.With("go-module", info.Path)
+	}
+	logger = logger.With("snrgy-log-type", "connector-webhook")
+
+	router.HandleFunc("/authn", func(writer http.ResponseWriter, request *http.Request) {
+		authn(writer, request, config, platform, logger)
+	})
+
+	router.HandleFunc("/authz", func(writer http.ResponseWriter, request *http.Request) {
+		authz(writer, request, config, platform)
+	})
+
+	router.HandleFunc("/webhook", func(writer http.ResponseWriter, request *http.Request) {
+		webhook(writer, request, config, platform, logger)
+	})
+
+	explain.InitEndpoint(config, router, platform)
+	validate.InitEndpoint(config, router)
+	lint.InitEndpoints(config, router, topicParser)
+
+	var handler http.Handler = router
+	if config.Debug {
+		handler = vernemqtt.Logger(router)
+	}
+	server := &http.Server{Addr: ":" + config.WebhookPort, Handler: handler, WriteTimeout: 10 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
+	go func() {
+		config.GetLogger().Info("emqx webhook started", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			config.GetLogger().Error("FATAL: api server error", "error", err)
+			log.Fatal(err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		config.GetLogger().Info("emqx webhook shutdown", "result", server.Shutdown(context.Background()))
+	}()
+}
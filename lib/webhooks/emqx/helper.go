@@ -0,0 +1,42 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+func sendJson(writer http.ResponseWriter, msg interface{}) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	err := json.NewEncoder(writer).Encode(msg)
+	if err != nil {
+		slog.Error("unable to send response", "error", err)
+	}
+}
+
+func sendAuthzResult(writer http.ResponseWriter, result string, reason string, logging bool) {
+	if logging && reason != "" {
+		slog.Debug("send authz result", "result", result, "reason", reason)
+	}
+	sendJson(writer, AuthzResponse{Result: result, Reason: reason})
+}
+
+func sendAuthnResult(writer http.ResponseWriter, result string, isSuperuser bool) {
+	sendJson(writer, AuthnResponse{Result: result, IsSuperuser: isSuperuser})
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+const (
+	ResultAllow  = "allow"
+	ResultDeny   = "deny"
+	ResultIgnore = "ignore"
+)
+
+const (
+	ActionPublish   = "publish"
+	ActionSubscribe = "subscribe"
+)
+
+const (
+	EventClientConnected     = "client.connected"
+	EventClientDisconnected  = "client.disconnected"
+	EventSessionSubscribed   = "session.subscribed"
+	EventSessionUnsubscribed = "session.unsubscribed"
+	EventMessagePublish      = "message.publish"
+)
+
+type AuthnMsg struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	ClientId string `json:"clientid"`
+	PeerHost string `json:"peerhost"`
+}
+
+type AuthnResponse struct {
+	Result      string `json:"result" example:"allow"`
+	IsSuperuser bool   `json:"is_superuser"`
+}
+
+type AuthzMsg struct {
+	Username string `json:"username"`
+	ClientId string `json:"clientid"`
+	Topic    string `json:"topic"`
+	Action   string `json:"action" example:"publish"`
+}
+
+type AuthzResponse struct {
+	Result string `json:"result" example:"allow"`
+	Reason string `json:"reason,omitempty"` //ignored by emqx; explains denied requests
+}
+
+type WebhookMsg struct {
+	Event      string `json:"event" example:"message.publish"`
+	ClientId   string `json:"clientid"`
+	Username   string `json:"username"`
+	Topic      string `json:"topic"`
+	Payload    string `json:"payload"`
+	Qos        int    `json:"qos"`
+	CleanStart bool   `json:"clean_start"`
+	Reason     string `json:"reason"`
+}
+
+type EmptyResponse struct{}
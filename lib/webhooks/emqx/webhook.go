@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emqx
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// webhook godoc
+// @Summary      emqx webhook
+// @Description  handles client.connected, client.disconnected, session.subscribed, session.unsubscribed and message.publish events; published messages are forwarded to kafka; all responses are with code=200
+// @Accept       json
+// @Produce      json
+// @Param        message body WebhookMsg true "event"
+// @Success      200 {object}  EmptyResponse
+// @Router       /webhook [POST]
+func webhook(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
+	defer sendJson(writer, EmptyResponse{})
+	buf, err := io.ReadAll(request.Body)
+	if err != nil {
+		logger.Error("unable to read emqx webhook message", "error", err)
+		return
+	}
+	msg := WebhookMsg{}
+	err = json.Unmarshal(buf, &msg)
+	if err != nil {
+		logger.Error("unable to decode emqx webhook message", "error", err)
+		return
+	}
+	switch msg.Event {
+	case EventClientConnected:
+		platform.ClientOnline(hooks.OnlineRequest{ClientId: msg.ClientId, CleanSession: &msg.CleanStart})
+	case EventClientDisconnected:
+		logger.Info("disconnect", "action", "disconnect", "clientId", msg.ClientId, "reason", msg.Reason)
+		platform.ClientGone(msg.ClientId)
+	case EventSessionSubscribed:
+		decision, err := platform.AuthorizeSubscribe(hooks.SubscribeRequest{Username: msg.Username, ClientId: msg.ClientId, Topics: []hooks.TopicRequest{{Topic: msg.Topic, Qos: msg.Qos}}})
+		if err != nil {
+			config.GetLogger().Error("unable to handle emqx subscription event", "error", err, "topic", msg.Topic)
+			return
+		}
+		platform.Subscribed(msg.ClientId, decision)
+	case EventSessionUnsubscribed:
+		_ = platform.Unsubscribe(hooks.UnsubscribeRequest{Username: msg.Username, ClientId: msg.ClientId, Topics: []string{msg.Topic}})
+	case EventMessagePublish:
+		_, err = platform.HandlePublish(hooks.PublishRequest{
+			Username: msg.Username,
+			ClientId: msg.ClientId,
+			Topic:    msg.Topic,
+			Payload:  []byte(msg.Payload),
+			Qos:      msg.Qos,
+			Size:     len(buf),
+		})
+		if err != nil {
+			config.GetLogger().Error("unable to handle emqx publish event", "error", err, "topic", msg.Topic)
+		}
+	default:
+		config.GetLogger().Debug("ignore emqx event", "event", msg.Event)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package explain provides the debug endpoint GET /debug/explain of the webhook servers, which explains the device and service resolution of published topics.
+package explain
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
+)
+
+// UserTokenHeader may contain the token of the user instead of the user query parameter.
+// the token is not accepted as query parameter to keep it out of access logs
+const UserTokenHeader = "X-User-Token"
+
+var ErrForbidden = errors.New("admin role required")
+
+// Explainer is implemented by *hooks.Platform
+type Explainer interface {
+	ExplainTopic(username string, token security.JwtToken, topic string) (topic.Explanation, error)
+}
+
+// Authorize returns nil if the request may use the endpoint
+type Authorize func(request *http.Request) error
+
+// InitEndpoint adds GET /debug/explain?topic=<topic>&user=<username> to the router.
+// requests need an admin token (Authorization header) valid for the keycloak certs of config.AuthEndpoint
+func InitEndpoint(config configuration.Config, router *http.ServeMux, explainer Explainer) {
+	certs := &jwt.KeycloakCertProvider{CertUrl: config.AuthEndpoint + "/auth/realms/master/protocol/openid-connect/certs"}
+	router.Handle("GET /debug/explain", Handler(func(request *http.Request) error {
+		token, err := jwt.GetParsedAndValidatedToken(certs, request)
+		if err != nil {
+			return err
+		}
+		if !token.IsAdmin() {
+			return ErrForbidden
+		}
+		return nil
+	}, explainer))
+}
+
+func Handler(authorize Authorize, explainer Explainer) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		err := authorize(request)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(writer, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		query := request.URL.Query()
+		mqttTopic := query.Get("topic")
+		if mqttTopic == "" {
+			http.Error(writer, "missing topic", http.StatusBadRequest)
+			return
+		}
+		result, err := explainer.ExplainTopic(query.Get("user"), security.JwtToken(request.Header.Get(UserTokenHeader)), mqttTopic)
+		if errors.Is(err, hooks.ErrMissingExplainUser) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.Error("unable to send explanation", "error", err)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package explain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+type testExplainer struct{}
+
+func (this testExplainer) ExplainTopic(username string, token security.JwtToken, mqttTopic string) (result topic.Explanation, err error) {
+	if username == "" && token == "" {
+		return result, hooks.ErrMissingExplainUser
+	}
+	result.Topic = mqttTopic
+	result.Result.Error = username + string(token)
+	return result, nil
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(func(request *http.Request) error {
+		switch request.Header.Get("Authorization") {
+		case "admin":
+			return nil
+		case "user":
+			return ErrForbidden
+		default:
+			return security.ErrorAccessDenied
+		}
+	}, testExplainer{})
+
+	call := func(auth string, userToken string, query string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/debug/explain?"+query, nil)
+		request.Header.Set("Authorization", auth)
+		if userToken != "" {
+			request.Header.Set(UserTokenHeader, userToken)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if code := call("", "", "topic=foo&user=u").Code; code != http.StatusUnauthorized {
+		t.Error(code)
+	}
+	if code := call("user", "", "topic=foo&user=u").Code; code != http.StatusForbidden {
+		t.Error(code)
+	}
+	if code := call("admin", "", "user=u").Code; code != http.StatusBadRequest {
+		t.Error(code)
+	}
+	if code := call("admin", "", "topic=foo").Code; code != http.StatusBadRequest {
+		t.Error(code)
+	}
+
+	//testExplainer returns username + token as result error
+	for query, userToken := range map[string]string{"topic=foo%2Fbar&user=u": "", "topic=foo%2Fbar": "t"} {
+		recorder := call("admin", userToken, query)
+		if recorder.Code != http.StatusOK {
+			t.Error(query, recorder.Code, recorder.Body.String())
+			continue
+		}
+		result := topic.Explanation{}
+		err := json.NewDecoder(recorder.Body).Decode(&result)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		expectedUser := "u"
+		if userToken != "" {
+			expectedUser = userToken
+		}
+		if result.Topic != "foo/bar" || result.Result.Error != expectedUser {
+			t.Error(query, result)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lint provides the endpoints GET /lint/device-types/{id} and GET /lint/protocols/{id} of the webhook servers,
+// which report colliding service local ids and command topics of device-types (see topic.Topic.AnalyseDeviceType).
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
+)
+
+// Analyser is implemented by *topic.Topic
+type Analyser interface {
+	AnalyseDeviceTypeById(token security.JwtToken, deviceTypeId string) (topic.Analysis, error)
+	AnalyseProtocol(token security.JwtToken, protocolId string) ([]topic.Analysis, error)
+}
+
+// Authorize returns nil if the request may use the endpoint
+type Authorize func(request *http.Request) error
+
+// InitEndpoints adds GET /lint/device-types/{id} and GET /lint/protocols/{id} to the router.
+// requests need a token (Authorization header) valid for the keycloak certs of config.AuthEndpoint; device-types are read with this token
+func InitEndpoints(config configuration.Config, router *http.ServeMux, analyser Analyser) {
+	certs := &jwt.KeycloakCertProvider{CertUrl: config.AuthEndpoint + "/auth/realms/master/protocol/openid-connect/certs"}
+	authorize := func(request *http.Request) error {
+		_, err := jwt.GetParsedAndValidatedToken(certs, request)
+		return err
+	}
+	router.Handle("GET /lint/device-types/{id}", DeviceTypeHandler(authorize, analyser))
+	router.Handle("GET /lint/protocols/{id}", ProtocolHandler(authorize, analyser))
+}
+
+func DeviceTypeHandler(authorize Authorize, analyser Analyser) http.Handler {
+	return handler(authorize, func(token security.JwtToken, id string) (any, error) {
+		return analyser.AnalyseDeviceTypeById(token, id)
+	})
+}
+
+func ProtocolHandler(authorize Authorize, analyser Analyser) http.Handler {
+	return handler(authorize, func(token security.JwtToken, id string) (any, error) {
+		return analyser.AnalyseProtocol(token, id)
+	})
+}
+
+func handler(authorize Authorize, analyse func(token security.JwtToken, id string) (any, error)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		err := authorize(request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		result, err := analyse(security.JwtToken(request.Header.Get("Authorization")), request.PathValue("id"))
+		switch {
+		case errors.Is(err, security.ErrorNotFound):
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, security.ErrorAccessDenied):
+			http.Error(writer, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.Error("unable to send analysis", "error", err)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+type testAnalyser struct{}
+
+func (this testAnalyser) AnalyseDeviceTypeById(token security.JwtToken, deviceTypeId string) (result topic.Analysis, err error) {
+	if deviceTypeId != "dt1" {
+		return result, security.ErrorNotFound
+	}
+	return topic.Analysis{DeviceTypeId: deviceTypeId, DeviceTypeName: string(token), Findings: []topic.Finding{}}, nil
+}
+
+func (this testAnalyser) AnalyseProtocol(token security.JwtToken, protocolId string) (result []topic.Analysis, err error) {
+	return []topic.Analysis{{DeviceTypeId: protocolId, DeviceTypeName: string(token)}}, nil
+}
+
+func TestHandlers(t *testing.T) {
+	authorize := func(request *http.Request) error {
+		if request.Header.Get("Authorization") == "" {
+			return security.ErrorAccessDenied
+		}
+		return nil
+	}
+	router := http.NewServeMux()
+	router.Handle("GET /lint/device-types/{id}", DeviceTypeHandler(authorize, testAnalyser{}))
+	router.Handle("GET /lint/protocols/{id}", ProtocolHandler(authorize, testAnalyser{}))
+
+	call := func(auth string, path string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Authorization", auth)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if code := call("", "/lint/device-types/dt1").Code; code != http.StatusUnauthorized {
+		t.Error(code)
+	}
+	if code := call("token", "/lint/device-types/unknown").Code; code != http.StatusNotFound {
+		t.Error(code)
+	}
+
+	//testAnalyser returns the token as device-type name
+	recorder := call("token", "/lint/device-types/dt1")
+	analysis := topic.Analysis{}
+	err := json.NewDecoder(recorder.Body).Decode(&analysis)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if analysis.DeviceTypeId != "dt1" || analysis.DeviceTypeName != "token" {
+		t.Error(analysis)
+	}
+
+	recorder = call("token", "/lint/protocols/p1")
+	analyses := []topic.Analysis{}
+	err = json.NewDecoder(recorder.Body).Decode(&analyses)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(analyses) != 1 || analyses[0].DeviceTypeId != "p1" || analyses[0].DeviceTypeName != "token" {
+		t.Error(analyses)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// acl godoc
+// @Summary      mosquitto-go-auth acl check
+// @Description  checks if the user may access the device referenced by the topic; acc: 1=read, 2=write, 3=readwrite, 4=subscribe; responds with code=200 if allowed, else with code=403
+// @Accept       json
+// @Produce      json
+// @Param        message body AclMsg true "acl infos"
+// @Success      200 {object}  Response
+// @Failure      403 {object}  Response
+// @Router       /acl [POST]
+func acl(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	msg := AclMsg{}
+	err := decodeMsg(request, &msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode acl check message", "error", err)
+		sendDenied(writer, err.Error(), config.Debug)
+		return
+	}
+	var verdict hooks.Verdict
+	var reason string
+	if msg.Acc == AccWrite {
+		var decision hooks.PublishDecision
+		decision, err = platform.AuthorizePublish(hooks.PublishRequest{Username: msg.Username, ClientId: msg.ClientId, Topic: msg.Topic})
+		verdict, reason = decision.Verdict, decision.Reason
+	} else {
+		var decision hooks.SubscribeDecision
+		decision, err = platform.AuthorizeSubscribe(hooks.SubscribeRequest{Username: msg.Username, ClientId: msg.ClientId, Topics: []hooks.TopicRequest{{Topic: msg.Topic}}})
+		if err == nil && len(decision.Topics) == 1 {
+			verdict, reason = decision.Topics[0].Verdict, decision.Topics[0].Reason
+		}
+		if err == nil && msg.Acc == AccSubscribe {
+			platform.Subscribed(msg.ClientId, decision)
+		}
+	}
+	if err != nil {
+		sendDenied(writer, err.Error(), config.Debug)
+		return
+	}
+	//mosquitto can not rewrite topics; a rewritten topic in the decision is ignored
+	if verdict != hooks.Allow {
+		sendDenied(writer, reason, config.Debug)
+		return
+	}
+	sendOk(writer)
+}
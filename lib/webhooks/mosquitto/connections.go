@@ -0,0 +1,64 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"regexp"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// mosquitto notice log lines, optionally prefixed by a timestamp (log_timestamp)
+var (
+	connectLogPattern    = regexp.MustCompile(`New client connected from \S+ as (\S+) \(p\d+, c(\d)`)
+	disconnectLogPattern = regexp.MustCompile(`(?:Client (\S+) (?:\[\S+\] )?(?:closed its connection|disconnected|has exceeded timeout)|Socket error on client (\S+), disconnecting)`)
+)
+
+// Subscriber is implemented by lib.Mqtt.Subscribe
+type Subscriber func(topic string, qos byte, handler func(topic string, payload []byte, qos byte)) error
+
+// StartConnectionEvents subscribes config.MosquittoLogTopic to pass client connects and disconnects to platform, because go-auth does not signal them.
+// mosquitto has to be configured with log_dest topic and log_type notice; the connector client subscribes as superuser
+func StartConnectionEvents(config configuration.Config, subscribe Subscriber, platform hooks.Hooks) error {
+	if config.MosquittoLogTopic == "" || config.MosquittoLogTopic == "-" {
+		return nil
+	}
+	config.GetLogger().Info("start mosquitto connection events", "topic", config.MosquittoLogTopic)
+	return subscribe(config.MosquittoLogTopic, 1, func(topic string, payload []byte, qos byte) {
+		HandleLogMessage(platform, string(payload))
+	})
+}
+
+// HandleLogMessage calls platform.ClientOnline for connect log lines and platform.ClientGone for disconnect log lines; other lines are ignored
+func HandleLogMessage(platform hooks.Hooks, line string) {
+	if match := connectLogPattern.FindStringSubmatch(line); match != nil {
+		cleanSession := match[2] == "1"
+		platform.ClientOnline(hooks.OnlineRequest{ClientId: match[1], CleanSession: &cleanSession})
+		return
+	}
+	if match := disconnectLogPattern.FindStringSubmatch(line); match != nil {
+		clientId := match[1]
+		if clientId == "" {
+			clientId = match[2]
+		}
+		if clientId == "<unknown>" {
+			return
+		}
+		platform.ClientGone(clientId)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+type testHooks struct {
+	hooks.Hooks
+	events []string
+}
+
+func (this *testHooks) ClientOnline(req hooks.OnlineRequest) {
+	clean := "nil"
+	if req.CleanSession != nil && *req.CleanSession {
+		clean = "clean"
+	} else if req.CleanSession != nil {
+		clean = "persistent"
+	}
+	this.events = append(this.events, "online "+req.ClientId+" "+clean)
+}
+
+func (this *testHooks) ClientGone(clientId string) {
+	this.events = append(this.events, "gone "+clientId)
+}
+
+func TestHandleLogMessage(t *testing.T) {
+	platform := &testHooks{}
+	for _, line := range []string{
+		"1700000000: New client connected from 172.17.0.1:51234 as client-1 (p2, c1, k60, u'user').",
+		"2023-11-14T22:13:20: New client connected from 172.17.0.1:51235 as client-2 (p5, c0, k30, u'user').",
+		"1700000000: New connection from 172.17.0.1:51236 on port 1883.",
+		"1700000000: Client client-1 closed its connection.",
+		"1700000000: Client client-2 disconnected.",
+		"1700000000: Client client-3 has exceeded timeout, disconnecting.",
+		"1700000000: Socket error on client client-4, disconnecting.",
+		"1700000000: Client <unknown> disconnected, not authorised.",
+		"1700000000: Saving in-memory database to /mosquitto/data/mosquitto.db.",
+	} {
+		HandleLogMessage(platform, line)
+	}
+	expected := []string{
+		"online client-1 clean",
+		"online client-2 persistent",
+		"gone client-1",
+		"gone client-2",
+		"gone client-3",
+		"gone client-4",
+	}
+	if !reflect.DeepEqual(platform.events, expected) {
+		t.Error(platform.events, expected)
+	}
+}
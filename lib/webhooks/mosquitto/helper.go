@@ -0,0 +1,72 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// decodeMsg reads the request parameters as json or form values, depending on auth_opt_http_params_mode
+func decodeMsg(request *http.Request, msg interface{}) error {
+	if !strings.HasPrefix(request.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return json.NewDecoder(request.Body).Decode(msg)
+	}
+	err := request.ParseForm()
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case *UserMsg:
+		m.Username = request.PostForm.Get("username")
+		m.Password = request.PostForm.Get("password")
+		m.ClientId = request.PostForm.Get("clientid")
+	case *SuperuserMsg:
+		m.Username = request.PostForm.Get("username")
+	case *AclMsg:
+		m.Username = request.PostForm.Get("username")
+		m.ClientId = request.PostForm.Get("clientid")
+		m.Topic = request.PostForm.Get("topic")
+		m.Acc, err = strconv.Atoi(request.PostForm.Get("acc"))
+	}
+	return err
+}
+
+// sendOk is understood by the status and the json response mode of mosquitto-go-auth
+func sendOk(writer http.ResponseWriter) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	err := json.NewEncoder(writer).Encode(Response{Ok: true})
+	if err != nil {
+		slog.Error("unable to send ok", "error", err)
+	}
+}
+
+// sendDenied is understood by the status and the json response mode of mosquitto-go-auth
+func sendDenied(writer http.ResponseWriter, msg string, logging bool) {
+	if logging {
+		slog.Debug("send denied", "msg", msg)
+	}
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.WriteHeader(http.StatusForbidden)
+	err := json.NewEncoder(writer).Encode(Response{Ok: false, Error: msg})
+	if err != nil {
+		slog.Error("unable to send denied msg", "error", err, "msg", msg)
+	}
+}
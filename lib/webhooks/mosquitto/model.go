@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+// access levels used by mosquitto-go-auth in AclMsg.Acc
+const (
+	AccRead      = 1
+	AccWrite     = 2
+	AccReadWrite = 3
+	AccSubscribe = 4
+)
+
+type UserMsg struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	ClientId string `json:"clientid"`
+}
+
+type SuperuserMsg struct {
+	Username string `json:"username"`
+}
+
+type AclMsg struct {
+	Username string `json:"username"`
+	ClientId string `json:"clientid"`
+	Topic    string `json:"topic"`
+	Acc      int    `json:"acc"`
+}
+
+type Response struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
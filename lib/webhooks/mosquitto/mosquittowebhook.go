@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/explain"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/lint"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/validate"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/vernemqtt"
+	"github.com/SENERGY-Platform/platform-connector-lib"
+)
+
+// InitWebhooks starts the http backend for the mosquitto-go-auth plugin (https://github.com/iegomez/mosquitto-go-auth).
+// the plugin has to be configured with auth_opt_backends http, auth_opt_http_getuser_uri /user,
+// auth_opt_http_superuser_uri /superuser and auth_opt_http_aclcheck_uri /acl.
+// auth_opt_http_response_mode may be status or json, auth_opt_http_params_mode may be json or form.
+//
+// differences to the vernemqtt webhooks:
+//   - go-auth can not rewrite topics: devices have to subscribe to command topics including the device-id prefix created by topic.Topic.Create
+//     or, for topics of conventions (sparkplug, homie, zigbee2mqtt, tasmota), the owner-id prefix
+//   - go-auth does not forward payloads: the acl check only authorizes publishes, events are not forwarded to kafka
+//   - go-auth does not signal connects and disconnects: they are read from the broker log instead (see StartConnectionEvents)
+func InitWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+	platform := hooks.NewFromConnector(config, connector, topicParser, connectionLog)
+	router := http.NewServeMux()
+
+	logger := config.GetLogger()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		logger = logger.With("go-module", info.Path)
+	}
+	logger = logger.With("snrgy-log-type", "connector-webhook")
+
+	router.HandleFunc("/user", func(writer http.ResponseWriter, request *http.Request) {
+		user(writer, request, config, platform, logger)
+	})
+
+	router.HandleFunc("/superuser", func(writer http.ResponseWriter, request *http.Request) {
+		superuser(writer, request, config)
+	})
+
+	router.HandleFunc("/acl", func(writer http.ResponseWriter, request *http.Request) {
+		acl(writer, request, config, platform)
+	})
+
+	explain.InitEndpoint(config, router, platform)
+	validate.InitEndpoint(config, router)
+	lint.InitEndpoints(config, router, topicParser)
+
+	var handler http.Handler = router
+	if config.Debug {
+		handler = vernemqtt.Logger(router)
+	}
+	server := &http.Server{Addr: ":" + config.WebhookPort, Handler: handler, WriteTimeout: 10 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
+	go func() {
+		config.GetLogger().Info("mosquitto-go-auth backend started", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			config.GetLogger().Error("FATAL: api server error", "error", err)
+			log.Fatal(err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		config.GetLogger().Info("mosquitto-go-auth backend shutdown", "result", server.Shutdown(context.Background()))
+	}()
+}
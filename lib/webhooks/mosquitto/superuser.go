@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+)
+
+// superuser godoc
+// @Summary      mosquitto-go-auth superuser check
+// @Description  only the connector itself is a superuser; responds with code=200 for superusers, else with code=403
+// @Accept       json
+// @Produce      json
+// @Param        message body SuperuserMsg true "user infos"
+// @Success      200 {object}  Response
+// @Failure      403 {object}  Response
+// @Router       /superuser [POST]
+func superuser(writer http.ResponseWriter, request *http.Request, config configuration.Config) {
+	msg := SuperuserMsg{}
+	err := decodeMsg(request, &msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode superuser check message", "error", err)
+		sendDenied(writer, err.Error(), config.Debug)
+		return
+	}
+	if msg.Username != config.AuthClientId {
+		sendDenied(writer, "no superuser", false)
+		return
+	}
+	sendOk(writer)
+}
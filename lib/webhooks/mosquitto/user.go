@@ -0,0 +1,64 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosquitto
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// user godoc
+// @Summary      mosquitto-go-auth user check
+// @Description  checks auth; responds with code=200 if the user is allowed to connect, else with code=403
+// @Accept       json
+// @Produce      json
+// @Param        message body UserMsg true "login infos"
+// @Success      200 {object}  Response
+// @Failure      403 {object}  Response
+// @Router       /user [POST]
+func user(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
+	msg := UserMsg{}
+	err := decodeMsg(request, &msg)
+	if err != nil {
+		logger.Error("unable to decode user check message", "err", err)
+		sendDenied(writer, err.Error(), config.Debug)
+		return
+	}
+
+	if config.MqttAuthMethod == "certificate" {
+		logger.Info("login", "action", "login", "loginType", "cert", "clientId", msg.ClientId)
+	} else {
+		logger.Info("login", "action", "login", "loginType", "pw", "username", msg.Username, "clientId", msg.ClientId)
+	}
+
+	decision, err := platform.Authenticate(hooks.AuthRequest{
+		Username: msg.Username,
+		Password: msg.Password,
+		ClientId: msg.ClientId,
+	})
+	if err != nil {
+		logger.Error("unable to get user token", "error", err, "username", msg.Username, "clientId", msg.ClientId)
+	}
+	if decision.Verdict != hooks.Allow {
+		sendDenied(writer, decision.Reason, config.Debug)
+		return
+	}
+	sendOk(writer)
+}
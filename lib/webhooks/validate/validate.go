@@ -0,0 +1,99 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package validate provides the endpoint POST /validate/topic-template of the webhook servers,
+// which checks service local ids and topic patterns before device-type authors save them.
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
+)
+
+type Request struct {
+	Kind     string `json:"kind"` //topic.TemplateKindLocalServiceId (default), topic.TemplateKindActuatorTopicPattern, topic.TemplateKindEventTopicPattern or topic.TemplateKindResponseTopicPattern
+	Template string `json:"template"`
+}
+
+type Response struct {
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+	Example string `json:"example,omitempty"` //command topic created with example values; the device id prefix is not added
+}
+
+var ErrForbidden = errors.New("admin role required")
+
+// Authorize returns nil if the request may use the endpoint
+type Authorize func(request *http.Request) error
+
+// InitEndpoint adds POST /validate/topic-template to the router.
+// requests need an admin token (Authorization header) valid for the keycloak certs of config.AuthEndpoint
+func InitEndpoint(config configuration.Config, router *http.ServeMux) {
+	certs := &jwt.KeycloakCertProvider{CertUrl: config.AuthEndpoint + "/auth/realms/master/protocol/openid-connect/certs"}
+	router.Handle("POST /validate/topic-template", Handler(func(request *http.Request) error {
+		token, err := jwt.GetParsedAndValidatedToken(certs, request)
+		if err != nil {
+			return err
+		}
+		if !token.IsAdmin() {
+			return ErrForbidden
+		}
+		return nil
+	}))
+}
+
+// Handler responds with status 200 and Response.Valid=false for invalid templates
+func Handler(authorize Authorize) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		err := authorize(request)
+		if errors.Is(err, ErrForbidden) {
+			http.Error(writer, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		req := Request{}
+		err = json.NewDecoder(request.Body).Decode(&req)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Kind == "" {
+			req.Kind = topic.TemplateKindLocalServiceId
+		}
+		result := Response{Valid: true}
+		result.Example, err = topic.ValidateTemplate(req.Kind, req.Template)
+		if errors.Is(err, topic.ErrInvalidTopicTemplate) || errors.Is(err, topic.ErrInvalidEventTopicPattern) {
+			result = Response{Valid: false, Error: err.Error()}
+		} else if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.Error("unable to send template validation", "error", err)
+		}
+	})
+}
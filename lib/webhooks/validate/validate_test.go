@@ -0,0 +1,81 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package validate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
+)
+
+func TestHandler(t *testing.T) {
+	handler := Handler(func(request *http.Request) error {
+		switch request.Header.Get("Authorization") {
+		case "":
+			return security.ErrorAccessDenied
+		case "user":
+			return ErrForbidden
+		default:
+			return nil
+		}
+	})
+
+	call := func(auth string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/validate/topic-template", strings.NewReader(body))
+		request.Header.Set("Authorization", auth)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if code := call("", `{"template": "set"}`).Code; code != http.StatusUnauthorized {
+		t.Error(code)
+	}
+	if code := call("user", `{"template": "set"}`).Code; code != http.StatusForbidden {
+		t.Error(code)
+	}
+	if code := call("admin", `{`).Code; code != http.StatusBadRequest {
+		t.Error(code)
+	}
+
+	for body, expected := range map[string]Response{
+		`{"template": "{{.LocalDeviceId}}/set"}`:                                                        {Valid: true, Example: "example-device/set"},
+		`{"kind": "event_topic_pattern", "template": "devices/{{.LocalDeviceId}}/{{.LocalServiceId}}"}`: {Valid: true},
+		`{"template": "{{.LocalDeviceId"}`:                                                              {Valid: false},
+		`{"kind": "event_topic_pattern", "template": "devices/{{.LocalDeviceId}}"}`:                     {Valid: false},
+		`{"template": "{{range 2000000000}}x{{end}}"}`:                                                  {Valid: false},
+	} {
+		recorder := call("admin", body)
+		if recorder.Code != http.StatusOK {
+			t.Error(body, recorder.Code)
+			continue
+		}
+		result := Response{}
+		err := json.NewDecoder(recorder.Body).Decode(&result)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if result.Valid != expected.Valid || result.Example != expected.Example || (result.Error == "") != expected.Valid {
+			t.Error(body, result)
+		}
+	}
+}
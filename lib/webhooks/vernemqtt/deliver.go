@@ -0,0 +1,57 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vernemqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// deliver godoc
+// @Summary      on_deliver webhook
+// @Description  maps the topic of a delivered message back to the form the client subscribed with, reversing the device-id prefix added by /subscribe; may be registered for on_deliver and on_deliver_m5; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format
+// @Accept       json
+// @Produce      json
+// @Param        message body DeliverWebhookMsg true "delivered message"
+// @Success      200 {object}  OkResponse
+// @Success      201 {object}  DeliverResponse
+// @Failure      400 {object}  ErrorResponse
+// @Router       /deliver [POST]
+func deliver(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	msg := DeliverWebhookMsg{}
+	err := json.NewDecoder(request.Body).Decode(&msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode deliver webhook message", "error", err)
+		sendError(writer, err.Error(), config.Debug)
+		return
+	}
+	req := hooks.DeliverRequest{Username: msg.Username, ClientId: msg.ClientId, Topic: msg.Topic}
+	decision, err := platform.Deliver(req)
+	if err != nil || !decision.Rewritten(req) {
+		//delivery should not fail because of the connection-log
+		fmt.Fprintf(writer, `{"result": "ok"}`)
+		return
+	}
+	err = json.NewEncoder(writer).Encode(DeliverResponse{Result: "ok", Modifiers: DeliverModifiers{Topic: decision.Topic}})
+	if err != nil {
+		config.GetLogger().Error("unable to encode deliver webhook result", "error", err)
+	}
+}
@@ -24,7 +24,7 @@ import (
 	"runtime/debug"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // disconnect godoc
@@ -36,7 +36,7 @@ import (
 // @Success      200 {object}  EmptyResponse
 // @Failure      400 {object}  ErrorResponse
 // @Router       /disconnect [POST]
-func disconnect(writer http.ResponseWriter, request *http.Request, config configuration.Config, connectionLog connectionlog.ConnectionLog, logger *slog.Logger) {
+func disconnect(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
 	defer func() {
 		if p := recover(); p != nil {
 			if config.Debug {
@@ -55,5 +55,5 @@ func disconnect(writer http.ResponseWriter, request *http.Request, config config
 		return
 	}
 	logger.Info("disconnect", "action", "disconnect", "clientId", msg.ClientId)
-	connectionLog.Disconnect(msg.ClientId)
+	platform.ClientGone(msg.ClientId)
 }
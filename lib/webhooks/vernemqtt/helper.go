@@ -20,8 +20,8 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"strings"
 
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
 )
 
@@ -35,6 +35,34 @@ func sendError(writer http.ResponseWriter, msg string, logging bool) {
 	}
 }
 
+func sendErrorM5(writer http.ResponseWriter, code hooks.ReasonCode, reason string, logging bool) {
+	if logging {
+		slog.Debug("send error", "reason-code", code, "reason", reason)
+	}
+	err := json.NewEncoder(writer).Encode(ErrorM5Response{Result: ErrorM5ResponseResult{Error: ErrorM5{ReasonCode: reasonCodeName(code), ReasonString: reason}}})
+	if err != nil {
+		slog.Error("unable to send error msg", "error", err, "reason", reason)
+	}
+}
+
+// reasonCodeName returns the name vernemqtt uses for the mqtt v5 reason code
+func reasonCodeName(code hooks.ReasonCode) string {
+	switch code {
+	case hooks.ReasonBadUsernameOrPassword:
+		return "bad_username_or_password"
+	case hooks.ReasonNotAuthorized:
+		return "not_authorized"
+	case hooks.ReasonTopicFilterInvalid:
+		return "topic_filter_invalid"
+	case hooks.ReasonTopicNameInvalid:
+		return "topic_name_invalid"
+	case hooks.ReasonPayloadFormatInvalid:
+		return "payload_format_invalid"
+	default:
+		return "unspecified_error"
+	}
+}
+
 func sendIgnoreRedirect(writer http.ResponseWriter, topic string, base64Msg string) {
 	slog.Debug("send ignore redirect", "topic", topic, "msg", base64Msg)
 	err := json.NewEncoder(writer).Encode(RedirectResponse{
@@ -51,13 +79,7 @@ func sendIgnoreRedirect(writer http.ResponseWriter, topic string, base64Msg stri
 	}
 }
 
-func sendWithPrefixRedirect(writer http.ResponseWriter, prefix string, topic string, base64Msg string) {
-	if !strings.HasSuffix(prefix, "/") {
-		prefix += "/"
-	}
-	if !strings.HasPrefix(topic, prefix) {
-		topic = prefix + topic
-	}
+func sendRedirect(writer http.ResponseWriter, topic string, base64Msg string) {
 	slog.Debug("send redirect", "topic", topic, "msg", base64Msg)
 	err := json.NewEncoder(writer).Encode(RedirectResponse{
 		Result: "ok",
@@ -24,10 +24,7 @@ import (
 	"strconv"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
-	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
-	"github.com/SENERGY-Platform/platform-connector-lib/model"
-	"github.com/SENERGY-Platform/platform-connector-lib/security"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // login godoc
@@ -39,7 +36,7 @@ import (
 // @Success      200 {object}  OkResponse
 // @Failure      400 {object}  ErrorResponse
 // @Router       /login [POST]
-func login(writer http.ResponseWriter, request *http.Request, config configuration.Config, connector *platform_connector_lib.Connector, connectionLog connectionlog.ConnectionLog, logger *slog.Logger) {
+func login(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
 	//{"peer_addr":"172.20.0.30","peer_port":41310,"mountpoint":"","client_id":"sepl_mqtt_connector_1","username":"sepl","password":"sepl","clean_session":true}
 	msg := LoginWebhookMsg{}
 	err := json.NewDecoder(request.Body).Decode(&msg)
@@ -49,42 +46,27 @@ func login(writer http.ResponseWriter, request *http.Request, config configurati
 		return
 	}
 
-	authenticationMethod := config.MqttAuthMethod
-
-	if authenticationMethod == "certificate" {
+	if config.MqttAuthMethod == "certificate" {
 		logger.Info("login", "action", "login", "peerAddr", msg.PeerAddr, "loginType", "cert", "clientId", msg.ClientId, "cleanStart", msg.CleanStart, "cleanSession", msg.CleanSession)
 	} else {
 		logger.Info("login", "action", "login", "peerAddr", msg.PeerAddr, "loginType", "pw", "username", msg.Username, "clientId", msg.ClientId, "cleanStart", msg.CleanStart, "cleanSession", msg.CleanSession)
 	}
 
-	if msg.Username != config.AuthClientId {
-		var token security.JwtToken
-		var err error
-
-		if authenticationMethod == "password" {
-			token, err = connector.Security().GetUserToken(msg.Username, msg.Password, model.RemoteInfo{
-				Ip:       msg.PeerAddr,
-				Port:     strconv.Itoa(msg.PeerPort),
-				Protocol: config.SecRemoteProtocol,
-			})
-		} else if authenticationMethod == "certificate" {
-			// The user is already authenticated by the TLS client certificate validation in the broker
-			token, err = connector.Security().ExchangeUserToken(msg.Username, model.RemoteInfo{
-				Ip:       msg.PeerAddr,
-				Port:     strconv.Itoa(msg.PeerPort),
-				Protocol: config.SecRemoteProtocol,
-			})
-		}
-		if err != nil {
-			logger.Error("unable to get user token", "error", err, "msg", fmt.Sprintf("%#v", msg))
-			sendError(writer, err.Error(), config.Debug)
-			return
-		}
-		if token == "" {
-			sendError(writer, "access denied", config.Debug)
-			return
-		}
-		connectionLog.SetCleanSession(msg.ClientId, msg.CleanSession || msg.CleanStart)
+	cleanSession := msg.CleanSession || msg.CleanStart
+	decision, err := platform.Authenticate(hooks.AuthRequest{
+		Username:     msg.Username,
+		Password:     msg.Password,
+		ClientId:     msg.ClientId,
+		PeerAddr:     msg.PeerAddr,
+		PeerPort:     strconv.Itoa(msg.PeerPort),
+		CleanSession: &cleanSession,
+	})
+	if err != nil {
+		logger.Error("unable to get user token", "error", err, "username", msg.Username, "clientId", msg.ClientId)
+	}
+	if decision.Verdict != hooks.Allow {
+		sendError(writer, decision.Reason, config.Debug)
+		return
 	}
 	fmt.Fprintf(writer, `{"result": "ok"}`)
 }
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vernemqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// loginM5 godoc
+// @Summary      auth_on_register_m5 webhook
+// @Description  checks auth of mqtt v5 clients; the session expiry interval decides if the session is logged as clean; denied logins respond with a v5 reason code; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format
+// @Accept       json
+// @Produce      json
+// @Param        message body LoginM5WebhookMsg true "login infos"
+// @Success      200 {object}  OkResponse
+// @Failure      400 {object}  ErrorM5Response
+// @Router       /login_m5 [POST]
+func loginM5(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks, logger *slog.Logger) {
+	msg := LoginM5WebhookMsg{}
+	err := json.NewDecoder(request.Body).Decode(&msg)
+	if err != nil {
+		logger.Error("unable to decode login_m5 webhook message", "err", err)
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+
+	cleanSession := msg.Properties.CleanSession()
+	if config.MqttAuthMethod == "certificate" {
+		logger.Info("login", "action", "login", "peerAddr", msg.PeerAddr, "loginType", "cert", "clientId", msg.ClientId, "cleanStart", msg.CleanStart, "cleanSession", cleanSession)
+	} else {
+		logger.Info("login", "action", "login", "peerAddr", msg.PeerAddr, "loginType", "pw", "username", msg.Username, "clientId", msg.ClientId, "cleanStart", msg.CleanStart, "cleanSession", cleanSession)
+	}
+
+	decision, err := platform.Authenticate(hooks.AuthRequest{
+		Username:     msg.Username,
+		Password:     msg.Password,
+		ClientId:     msg.ClientId,
+		PeerAddr:     msg.PeerAddr,
+		PeerPort:     strconv.Itoa(msg.PeerPort),
+		CleanSession: &cleanSession,
+	})
+	if err != nil {
+		logger.Error("unable to get user token", "error", err, "username", msg.Username, "clientId", msg.ClientId)
+	}
+	if decision.Verdict != hooks.Allow {
+		sendErrorM5(writer, decision.ReasonCode, decision.Reason, config.Debug)
+		return
+	}
+	fmt.Fprintf(writer, `{"result": "ok"}`)
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package vernemqtt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+)
+
+// testHooks allows user "user" with password "pw", denies publishes and subscriptions to "denied/..."
+// and rewrites everything else: publishes to "forwarded/..." are forwarded to the platform, subscriptions get the "device/" prefix
+type testHooks struct {
+	hooks.Hooks
+	subscribed []string
+}
+
+func (this *testHooks) Authenticate(req hooks.AuthRequest) (hooks.AuthDecision, error) {
+	if req.Username != "user" || req.Password != "pw" {
+		return hooks.AuthDecision{Verdict: hooks.Deny, ReasonCode: hooks.ReasonBadUsernameOrPassword, Reason: "wrong password"}, nil
+	}
+	return hooks.AuthDecision{Verdict: hooks.Allow}, nil
+}
+
+func (this *testHooks) HandlePublish(req hooks.PublishRequest) (hooks.PublishDecision, error) {
+	switch {
+	case strings.HasPrefix(req.Topic, "denied/"):
+		return hooks.PublishDecision{Verdict: hooks.Deny, ReasonCode: hooks.ReasonNotAuthorized, Reason: "denied"}, nil
+	case strings.HasPrefix(req.Topic, "forwarded/"):
+		return hooks.PublishDecision{Verdict: hooks.Allow, Topic: "device/" + req.Topic, Qos: req.Qos, Forwarded: true}, nil
+	case strings.HasPrefix(req.Topic, "ignored/"):
+		return hooks.PublishDecision{Verdict: hooks.Ignore, Topic: req.Topic}, nil
+	default:
+		return hooks.PublishDecision{Verdict: hooks.Allow, Topic: req.Topic, Qos: req.Qos}, nil
+	}
+}
+
+func (this *testHooks) AuthorizeSubscribe(req hooks.SubscribeRequest) (hooks.SubscribeDecision, error) {
+	result := hooks.SubscribeDecision{}
+	for _, t := range req.Topics {
+		if strings.HasPrefix(t.Topic, "denied/") {
+			result.Topics = append(result.Topics, hooks.TopicDecision{Verdict: hooks.Deny, RequestedTopic: t.Topic, Topic: t.Topic, Qos: t.Qos, ReasonCode: hooks.ReasonNotAuthorized, Reason: "denied"})
+		} else {
+			result.Topics = append(result.Topics, hooks.TopicDecision{Verdict: hooks.Allow, RequestedTopic: t.Topic, Topic: "device/" + t.Topic, Qos: t.Qos})
+		}
+	}
+	return result, nil
+}
+
+func (this *testHooks) Subscribed(clientId string, decision hooks.SubscribeDecision) {
+	for _, t := range decision.Topics {
+		if t.Verdict == hooks.Allow {
+			this.subscribed = append(this.subscribed, clientId+" "+t.Topic)
+		}
+	}
+}
+
+func TestLoginM5(t *testing.T) {
+	t.Run("allow", testM5Webhook(loginM5Handler, LoginM5WebhookMsg{Username: "user", Password: "pw", ClientId: "c1"}, OkResponse{Result: "ok"}))
+	t.Run("deny", testM5Webhook(loginM5Handler, LoginM5WebhookMsg{Username: "user", Password: "wrong", ClientId: "c1"}, ErrorM5Response{Result: ErrorM5ResponseResult{Error: ErrorM5{ReasonCode: "bad_username_or_password", ReasonString: "wrong password"}}}))
+}
+
+func TestPublishM5(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"value": 42}`))
+	t.Run("allow", testM5Webhook(publishM5, PublishM5WebhookMsg{Username: "user", ClientId: "c1", Topic: "topic", Payload: payload, Qos: 1}, OkResponse{Result: "ok"}))
+	t.Run("deny", testM5Webhook(publishM5, PublishM5WebhookMsg{Username: "user", ClientId: "c1", Topic: "denied/topic", Payload: payload, Qos: 1}, ErrorM5Response{Result: ErrorM5ResponseResult{Error: ErrorM5{ReasonCode: "not_authorized", ReasonString: "denied"}}}))
+	t.Run("rewrite", testM5Webhook(publishM5, PublishM5WebhookMsg{Username: "user", ClientId: "c1", Topic: "forwarded/topic", Payload: payload, Qos: 1}, RedirectResponse{Result: "ok", Modifiers: RedirectModifiers{Topic: "device/forwarded/topic", Payload: payload}}))
+	t.Run("ignore", testM5Webhook(publishM5, PublishM5WebhookMsg{Username: "user", ClientId: "c1", Topic: "ignored/topic", Payload: payload, Qos: 1}, RedirectResponse{Result: "ok", Modifiers: RedirectModifiers{Topic: "ignored/ignored/topic", Payload: payload}}))
+	t.Run("invalid payload", testM5Webhook(publishM5, PublishM5WebhookMsg{Username: "user", ClientId: "c1", Topic: "topic", Payload: "not base64", Qos: 1}, ErrorM5Response{Result: ErrorM5ResponseResult{Error: ErrorM5{ReasonCode: "unspecified_error", ReasonString: "illegal base64 data at input byte 3"}}}))
+}
+
+func TestPropertiesM5Message(t *testing.T) {
+	properties := PropertiesM5{}
+	err := json.Unmarshal([]byte(`{"p_content_type": "application/json", "p_user_property": [["unit", "°C"]]}`), &properties)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := message.Properties{ContentType: "application/json", UserProperties: []message.UserProperty{{Key: "unit", Value: "°C"}}}
+	if actual := properties.Message(); !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, expected)
+	}
+}
+
+func TestSubscribeM5(t *testing.T) {
+	t.Run("allow and rewrite", testM5Webhook(subscribeM5, SubscribeM5WebhookMsg{Username: "user", ClientId: "c1", Topics: []WebhookmsgTopic{{Topic: "cmd/#", Qos: 1}}}, SubscribeM5WebhookResult{
+		Result:    "ok",
+		Modifiers: SubscribeModifiers{Topics: []WebhookmsgTopic{{Topic: "device/cmd/#", Qos: 1}}},
+	}))
+	t.Run("deny", testM5Webhook(subscribeM5, SubscribeM5WebhookMsg{Username: "user", ClientId: "c1", Topics: []WebhookmsgTopic{{Topic: "denied/#", Qos: 2}}}, SubscribeM5WebhookResult{
+		Result: "ok",
+		Modifiers: SubscribeModifiers{
+			Topics:     []WebhookmsgTopic{{Topic: "denied/#", Qos: 135}},
+			Properties: &PropertiesM5{ReasonString: "denied/#: denied"},
+		},
+	}))
+	t.Run("mixed", testM5Webhook(subscribeM5, SubscribeM5WebhookMsg{Username: "user", ClientId: "c1", Topics: []WebhookmsgTopic{{Topic: "cmd/#", Qos: 0}, {Topic: "denied/#", Qos: 1}}}, SubscribeM5WebhookResult{
+		Result: "ok",
+		Modifiers: SubscribeModifiers{
+			Topics:     []WebhookmsgTopic{{Topic: "device/cmd/#", Qos: 0}, {Topic: "denied/#", Qos: 135}},
+			Properties: &PropertiesM5{ReasonString: "denied/#: denied"},
+		},
+	}))
+
+	t.Run("only allowed topics are stored", func(t *testing.T) {
+		platform := &testHooks{}
+		body, _ := json.Marshal(SubscribeM5WebhookMsg{Username: "user", ClientId: "c1", Topics: []WebhookmsgTopic{{Topic: "cmd/#", Qos: 0}, {Topic: "denied/#", Qos: 1}}})
+		subscribeM5(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/subscribe_m5", strings.NewReader(string(body))), configuration.Config{}, platform)
+		if !reflect.DeepEqual(platform.subscribed, []string{"c1 device/cmd/#"}) {
+			t.Error(platform.subscribed)
+		}
+	})
+}
+
+func loginM5Handler(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	loginM5(writer, request, config, platform, slog.Default())
+}
+
+func testM5Webhook[T any](handler func(http.ResponseWriter, *http.Request, configuration.Config, hooks.Hooks), msg interface{}, expected T) func(t *testing.T) {
+	return func(t *testing.T) {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))), configuration.Config{}, &testHooks{})
+		if recorder.Code != http.StatusOK {
+			t.Error(recorder.Code)
+			return
+		}
+		var actual T
+		decoder := json.NewDecoder(recorder.Body)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("\n%#v\n%#v", actual, expected)
+		}
+	}
+}
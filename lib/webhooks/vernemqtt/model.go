@@ -51,6 +51,22 @@ type LoginWebhookMsg struct {
 	CleanStart   bool   `json:"clean_start"`   //v5
 }
 
+type DeliverWebhookMsg struct {
+	Username string `json:"username"`
+	ClientId string `json:"client_id"`
+	Topic    string `json:"topic"`
+	Payload  string `json:"payload"`
+}
+
+type DeliverResponse struct {
+	Result    string           `json:"result" example:"ok" default:"ok"`
+	Modifiers DeliverModifiers `json:"modifiers"`
+}
+
+type DeliverModifiers struct {
+	Topic string `json:"topic"`
+}
+
 type OnlineWebhookMsg struct {
 	ClientId string `json:"client_id"`
 }
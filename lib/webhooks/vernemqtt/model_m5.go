@@ -0,0 +1,131 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vernemqtt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+)
+
+type LoginM5WebhookMsg struct {
+	PeerAddr   string       `json:"peer_addr"`
+	PeerPort   int          `json:"peer_port"`
+	Username   string       `json:"username"`
+	Password   string       `json:"password"`
+	ClientId   string       `json:"client_id"`
+	CleanStart bool         `json:"clean_start"`
+	Properties PropertiesM5 `json:"properties"`
+}
+
+type PublishM5WebhookMsg struct {
+	Username   string       `json:"username"`
+	ClientId   string       `json:"client_id"`
+	Topic      string       `json:"topic"`
+	Payload    string       `json:"payload"`
+	Qos        int          `json:"qos"`
+	Properties PropertiesM5 `json:"properties"`
+}
+
+type SubscribeM5WebhookMsg struct {
+	Username   string            `json:"username"`
+	ClientId   string            `json:"client_id"`
+	Topics     []WebhookmsgTopic `json:"topics"`
+	Properties PropertiesM5      `json:"properties"`
+}
+
+// PropertiesM5 are the mqtt v5 properties sent by vernemqtt; unknown properties are ignored
+type PropertiesM5 struct {
+	SessionExpiryInterval *int64         `json:"p_session_expiry_interval,omitempty"`
+	ContentType           string         `json:"p_content_type,omitempty"`
+	PayloadFormat         string         `json:"p_payload_format_indicator,omitempty"`
+	UserProperties        UserProperties `json:"p_user_property,omitempty"`
+	ReasonString          string         `json:"p_reason_string,omitempty"`
+}
+
+// CleanSession returns true if the session ends with the connection (no or zero session expiry interval)
+func (this PropertiesM5) CleanSession() bool {
+	return this.SessionExpiryInterval == nil || *this.SessionExpiryInterval == 0
+}
+
+// Message returns the content type and user properties forwarded with published messages
+func (this PropertiesM5) Message() (result message.Properties) {
+	result.ContentType = this.ContentType
+	for _, property := range this.UserProperties {
+		result.UserProperties = append(result.UserProperties, message.UserProperty{Key: property.Key, Value: property.Value})
+	}
+	return result
+}
+
+type UserProperty struct {
+	Key   string `json:"k"`
+	Value string `json:"v"`
+}
+
+// UserProperties accepts lists of key-value pairs ([["k","v"]]), lists of objects ([{"k":"k","v":"v"}]) and plain objects ({"k":"v"})
+type UserProperties []UserProperty
+
+func (this *UserProperties) UnmarshalJSON(b []byte) error {
+	var pairs [][]string
+	if err := json.Unmarshal(b, &pairs); err == nil {
+		for _, pair := range pairs {
+			if len(pair) != 2 {
+				return fmt.Errorf("invalid user property %v", pair)
+			}
+			*this = append(*this, UserProperty{Key: pair[0], Value: pair[1]})
+		}
+		return nil
+	}
+	var list []UserProperty
+	if err := json.Unmarshal(b, &list); err == nil {
+		*this = append(*this, list...)
+		return nil
+	}
+	var object map[string]string
+	err := json.Unmarshal(b, &object)
+	if err != nil {
+		return err
+	}
+	for k, v := range object {
+		*this = append(*this, UserProperty{Key: k, Value: v})
+	}
+	return nil
+}
+
+type ErrorM5Response struct {
+	Result ErrorM5ResponseResult `json:"result"`
+}
+
+type ErrorM5ResponseResult struct {
+	Error ErrorM5 `json:"error"`
+}
+
+type ErrorM5 struct {
+	ReasonCode   string `json:"reason_code" example:"not_authorized"`
+	ReasonString string `json:"reason_string,omitempty"`
+}
+
+type SubscribeM5WebhookResult struct {
+	Result    string             `json:"result" example:"ok" default:"ok"`
+	Modifiers SubscribeModifiers `json:"modifiers"`
+}
+
+type SubscribeModifiers struct {
+	Topics     []WebhookmsgTopic `json:"topics"`
+	Properties *PropertiesM5     `json:"properties,omitempty"`
+}
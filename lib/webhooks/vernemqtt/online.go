@@ -23,7 +23,7 @@ import (
 	"runtime/debug"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // online godoc
@@ -35,7 +35,7 @@ import (
 // @Success      200 {object} EmptyResponse
 // @Failure      400 {object} ErrorResponse
 // @Router       /online [POST]
-func online(writer http.ResponseWriter, request *http.Request, config configuration.Config, connectionLog connectionlog.ConnectionLog) {
+func online(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
 	defer func() {
 		if p := recover(); p != nil {
 			if config.Debug {
@@ -54,5 +54,5 @@ func online(writer http.ResponseWriter, request *http.Request, config configurat
 		return
 	}
 	config.GetLogger().Debug("/online", "msg", fmt.Sprintf("%#v", msg))
-	connectionLog.Connect(msg.ClientId)
+	platform.ClientOnline(hooks.OnlineRequest{ClientId: msg.ClientId})
 }
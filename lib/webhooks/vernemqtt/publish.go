@@ -19,16 +19,12 @@ package vernemqtt
 import (
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
-	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
-	"github.com/SENERGY-Platform/platform-connector-lib/model"
-	"github.com/SENERGY-Platform/platform-connector-lib/statistics"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // publish godoc
@@ -41,13 +37,12 @@ import (
 // @Success      201 {object}  RedirectResponse
 // @Failure      400 {object}  ErrorResponse
 // @Router       /publish [POST]
-func publish(writer http.ResponseWriter, request *http.Request, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic) {
+func publish(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
 	buf, err := io.ReadAll(request.Body)
 	if err != nil {
 		sendError(writer, err.Error(), true)
 		return
 	}
-	msgSize := float64(len(buf))
 	msg := PublishWebhookMsg{}
 	err = json.Unmarshal(buf, &msg)
 	if err != nil {
@@ -55,51 +50,33 @@ func publish(writer http.ResponseWriter, request *http.Request, config configura
 		sendError(writer, err.Error(), config.Debug)
 		return
 	}
-	if msg.Username != config.AuthClientId {
-		payload, err := base64.StdEncoding.DecodeString(msg.Payload)
-		if err != nil {
-			config.GetLogger().Error("unable to decode base64 encoded payload", "error", err)
-			sendError(writer, err.Error(), config.Debug)
-			return
-		}
-		statistics.SourceReceive(msgSize, msg.Username)
-		token, err := connector.Security().GetCachedUserToken(msg.Username, model.RemoteInfo{})
-		if err != nil {
-			config.GetLogger().Error("unable to get user token", "error", err, "username", msg.Username)
-			sendError(writer, err.Error(), config.Debug)
-			return
-		}
-
-		device, service, err := topicParser.Parse(token, msg.Topic)
-		if errors.Is(err, topic.ErrNoDeviceIdCandidateFound) || errors.Is(err, topic.ErrNoDeviceMatchFound) {
-			sendIgnoreRedirect(writer, msg.Topic, msg.Payload)
-			return
-		}
-		if errors.Is(err, topic.ErrNoServiceMatchFound) {
-			TryCreateService(config, connector, device, msg.Topic, payload)
-			return
-		}
-		if err != nil {
-			config.GetLogger().Error("unable to parse topic", "error", err, "topic", msg.Topic)
-			sendError(writer, err.Error(), config.Debug)
-			return
-		}
-		var info platform_connector_lib.HandledDeviceInfo
-		info, err = connector.HandleDeviceIdentEventWithAuthToken(token, device.Id, device.LocalId, service.Id, service.LocalId, map[string]string{
-			"payload": string(payload),
-		}, platform_connector_lib.Qos(msg.Qos))
-		if info.DeviceId != "" && info.DeviceTypeId != "" {
-			statistics.DeviceMsgReceive(msgSize, msg.Username, info.DeviceId, info.DeviceTypeId, info.ServiceIds)
-		}
-		if err != nil {
-			config.GetLogger().Error("unable to handle device ident event", "error", err, "device", device.Id, "service", service.Id, "device-local-id", device.LocalId, "service-local-id", service.LocalId, "topic", msg.Topic)
-			fmt.Fprintf(writer, `{"result": "ok"}`)
-			return
-		}
-		statistics.SourceReceiveHandled(msgSize, msg.Username)
-		statistics.DeviceMsgHandled(msgSize, msg.Username, info.DeviceId, info.DeviceTypeId, info.ServiceIds)
-		sendWithPrefixRedirect(writer, device.Id, msg.Topic, msg.Payload)
-	} else {
+	payload, err := base64.StdEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		config.GetLogger().Error("unable to decode base64 encoded payload", "error", err)
+		sendError(writer, err.Error(), config.Debug)
+		return
+	}
+	req := hooks.PublishRequest{
+		Username: msg.Username,
+		ClientId: msg.ClientId,
+		Topic:    msg.Topic,
+		Payload:  payload,
+		Qos:      msg.Qos,
+		Size:     len(buf),
+	}
+	decision, err := platform.HandlePublish(req)
+	if err != nil {
+		sendError(writer, err.Error(), config.Debug)
+		return
+	}
+	switch {
+	case decision.Verdict == hooks.Ignore:
+		sendIgnoreRedirect(writer, msg.Topic, msg.Payload)
+	case decision.Verdict == hooks.Deny:
+		sendError(writer, decision.Reason, config.Debug)
+	case decision.Forwarded || decision.Rewritten(req):
+		sendRedirect(writer, decision.Topic, msg.Payload)
+	default:
 		fmt.Fprintf(writer, `{"result": "ok"}`)
 	}
 }
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vernemqtt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// publishM5 godoc
+// @Summary      auth_on_publish_m5 webhook
+// @Description  checks auth for the published mqtt v5 message and forwards it with its content type and user properties to kafka; denied messages respond with a v5 reason code; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format
+// @Accept       json
+// @Produce      json
+// @Param        message body PublishM5WebhookMsg true "publish message"
+// @Success      200 {object}  OkResponse
+// @Success      201 {object}  RedirectResponse
+// @Failure      400 {object}  ErrorM5Response
+// @Router       /publish_m5 [POST]
+func publishM5(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	buf, err := io.ReadAll(request.Body)
+	if err != nil {
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), true)
+		return
+	}
+	msg := PublishM5WebhookMsg{}
+	err = json.Unmarshal(buf, &msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode publish_m5 webhook message", "error", err)
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+	payload, err := base64.StdEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		config.GetLogger().Error("unable to decode base64 encoded payload", "error", err)
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+	config.GetLogger().Debug("/publish_m5", "topic", msg.Topic, "content-type", msg.Properties.ContentType, "user-properties", fmt.Sprint(msg.Properties.UserProperties))
+	req := hooks.PublishRequest{
+		Username:   msg.Username,
+		ClientId:   msg.ClientId,
+		Topic:      msg.Topic,
+		Payload:    payload,
+		Qos:        msg.Qos,
+		Size:       len(buf),
+		Properties: msg.Properties.Message(),
+	}
+	decision, err := platform.HandlePublish(req)
+	if err != nil {
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+	switch {
+	case decision.Verdict == hooks.Ignore:
+		sendIgnoreRedirect(writer, msg.Topic, msg.Payload)
+	case decision.Verdict == hooks.Deny:
+		sendErrorM5(writer, decision.ReasonCode, decision.Reason, config.Debug)
+	case decision.Forwarded || decision.Rewritten(req):
+		sendRedirect(writer, decision.Topic, msg.Payload)
+	default:
+		fmt.Fprintf(writer, `{"result": "ok"}`)
+	}
+}
@@ -18,16 +18,11 @@ package vernemqtt
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
-	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
-	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // subscribe godoc
@@ -40,7 +35,7 @@ import (
 // @Success      201 {object}  SubscribeWebhookResult
 // @Failure      400 {object}  ErrorResponse
 // @Router       /subscribe [POST]
-func subscribe(writer http.ResponseWriter, request *http.Request, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+func subscribe(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
 	//{"username":"sepl","mountpoint":"","client_id":"sepl_mqtt_connector_1","topics":[{"topic":"$share/sepl_mqtt_connector/#","qos":2}]}
 	msg := SubscribeWebhookMsg{}
 	err := json.NewDecoder(request.Body).Decode(&msg)
@@ -49,37 +44,24 @@ func subscribe(writer http.ResponseWriter, request *http.Request, config configu
 		sendError(writer, err.Error(), config.Debug)
 		return
 	}
+	req := hooks.SubscribeRequest{Username: msg.Username, ClientId: msg.ClientId}
+	for _, t := range msg.Topics {
+		req.Topics = append(req.Topics, hooks.TopicRequest{Topic: t.Topic, Qos: int(t.Qos)})
+	}
+	decision, err := platform.AuthorizeSubscribe(req)
+	if err != nil {
+		sendError(writer, err.Error(), config.Debug)
+		return
+	}
+	platform.Subscribed(msg.ClientId, decision)
 	resultTopics := []WebhookmsgTopic{}
-	if msg.Username != config.AuthClientId {
-		token, err := connector.Security().GetCachedUserToken(msg.Username, model.RemoteInfo{})
-		if err != nil {
-			sendError(writer, err.Error(), config.Debug)
-			return
-		}
-		for _, mqtttopic := range msg.Topics {
-			device, _, err := topicParser.Parse(token, mqtttopic.Topic)
-			if errors.Is(err, topic.ErrNoServiceMatchFound) {
-				//we want to only check device access
-				err = nil
-			}
-			if err == nil {
-				connectionLog.Subscribe(msg.ClientId, mqtttopic.Topic, device.Id)
-			}
-			if errors.Is(err, topic.ErrMultipleMatchingDevicesFound) || errors.Is(err, topic.ErrNoDeviceMatchFound) || errors.Is(err, topic.ErrNoDeviceIdCandidateFound) {
-				//no err but disallow subscription
-				mqtttopic.Qos = 128
-				err = nil
-			}
-			if err != nil && !errors.Is(err, topic.ErrNoDeviceIdCandidateFound) {
-				config.GetLogger().Warn("unable to parse topic", "error", err, "topic", mqtttopic.Topic)
-				sendError(writer, err.Error(), config.Debug)
-				return
-			}
-			prefix := device.Id + "/"
-			if !strings.HasPrefix(mqtttopic.Topic, prefix) {
-				mqtttopic.Topic = prefix + mqtttopic.Topic
+	if !decision.Superuser {
+		for _, t := range decision.Topics {
+			resultTopic := WebhookmsgTopic{Topic: t.Topic, Qos: int64(t.Qos)}
+			if t.Verdict != hooks.Allow {
+				resultTopic.Qos = 128
 			}
-			resultTopics = append(resultTopics, mqtttopic)
+			resultTopics = append(resultTopics, resultTopic)
 		}
 	}
 	config.GetLogger().Debug("/subscribe", "msg", fmt.Sprintf("%#v", msg), "response", fmt.Sprintf("%#v", resultTopics))
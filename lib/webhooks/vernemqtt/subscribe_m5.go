@@ -0,0 +1,76 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vernemqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
+)
+
+// subscribeM5 godoc
+// @Summary      auth_on_subscribe_m5 webhook
+// @Description  checks auth for the mqtt v5 subscription; SubscribeModifiers.Topics.Qos is the granted qos or, for rejected topics, a v5 reason code (135=not authorized, 143=topic filter invalid), the reasons are joined in SubscribeModifiers.Properties.ReasonString; all responses are with code=200, differences in swagger doc are because of technical incompatibilities of the documentation format
+// @Accept       json
+// @Produce      json
+// @Param        message body SubscribeM5WebhookMsg true "subscription message"
+// @Success      200 {object}  SubscribeM5WebhookResult
+// @Failure      400 {object}  ErrorM5Response
+// @Router       /subscribe_m5 [POST]
+func subscribeM5(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
+	msg := SubscribeM5WebhookMsg{}
+	err := json.NewDecoder(request.Body).Decode(&msg)
+	if err != nil {
+		config.GetLogger().Error("unable to decode subscribe_m5 webhook msg", "error", err)
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+	req := hooks.SubscribeRequest{Username: msg.Username, ClientId: msg.ClientId}
+	for _, t := range msg.Topics {
+		req.Topics = append(req.Topics, hooks.TopicRequest{Topic: t.Topic, Qos: int(t.Qos)})
+	}
+	decision, err := platform.AuthorizeSubscribe(req)
+	if err != nil {
+		sendErrorM5(writer, hooks.ReasonUnspecifiedError, err.Error(), config.Debug)
+		return
+	}
+	platform.Subscribed(msg.ClientId, decision)
+	result := SubscribeM5WebhookResult{Result: "ok", Modifiers: SubscribeModifiers{Topics: []WebhookmsgTopic{}}}
+	if !decision.Superuser {
+		reasons := []string{}
+		for _, t := range decision.Topics {
+			resultTopic := WebhookmsgTopic{Topic: t.Topic, Qos: int64(t.Qos)}
+			if t.Verdict != hooks.Allow {
+				resultTopic.Qos = int64(t.ReasonCode)
+				reasons = append(reasons, t.RequestedTopic+": "+t.Reason)
+			}
+			result.Modifiers.Topics = append(result.Modifiers.Topics, resultTopic)
+		}
+		if len(reasons) > 0 {
+			result.Modifiers.Properties = &PropertiesM5{ReasonString: strings.Join(reasons, "; ")}
+		}
+	}
+	config.GetLogger().Debug("/subscribe_m5", "msg", fmt.Sprintf("%#v", msg), "response", fmt.Sprintf("%#v", result))
+	err = json.NewEncoder(writer).Encode(result)
+	if err != nil {
+		config.GetLogger().Error("unable to encode subscribe_m5 webhook result", "error", err)
+	}
+}
@@ -23,10 +23,7 @@ import (
 	"runtime/debug"
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
-	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
-	"github.com/SENERGY-Platform/platform-connector-lib/model"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 )
 
 // unsubscribe godoc
@@ -38,7 +35,7 @@ import (
 // @Success      200 {object} UnsubResponse
 // @Failure      400 {object} ErrorResponse
 // @Router       /unsubscribe [POST]
-func unsubscribe(writer http.ResponseWriter, request *http.Request, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+func unsubscribe(writer http.ResponseWriter, request *http.Request, config configuration.Config, platform hooks.Hooks) {
 	defer func() {
 		if p := recover(); p != nil {
 			if config.Debug {
@@ -57,19 +54,5 @@ func unsubscribe(writer http.ResponseWriter, request *http.Request, config confi
 	config.GetLogger().Debug("unsubscribe", "msg", fmt.Sprintf("%#v", msg))
 	//defer json.NewEncoder(writer).Encode(map[string]interface{}{"result": "ok", "topics": msg.Topics})
 	defer json.NewEncoder(writer).Encode(UnsubResponse{Result: "ok", Topics: msg.Topics})
-	if msg.Username != config.AuthClientId {
-		token, err := connector.Security().GetCachedUserToken(msg.Username, model.RemoteInfo{})
-		if err != nil {
-			config.GetLogger().Error("unable to get user token", "error", err, "username", msg.Username)
-			return
-		}
-		for _, topic := range msg.Topics {
-			device, _, err := topicParser.Parse(token, topic)
-			if err != nil {
-				config.GetLogger().Error("unable to parse topic", "error", err, "topic", topic)
-				return
-			}
-			connectionLog.Unsubscribe(msg.ClientId, topic, device.Id)
-		}
-	}
+	_ = platform.Unsubscribe(hooks.UnsubscribeRequest{Username: msg.Username, ClientId: msg.ClientId, Topics: msg.Topics})
 }
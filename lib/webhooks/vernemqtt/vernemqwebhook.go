@@ -28,7 +28,11 @@ import (
 
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/explain"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/lint"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/validate"
 	"github.com/SENERGY-Platform/platform-connector-lib"
 	"github.com/swaggo/swag"
 )
@@ -42,8 +46,8 @@ import (
 // @license.name  Apache 2.0
 // @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
 // @BasePath  /
-func InitWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, connectionLog connectionlog.ConnectionLog) {
-	topicParser := topic.New(connector.IotCache, config.ActuatorTopicPattern)
+func InitWebhooks(ctx context.Context, config configuration.Config, connector *platform_connector_lib.Connector, topicParser *topic.Topic, connectionLog connectionlog.ConnectionLog) {
+	platform := hooks.NewFromConnector(config, connector, topicParser, connectionLog)
 	router := http.NewServeMux()
 
 	logger := config.GetLogger()
@@ -65,29 +69,49 @@ func InitWebhooks(ctx context.Context, config configuration.Config, connector *p
 	})
 
 	router.HandleFunc("/login", func(writer http.ResponseWriter, request *http.Request) {
-		login(writer, request, config, connector, connectionLog, logger)
+		login(writer, request, config, platform, logger)
 	})
 
 	router.HandleFunc("/online", func(writer http.ResponseWriter, request *http.Request) {
-		online(writer, request, config, connectionLog)
+		online(writer, request, config, platform)
 	})
 
 	router.HandleFunc("/disconnect", func(writer http.ResponseWriter, request *http.Request) {
-		disconnect(writer, request, config, connectionLog, logger)
+		disconnect(writer, request, config, platform, logger)
 	})
 
 	router.HandleFunc("/publish", func(writer http.ResponseWriter, request *http.Request) {
-		publish(writer, request, config, connector, topicParser)
+		publish(writer, request, config, platform)
 	})
 
 	router.HandleFunc("/subscribe", func(writer http.ResponseWriter, request *http.Request) {
-		subscribe(writer, request, config, connector, topicParser, connectionLog)
+		subscribe(writer, request, config, platform)
+	})
+
+	router.HandleFunc("/login_m5", func(writer http.ResponseWriter, request *http.Request) {
+		loginM5(writer, request, config, platform, logger)
+	})
+
+	router.HandleFunc("/publish_m5", func(writer http.ResponseWriter, request *http.Request) {
+		publishM5(writer, request, config, platform)
+	})
+
+	router.HandleFunc("/subscribe_m5", func(writer http.ResponseWriter, request *http.Request) {
+		subscribeM5(writer, request, config, platform)
+	})
+
+	router.HandleFunc("/deliver", func(writer http.ResponseWriter, request *http.Request) {
+		deliver(writer, request, config, platform)
 	})
 
 	router.HandleFunc("/unsubscribe", func(writer http.ResponseWriter, request *http.Request) {
-		unsubscribe(writer, request, config, connector, topicParser, connectionLog)
+		unsubscribe(writer, request, config, platform)
 	})
 
+	explain.InitEndpoint(config, router, platform)
+	validate.InitEndpoint(config, router)
+	lint.InitEndpoints(config, router, topicParser)
+
 	var handler http.Handler = router
 	if config.Debug {
 		handler = Logger(router)
@@ -18,6 +18,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"log"
 	"net/http"
@@ -30,9 +32,19 @@ import (
 	"github.com/SENERGY-Platform/mqtt-platform-connector/docs"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/platform-connector-lib/security"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		explain(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		lint(os.Args[2:])
+		return
+	}
+
 	time.Sleep(5 * time.Second) //wait for routing tables in cluster
 
 	configLocation := flag.String("config", "config.json", "configuration file")
@@ -65,6 +77,54 @@ func main() {
 	time.Sleep(1 * time.Second) //let time for ctx.Done() listener
 }
 
+// explain prints the explanation of lib.Explain as json
+// usage: mqtt-platform-connector explain -config config.json -user <username> -topic <topic>
+func explain(args []string) {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	username := flags.String("user", "", "username of the publishing client")
+	token := flags.String("token", "", "token of the publishing client; used instead of -user")
+	mqttTopic := flags.String("topic", "", "published topic")
+	runSubcommand(flags, args, func(config configuration.Config) (any, error) {
+		return lib.Explain(config, *username, security.JwtToken(*token), *mqttTopic)
+	})
+}
+
+// lint prints the analyses of lib.Lint as json
+// usage: mqtt-platform-connector lint -config config.json (-device-type <device-type-id> | -protocol <protocol-id>)
+func lint(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	deviceTypeId := flags.String("device-type", "", "id of the analysed device-type")
+	protocolId := flags.String("protocol", "", "id of the protocol; all device-types with services of the protocol are analysed")
+	token := flags.String("token", "", "token used to read device-types; default is the internal admin token")
+	runSubcommand(flags, args, func(config configuration.Config) (any, error) {
+		if (*deviceTypeId == "") == (*protocolId == "") {
+			return nil, errors.New("expect either -device-type or -protocol")
+		}
+		return lib.Lint(config, security.JwtToken(*token), *deviceTypeId, *protocolId)
+	})
+}
+
+// runSubcommand adds the -config flag, parses args and prints the result of run as json; errors are fatal
+func runSubcommand(flags *flag.FlagSet, args []string, run func(config configuration.Config) (any, error)) {
+	configLocation := flags.String("config", "config.json", "configuration file")
+	_ = flags.Parse(args)
+
+	config, err := configuration.Load(*configLocation)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := run(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "    ")
+	err = encoder.Encode(result)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func PublishAsyncApiDoc(conf configuration.Config) error {
 	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
 	return client.New(http.DefaultClient, conf.ApiDocsProviderBaseUrl).AsyncapiPutDoc(ctx, "github_com_SENERGY-Platform_mqtt-platform-connector", docs.AsyncApiDoc)
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/association"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/server/docker"
+)
+
+// TestPostgresAssociationReplicas checks that associations stored or removed by one instance become visible to another
+func TestPostgresAssociationReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conStr, err := docker.Postgres(ctx, wg, "associations")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expiration := association.Expiration
+	association.Expiration = 200 * time.Millisecond
+	defer func() { association.Expiration = expiration }()
+
+	a, err := association.NewPostgres(conStr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := association.NewPostgres(conStr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("read in background on miss", func(t *testing.T) {
+		err = a.Set("d1", "s1", "d1/foo")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		start := time.Now()
+		b.Get("d1", "s1")
+		if time.Since(start) > 10*time.Millisecond {
+			t.Error("get should not wait for the database", time.Since(start))
+		}
+		if topic, ok := getEventually(b, "d1", "s1", true); !ok || topic != "d1/foo" {
+			t.Error(topic, ok)
+		}
+	})
+
+	t.Run("refresh after expiration", func(t *testing.T) {
+		err = a.Set("d1", "s2", "d1/bar")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		time.Sleep(2 * association.Expiration)
+		if topic, ok := getEventually(b, "d1", "s2", true); !ok || topic != "d1/bar" {
+			t.Error(topic, ok)
+		}
+	})
+
+	t.Run("remove associations stored by other instance", func(t *testing.T) {
+		err = a.Set("d2", "s1", "d2/foo")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		//b does not mirror d2
+		err = b.RemoveService("s1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = b.RemoveDevice("d2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		time.Sleep(2 * association.Expiration)
+		if _, ok := getEventually(a, "d2", "s1", false); ok {
+			t.Error("association of removed device")
+		}
+		if _, ok := getEventually(a, "d1", "s1", false); ok {
+			t.Error("association of removed service")
+		}
+		if topic, ok := getEventually(a, "d1", "s2", true); !ok || topic != "d1/bar" {
+			t.Error(topic, ok)
+		}
+	})
+}
+
+// getEventually repeats Get until the association is found as expected, because missing and expired devices are read in the background
+func getEventually(store *association.Postgres, deviceId string, serviceId string, expectFound bool) (topic string, found bool) {
+	for i := 0; i < 20; i++ {
+		topic, found = store.Get(deviceId, serviceId)
+		if found == expectFound {
+			return topic, found
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return topic, found
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/message"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestCommandProperties(t *testing.T) {
+	request := model.ProtocolMsg{
+		Request: model.ProtocolRequest{Input: map[string]string{"payload": "{}", "qos": "1", "header": "h"}},
+		Metadata: model.Metadata{
+			Device:   model.Device{Id: longDeviceIdExample},
+			Service:  model.Service{Id: "service", Inputs: []models.Content{{ProtocolSegmentId: "header-segment", Serialization: models.PlainText}, {ProtocolSegmentId: "payload-segment", Serialization: models.JSON}}},
+			Protocol: model.Protocol{ProtocolSegments: []models.ProtocolSegment{{Id: "header-segment", Name: "header"}, {Id: "payload-segment", Name: "payload"}}},
+		},
+	}
+	pending := topic.PendingCommand{CorrelationId: "task", ResponseTopic: longDeviceIdExample + "/cmd/resp"}
+
+	properties, err := lib.CommandProperties(request, pending, time.Minute, time.Now().Add(-30*time.Second))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if properties.ResponseTopic != pending.ResponseTopic || string(properties.CorrelationData) != "task" || properties.ContentType != "application/json" {
+		t.Errorf("%#v", properties)
+	}
+	if properties.MessageExpiry == nil || *properties.MessageExpiry > 30 || *properties.MessageExpiry < 29 {
+		t.Errorf("%#v", properties.MessageExpiry)
+	}
+	expectedUserProperties := []message.UserProperty{
+		{Key: "device_id", Value: longDeviceIdExample},
+		{Key: "service_id", Value: "service"},
+		{Key: "header", Value: "h"},
+		{Key: "qos", Value: "1"},
+	}
+	if !reflect.DeepEqual(properties.UserProperties, expectedUserProperties) {
+		t.Errorf("%#v", properties.UserProperties)
+	}
+
+	//without pending response, ttl and protocol
+	request.Metadata.Protocol = model.Protocol{}
+	properties, err = lib.CommandProperties(request, topic.PendingCommand{}, 0, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if properties.ResponseTopic != "" || properties.CorrelationData != nil || properties.MessageExpiry != nil || properties.ContentType != "text/plain" {
+		t.Errorf("%#v", properties)
+	}
+
+	_, err = lib.CommandProperties(request, pending, time.Minute, time.Now().Add(-time.Hour))
+	if !errors.Is(err, lib.ErrCommandExpired) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/platform-connector-lib"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestShardCommandHandlerOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deviceCount := 10
+	commandCount := 50
+
+	mux := sync.Mutex{}
+	received := map[string][]int{}
+	wg := sync.WaitGroup{}
+	wg.Add(deviceCount * commandCount)
+	handler := lib.ShardCommandHandler(ctx, configuration.Config{CommandWorkerCount: 4}, func(commandRequest model.ProtocolMsg, requestMsg platform_connector_lib.CommandRequestMsg, t time.Time) error {
+		defer wg.Done()
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		index, _ := strconv.Atoi(commandRequest.Request.Input["payload"])
+		mux.Lock()
+		defer mux.Unlock()
+		received[commandRequest.Metadata.Device.Id] = append(received[commandRequest.Metadata.Device.Id], index)
+		return nil
+	})
+
+	for i := 0; i < commandCount; i++ {
+		for d := 0; d < deviceCount; d++ {
+			err := handler(model.ProtocolMsg{
+				Request:  model.ProtocolRequest{Input: map[string]string{"payload": strconv.Itoa(i)}},
+				Metadata: model.Metadata{Device: model.Device{Id: "device-" + strconv.Itoa(d)}},
+			}, nil, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	wg.Wait()
+
+	mux.Lock()
+	defer mux.Unlock()
+	if len(received) != deviceCount {
+		t.Error(len(received))
+	}
+	for device, indexes := range received {
+		if len(indexes) != commandCount {
+			t.Error(device, len(indexes))
+		}
+		for i, index := range indexes {
+			if i != index {
+				t.Error("unexpected command order", device, indexes)
+				break
+			}
+		}
+	}
+}
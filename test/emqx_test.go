@@ -0,0 +1,159 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/emqx"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/server"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestEmqxHttpAuth(t *testing.T) {
+	defaultConfig, err := configuration.Load("../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defaultConfig.InitTopics = true
+	defaultConfig.MqttAuthMethod = "password"
+	defaultConfig.BrokerFlavour = "emqx"
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, _, err := server.New(ctx, wg, defaultConfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	connector, err := lib.NewConnector(config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	emqx.InitWebhooks(ctx, config, connector, topic.New(connector.IotCache, config.ActuatorTopicPattern), connectionlog.Void)
+
+	time.Sleep(1 * time.Second)
+
+	backend := "http://localhost:" + config.WebhookPort
+	deviceLocalId := "emqx_device"
+	serviceLocalId := "sensor"
+	serviceId := "urn:infai:ses:service:7d1c3f8e-2b4a-4e5d-8c6f-1a2b3c4d5e6f"
+	device := model.Device{}
+
+	t.Run("create device", func(t *testing.T) {
+		protocol := createTestProtocol(t, config)
+		time.Sleep(10 * time.Second) //wait for cqrs
+		deviceType := createTestDeviceType(t, config, protocol, serviceLocalId, serviceId)
+		time.Sleep(10 * time.Second) //wait for cqrs
+		device = createTestDevice(t, config, deviceType, deviceLocalId, "")
+		time.Sleep(10 * time.Second) //wait for cqrs
+	})
+
+	t.Run("authn", func(t *testing.T) {
+		testEmqxAuthn(t, backend, emqx.AuthnMsg{Username: "sepl", Password: "sepl", ClientId: "c1"}, emqx.ResultAllow, false)
+		testEmqxAuthn(t, backend, emqx.AuthnMsg{Username: config.AuthClientId, Password: config.AuthClientSecret, ClientId: "c2"}, emqx.ResultAllow, true)
+		testEmqxAuthn(t, backend, emqx.AuthnMsg{Username: config.AuthClientId, Password: "wrong", ClientId: "c2"}, emqx.ResultDeny, false)
+	})
+
+	t.Run("authz", func(t *testing.T) {
+		testEmqxAuthz(t, backend, emqx.AuthzMsg{Username: "sepl", ClientId: "c1", Topic: device.Id + "/" + serviceLocalId, Action: emqx.ActionPublish}, emqx.ResultAllow)
+		testEmqxAuthz(t, backend, emqx.AuthzMsg{Username: "sepl", ClientId: "c1", Topic: device.Id + "/cmnd/#", Action: emqx.ActionSubscribe}, emqx.ResultAllow)
+		testEmqxAuthz(t, backend, emqx.AuthzMsg{Username: "sepl", ClientId: "c1", Topic: "cmnd/" + device.Id + "/#", Action: emqx.ActionSubscribe}, emqx.ResultDeny)
+		testEmqxAuthz(t, backend, emqx.AuthzMsg{Username: "sepl", ClientId: "c1", Topic: "unknown/" + serviceLocalId, Action: emqx.ActionPublish}, emqx.ResultDeny)
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		for _, msg := range []emqx.WebhookMsg{
+			{Event: emqx.EventClientConnected, ClientId: "c1", Username: "sepl", CleanStart: true},
+			{Event: emqx.EventSessionSubscribed, ClientId: "c1", Username: "sepl", Topic: device.Id + "/cmnd/#"},
+			{Event: emqx.EventMessagePublish, ClientId: "c1", Username: "sepl", Topic: device.Id + "/" + serviceLocalId, Payload: `{"level":42}`, Qos: 1},
+			{Event: emqx.EventClientDisconnected, ClientId: "c1", Username: "sepl", Reason: "normal"},
+		} {
+			body, err := json.Marshal(msg)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp, err := http.Post(backend+"/webhook", "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Error(msg.Event, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func testEmqxAuthn(t *testing.T, backend string, msg emqx.AuthnMsg, expectedResult string, expectedSuperuser bool) {
+	t.Helper()
+	result := emqx.AuthnResponse{}
+	testEmqxPost(t, backend+"/authn", msg, &result)
+	if result.Result != expectedResult || result.IsSuperuser != expectedSuperuser {
+		t.Error(msg, result)
+	}
+}
+
+func testEmqxAuthz(t *testing.T, backend string, msg emqx.AuthzMsg, expectedResult string) {
+	t.Helper()
+	result := emqx.AuthzResponse{}
+	testEmqxPost(t, backend+"/authz", msg, &result)
+	if result.Result != expectedResult {
+		t.Error(msg, result)
+	}
+}
+
+func testEmqxPost(t *testing.T, endpoint string, msg interface{}, result interface{}) {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp, err := http.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Error(endpoint, resp.StatusCode)
+		return
+	}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/connectionlog"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/mosquitto"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/server"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestMosquittoGoAuth(t *testing.T) {
+	defaultConfig, err := configuration.Load("../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defaultConfig.InitTopics = true
+	defaultConfig.MqttAuthMethod = "password"
+	defaultConfig.BrokerFlavour = "mosquitto"
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, _, err := server.New(ctx, wg, defaultConfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	connector, err := lib.NewConnector(config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	mosquitto.InitWebhooks(ctx, config, connector, topic.New(connector.IotCache, config.ActuatorTopicPattern), connectionlog.Void)
+
+	time.Sleep(1 * time.Second)
+
+	backend := "http://localhost:" + config.WebhookPort
+	deviceLocalId := "mosquitto_device"
+	serviceLocalId := "sensor"
+	serviceId := "urn:infai:ses:service:5b3e2a6e-0a5d-4c2b-9a0f-3a6f3f0c7f11"
+	device := model.Device{}
+
+	t.Run("create device", func(t *testing.T) {
+		protocol := createTestProtocol(t, config)
+		time.Sleep(10 * time.Second) //wait for cqrs
+		deviceType := createTestDeviceType(t, config, protocol, serviceLocalId, serviceId)
+		time.Sleep(10 * time.Second) //wait for cqrs
+		device = createTestDevice(t, config, deviceType, deviceLocalId, "")
+		time.Sleep(10 * time.Second) //wait for cqrs
+	})
+
+	t.Run("user", func(t *testing.T) {
+		testMosquittoCheck(t, backend+"/user", mosquitto.UserMsg{Username: "sepl", Password: "sepl", ClientId: "c1"}, true)
+		testMosquittoCheck(t, backend+"/user", mosquitto.UserMsg{Username: config.AuthClientId, Password: "wrong", ClientId: "c2"}, false)
+	})
+
+	t.Run("superuser", func(t *testing.T) {
+		testMosquittoCheck(t, backend+"/superuser", mosquitto.SuperuserMsg{Username: config.AuthClientId}, true)
+		testMosquittoCheck(t, backend+"/superuser", mosquitto.SuperuserMsg{Username: "sepl"}, false)
+	})
+
+	t.Run("acl", func(t *testing.T) {
+		testMosquittoCheck(t, backend+"/acl", mosquitto.AclMsg{Username: "sepl", ClientId: "c1", Topic: device.Id + "/" + serviceLocalId, Acc: mosquitto.AccWrite}, true)
+		testMosquittoCheck(t, backend+"/acl", mosquitto.AclMsg{Username: "sepl", ClientId: "c1", Topic: device.Id + "/cmd/#", Acc: mosquitto.AccSubscribe}, true)
+		testMosquittoCheck(t, backend+"/acl", mosquitto.AclMsg{Username: "sepl", ClientId: "c1", Topic: "unknown/" + serviceLocalId, Acc: mosquitto.AccWrite}, false)
+	})
+
+	t.Run("acl form params", func(t *testing.T) {
+		form := url.Values{}
+		form.Set("username", "sepl")
+		form.Set("clientid", "c1")
+		form.Set("topic", device.Id+"/"+serviceLocalId)
+		form.Set("acc", "2")
+		resp, err := http.Post(backend+"/acl", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Error(resp.StatusCode)
+		}
+	})
+}
+
+func testMosquittoCheck(t *testing.T, endpoint string, msg interface{}, expectOk bool) {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp, err := http.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	result := mosquitto.Response{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Ok != expectOk || (resp.StatusCode == http.StatusOK) != expectOk {
+		t.Error(endpoint, string(body), resp.StatusCode, result)
+	}
+}
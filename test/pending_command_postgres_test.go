@@ -0,0 +1,138 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/pendingcommand"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/server/docker"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+// TestPostgresPendingCommandReplicas checks that commands registered by one instance are taken exactly once by another
+func TestPostgresPendingCommandReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conStr, err := docker.Postgres(ctx, wg, "pendingcommands")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	a, err := pendingcommand.NewPostgres(conStr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	b, err := pendingcommand.NewPostgres(conStr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	acceptAll := func(pendingcommand.Command) bool { return true }
+	request := model.ProtocolMsg{Metadata: model.Metadata{Device: model.Device{Id: "d1", OwnerId: "user"}}}
+
+	t.Run("take command of other instance", func(t *testing.T) {
+		err = a.Add(pendingcommand.Command{Id: "c1", CorrelationId: "task", ResponseTopic: "d1/resp", Request: request, Expires: time.Now().Add(time.Minute)}, []string{"d1/resp", "resp"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		command, found, err := b.Find("resp", acceptAll, false)
+		if err != nil || !found || command.Id != "c1" || command.Request.Metadata.Device.Id != "d1" {
+			t.Error(command, found, err)
+			return
+		}
+		command, found, err = b.Find("d1/resp", acceptAll, true)
+		if err != nil || !found || command.Id != "c1" {
+			t.Error(command, found, err)
+			return
+		}
+		_, found, err = a.Find("resp", acceptAll, true)
+		if err != nil || found {
+			t.Error("command taken twice", found, err)
+		}
+	})
+
+	t.Run("responses in command order", func(t *testing.T) {
+		for _, id := range []string{"c2", "c3"} {
+			err = a.Add(pendingcommand.Command{Id: id, CorrelationId: "task", ResponseTopic: "d1/resp", Request: request, Expires: time.Now().Add(time.Minute)}, []string{"d1/resp"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		for _, id := range []string{"c2", "c3"} {
+			command, found, err := b.Find("d1/resp", acceptAll, true)
+			if err != nil || !found || command.Id != id {
+				t.Error(id, command, found, err)
+			}
+		}
+	})
+
+	t.Run("cancel by id", func(t *testing.T) {
+		//commands of the same task share the correlation id
+		for _, id := range []string{"c6", "c7"} {
+			err = a.Add(pendingcommand.Command{Id: id, CorrelationId: "task", ResponseTopic: "d1/resp", Request: request, Expires: time.Now().Add(time.Minute)}, []string{"d1/resp"})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		err = b.Cancel("c6")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		command, found, err := b.Find("d1/resp", acceptAll, true)
+		if err != nil || !found || command.Id != "c7" {
+			t.Error(command, found, err)
+		}
+	})
+
+	t.Run("cancel and expiration", func(t *testing.T) {
+		err = a.Add(pendingcommand.Command{Id: "c4", CorrelationId: "task", ResponseTopic: "d1/resp", Request: request, Expires: time.Now().Add(time.Minute)}, []string{"d1/resp"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = b.Cancel("c4")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = a.Add(pendingcommand.Command{Id: "c5", CorrelationId: "task", ResponseTopic: "d1/resp", Request: request, Expires: time.Now().Add(-time.Second)}, []string{"d1/resp"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, found, err := b.Find("d1/resp", acceptAll, true)
+		if err != nil || found {
+			t.Error("canceled or expired command found", found, err)
+		}
+	})
+}
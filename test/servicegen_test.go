@@ -12,8 +12,8 @@ import (
 	devicerepoconfig "github.com/SENERGY-Platform/device-repository/lib/configuration"
 	"github.com/SENERGY-Platform/models/go/models"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/hooks"
 	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
-	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/vernemqtt"
 	platform_connector_lib "github.com/SENERGY-Platform/platform-connector-lib"
 	"github.com/SENERGY-Platform/platform-connector-lib/iot"
 )
@@ -82,7 +82,7 @@ func TestServiceGen(t *testing.T) {
 		Description:   "dt",
 		DeviceClassId: dc.Id,
 		Attributes: []models.Attribute{{
-			Key:   vernemqtt.GenerateServiceAttr,
+			Key:   hooks.GenerateServiceAttr,
 			Value: "true",
 		}},
 		Services: []models.Service{{
@@ -123,7 +123,7 @@ func TestServiceGen(t *testing.T) {
 	send := func(topic string, payload []byte) {
 		device, _, err := topicParser.Parse(client.InternalAdminToken, topic)
 		if errors.Is(err, ErrNoServiceMatchFound) {
-			vernemqtt.TryCreateService(config, &connector, device, topic, payload)
+			hooks.TryCreateService(config, &connector, device, topic, payload)
 			time.Sleep(100 * time.Millisecond)
 			return
 		}
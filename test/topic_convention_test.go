@@ -0,0 +1,114 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestConventions(t *testing.T) {
+	zigbee, ok := topic.GetConvention("zigbee2mqtt")
+	if !ok {
+		t.Error("missing zigbee2mqtt convention")
+		return
+	}
+	t.Run(testConventionParse(zigbee, "zigbee2mqtt/lamp", []topic.Reference{{LocalDeviceId: "lamp", LocalServiceId: "state"}}))
+	t.Run(testConventionParse(zigbee, "zigbee2mqtt/kitchen/lamp/availability", []topic.Reference{
+		{LocalDeviceId: "kitchen", LocalServiceId: "lamp/availability"},
+		{LocalDeviceId: "kitchen/lamp", LocalServiceId: "availability"},
+		{LocalDeviceId: "kitchen/lamp/availability", LocalServiceId: "state"},
+	}))
+	t.Run(testConventionParse(zigbee, "zigbee2mqtt/bridge/state", nil))
+	t.Run(testConventionParse(zigbee, "tele/lamp/SENSOR", nil))
+	t.Run(testConventionCreate(zigbee, "kitchen/lamp", "set", "zigbee2mqtt/kitchen/lamp/set"))
+	t.Run(testConventionCreate(zigbee, "lamp", "set/brightness", "zigbee2mqtt/lamp/set/brightness"))
+
+	tasmota, ok := topic.GetConvention("tasmota")
+	if !ok {
+		t.Error("missing tasmota convention")
+		return
+	}
+	t.Run(testConventionParse(tasmota, "tele/plug1/SENSOR", []topic.Reference{{LocalDeviceId: "plug1", LocalServiceId: "tele/SENSOR"}}))
+	t.Run(testConventionParse(tasmota, "stat/plug1/POWER", []topic.Reference{{LocalDeviceId: "plug1", LocalServiceId: "stat/POWER"}}))
+	t.Run(testConventionParse(tasmota, "plug1/tele/SENSOR", nil))
+	t.Run(testConventionCreate(tasmota, "plug1", "cmnd/POWER", "cmnd/plug1/POWER"))
+	t.Run(testConventionCreate(tasmota, "plug1", "POWER", "cmnd/plug1/POWER"))
+}
+
+func TestCreateForDeviceWithConvention(t *testing.T) {
+	topics := topic.New(nil, "{{.DeviceId}}/cmnd/{{.LocalServiceId}}")
+	t.Run(testCreateForDevice(topics, "zigbee2mqtt", "set", "owner1/zigbee2mqtt/lamp/set"))
+	t.Run(testCreateForDevice(topics, "tasmota", "POWER", "owner1/cmnd/lamp/POWER"))
+	t.Run(testCreateForDevice(topics, "", "set", longDeviceIdExample+"/cmnd/set"))
+}
+
+func TestMatchesConvention(t *testing.T) {
+	for mqttTopic, expected := range map[string]bool{
+		"zigbee2mqtt/lamp/set":        true,
+		"tele/plug1/SENSOR":           true,
+		"owner1/zigbee2mqtt/lamp/set": false,
+		"zigbee2mqtt/bridge/state":    false,
+		longDeviceIdExample + "/set":  false,
+	} {
+		if actual := topic.MatchesConvention(mqttTopic); actual != expected {
+			t.Error(mqttTopic, actual, expected)
+		}
+	}
+}
+
+func testConventionParse(convention topic.Convention, mqttTopic string, expected []topic.Reference) (string, func(t *testing.T)) {
+	return mqttTopic, func(t *testing.T) {
+		actual := convention.Parse(mqttTopic)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Error(actual, expected)
+		}
+	}
+}
+
+func testConventionCreate(convention topic.Convention, localDeviceId string, localServiceId string, expected string) (string, func(t *testing.T)) {
+	return localServiceId, func(t *testing.T) {
+		actual, err := convention.Create(localDeviceId, localServiceId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if actual != expected {
+			t.Error(actual, expected)
+		}
+	}
+}
+
+func testCreateForDevice(topics *topic.Topic, convention string, localServiceId string, expected string) (string, func(t *testing.T)) {
+	return convention, func(t *testing.T) {
+		device := model.Device{Id: longDeviceIdExample, LocalId: "lamp", OwnerId: "owner1"}
+		if convention != "" {
+			device.Attributes = []model.Attribute{{Key: topic.ConventionAttr, Value: convention}}
+		}
+		actual, err := topics.CreateForDevice("", device, localServiceId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if actual != expected {
+			t.Error(actual, expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	iotmock "github.com/SENERGY-Platform/mqtt-platform-connector/test/server/mock/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+func TestExplain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deviceManagerUrl, deviceRepoUrl, err := iotmock.MockWithoutKafka(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	iotRepo := iot.New(deviceManagerUrl, deviceRepoUrl, "", slog.Default())
+	iotCache, err := iot.NewCache(iotRepo, 60, 60, 60, 2, 200*time.Millisecond)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	topics := topic.New(iotCache, "")
+
+	t.Run("create device type", testCreateDeviceType(deviceManagerUrl, model.DeviceType{
+		Id:   "dt1",
+		Name: "dt1",
+		Services: []model.Service{
+			{Id: "s1", LocalId: "poweron"},
+			{Id: "s2", LocalId: "void/poweron"},
+		},
+	}))
+
+	t.Run("create device", testCreateType(deviceManagerUrl, model.Device{
+		Id:           longDeviceIdExample,
+		DeviceTypeId: "dt1",
+	}))
+
+	t.Run("match", func(t *testing.T) {
+		result := topics.Explain("", shortDeviceIdExample+"/void/poweron")
+		if result.Convention != nil || result.Pattern != nil || result.LocalIdLookup != nil {
+			t.Errorf("%#v", result)
+		}
+		if len(result.IdCandidates) != 1 || result.IdCandidates[0].Id != longDeviceIdExample || result.IdCandidates[0].ShortId != shortDeviceIdExample || !result.IdCandidates[0].Found {
+			t.Errorf("%#v", result.IdCandidates)
+		}
+		if len(result.Candidates) != 1 || result.Candidates[0].Rank != 0 || len(result.Candidates[0].MatchedServices) != 2 || result.Candidates[0].MatchedServices[0].LocalId != "void/poweron" {
+			t.Errorf("%#v", result.Candidates)
+		}
+		if result.Result.Error != "" || result.Result.Device == nil || result.Result.Device.Id != longDeviceIdExample || result.Result.Service == nil || result.Result.Service.LocalId != "void/poweron" {
+			t.Errorf("%#v", result.Result)
+		}
+	})
+
+	t.Run("unknown device", func(t *testing.T) {
+		result := topics.Explain("", "cmd/foo/bar")
+		if len(result.IdCandidates) != 0 || result.LocalIdLookup == nil || len(result.LocalIdLookup.LocalIds) != 3 || len(result.LocalIdLookup.Devices) != 0 {
+			t.Errorf("%#v", result)
+		}
+		if result.Result.Error != topic.ErrNoDeviceMatchFound.Error() {
+			t.Errorf("%#v", result.Result)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/helper"
+	iotmock "github.com/SENERGY-Platform/mqtt-platform-connector/test/server/mock/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+const hubIdExample = "urn:infai:ses:hub:0d6c1f4e-8b3a-4f2e-9c1d-5a7b3e2f1c0a"
+const shortHubIdExample = "DWwfTos6Ty6cHVp7Pi8cCg"
+const deviceTypeIdExample = "urn:infai:ses:device-type:5b2e7c1a-3d4f-4e8b-9a6c-1f0e2d3c4b5a"
+const shortDeviceTypeIdExample = "Wy58Gj1PTouabB8OLTxLWg"
+const serviceIdExample = "urn:infai:ses:service:7e1d2c3b-4a5f-4b6e-8d7c-9f0a1b2c3d4e"
+const shortServiceIdExample = "fh0sO0pfS26NfJ8KGyw9Tg"
+
+func TestCreateForService(t *testing.T) {
+	topics := topic.New(nil, "{{.DeviceId}}/cmnd/{{.LocalServiceId}}")
+	device := model.Device{Id: longDeviceIdExample, LocalId: "lamp", DeviceTypeId: deviceTypeIdExample}
+
+	t.Run(testTopicCreateForService(topics, device, "{{.LocalDeviceId}}/{{.ShortServiceId}}", longDeviceIdExample+"/lamp/"+shortServiceIdExample))
+	t.Run(testTopicCreateForService(topics, device, "{{.ShortDeviceTypeId}}/{{.ServiceId}}", longDeviceIdExample+"/"+shortDeviceTypeIdExample+"/"+serviceIdExample))
+	t.Run(testTopicCreateForService(topics, device, "{{.DeviceTypeId}}/set", longDeviceIdExample+"/"+deviceTypeIdExample+"/set"))
+	t.Run(testTopicCreateForService(topics, device, "set", longDeviceIdExample+"/cmnd/set"))
+
+	//the hub of the device is unknown
+	_, err := topics.CreateForService("", device, model.Service{Id: serviceIdExample, LocalId: "{{.ShortHubId}}/set"})
+	if err == nil {
+		t.Error("missing error for unknown hub placeholder")
+	}
+}
+
+func TestHubTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deviceManagerUrl, deviceRepoUrl, err := iotmock.MockWithoutKafka(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	iotRepo := iot.New(deviceManagerUrl, deviceRepoUrl, "", slog.Default())
+	iotCache, err := iot.NewCache(iotRepo, 60, 60, 60, 2, 200*time.Millisecond)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	topics := topic.NewFromConfig(iotCache, configuration.Config{
+		DeviceRepoUrl:        deviceRepoUrl,
+		ActuatorTopicPattern: "{{.DeviceId}}/cmnd/{{.LocalServiceId}}",
+		EventTopicPatterns:   []string{"hubs/{{.ShortHubId}}/{{.LocalDeviceId}}/{{.LocalServiceId}}"},
+	}, nil)
+
+	t.Run("create device type", testCreateDeviceType(deviceManagerUrl, model.DeviceType{
+		Id:   "dt1",
+		Name: "dt1",
+		Services: []model.Service{
+			{Id: "s1", LocalId: "state"},
+		},
+	}))
+
+	t.Run("create device", testCreateType(deviceManagerUrl, model.Device{
+		Id:           longDeviceIdExample,
+		LocalId:      "lamp",
+		DeviceTypeId: "dt1",
+	}))
+
+	t.Run("create hub", testCreateHub(deviceManagerUrl, model.Hub{
+		Id:             hubIdExample,
+		Name:           "gateway",
+		DeviceLocalIds: []string{"lamp"},
+		DeviceIds:      []string{longDeviceIdExample},
+	}))
+
+	//heuristics: short hub id and local device id
+	t.Run(testTopicParse(topics, shortHubIdExample+"/lamp/state", longDeviceIdExample, "state"))
+	//event topic pattern
+	t.Run(testTopicParse(topics, "hubs/"+shortHubIdExample+"/lamp/state", longDeviceIdExample, "state"))
+	t.Run(testTopicParserExpectError(topics, "hubs/"+shortHubIdExample+"/unknown/state", topic.ErrNoDeviceMatchFound))
+
+	//the hub of the device is known after the parse
+	device := model.Device{Id: longDeviceIdExample, LocalId: "lamp", DeviceTypeId: "dt1"}
+	t.Run(testTopicCreateForService(topics, device, "{{.ShortHubId}}/{{.LocalDeviceId}}/set", longDeviceIdExample+"/"+shortHubIdExample+"/lamp/set"))
+	t.Run(testTopicCreateForService(topics, device, "{{.HubLocalId}}/{{.LocalDeviceId}}/set", longDeviceIdExample+"/gateway/lamp/set"))
+}
+
+func testTopicCreateForService(topics *topic.Topic, device model.Device, localServiceId string, expectedTopic string) (string, func(t *testing.T)) {
+	return "create " + localServiceId, func(t *testing.T) {
+		actual, err := topics.CreateForService("", device, model.Service{Id: serviceIdExample, LocalId: localServiceId})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if actual != expectedTopic {
+			t.Error(actual, expectedTopic)
+		}
+	}
+}
+
+func testCreateHub(deviceManagerUrl string, hub model.Hub) func(t *testing.T) {
+	return func(t *testing.T) {
+		b := new(bytes.Buffer)
+		err := json.NewEncoder(b).Encode(hub)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		client := http.Client{
+			Timeout: 5 * time.Second,
+		}
+		req, err := http.NewRequest("PUT", deviceManagerUrl+"/hubs/"+url.PathEscape(hub.Id), b)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", string(helper.AdminJwt))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Error(errors.New(resp.Status))
+		}
+	}
+}
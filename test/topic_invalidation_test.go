@@ -0,0 +1,134 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/test/server/docker"
+	"github.com/segmentio/kafka-go"
+)
+
+// TestTopicInvalidation checks that changes of all partitions are received and that no consumer group is created
+func TestTopicInvalidation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	kafkaUrl, err := docker.Kafka(ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	conn, err := kafka.Dial("tcp", kafkaUrl)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	err = conn.CreateTopics(kafka.TopicConfig{Topic: "device-types", NumPartitions: 2, ReplicationFactor: 1}, kafka.TopicConfig{Topic: "devices", NumPartitions: 2, ReplicationFactor: 1})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	invalidation := &testTopicInvalidation{}
+	err = lib.StartTopicInvalidation(ctx, configuration.Config{
+		KafkaUrl:             kafkaUrl,
+		KafkaConsumerMaxWait: "100ms",
+		DeviceTypeTopic:      "device-types",
+		DeviceTopic:          "devices",
+	}, invalidation)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	writer := &kafka.Writer{Addr: kafka.TCP(kafkaUrl), Balancer: &kafka.Hash{}}
+	defer writer.Close()
+	err = writer.WriteMessages(ctx,
+		kafka.Message{Topic: "device-types", Key: []byte("dt1"), Value: []byte(`{"command": "PUT", "id": "dt1"}`)},
+		kafka.Message{Topic: "device-types", Key: []byte("dt2"), Value: []byte(`{"command": "DELETE", "id": "dt2"}`)},
+		kafka.Message{Topic: "devices", Key: []byte("d1"), Value: []byte(`{"command": "PUT", "id": "d1"}`)},
+		kafka.Message{Topic: "devices", Key: []byte("d2"), Value: []byte(`{"command": "DELETE", "id": "d2"}`)},
+		kafka.Message{Topic: "devices", Key: []byte("d3"), Value: []byte(`invalid`)},
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(2 * time.Second)
+
+	//the order of messages in different partitions is undefined
+	expected := []string{"invalidate device d1", "invalidate device-type dt1", "remove device d2", "remove device-type dt2"}
+	invalidation.mux.Lock()
+	actual := slices.Sorted(slices.Values(invalidation.calls))
+	invalidation.mux.Unlock()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual)
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(kafkaUrl)}
+	groups, err := client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(groups.Groups) != 0 {
+		t.Error("unexpected consumer groups", groups.Groups)
+	}
+}
+
+type testTopicInvalidation struct {
+	mux   sync.Mutex
+	calls []string
+}
+
+func (this *testTopicInvalidation) add(call string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.calls = append(this.calls, call)
+}
+
+func (this *testTopicInvalidation) InvalidateDeviceType(deviceTypeId string) {
+	this.add("invalidate device-type " + deviceTypeId)
+}
+
+func (this *testTopicInvalidation) RemoveDeviceType(deviceTypeId string) {
+	this.add("remove device-type " + deviceTypeId)
+}
+
+func (this *testTopicInvalidation) InvalidateDevice(deviceId string) {
+	this.add("invalidate device " + deviceId)
+}
+
+func (this *testTopicInvalidation) RemoveDevice(deviceId string) {
+	this.add("remove device " + deviceId)
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/configuration"
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/topic"
+	iotmock "github.com/SENERGY-Platform/mqtt-platform-connector/test/server/mock/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/iot"
+	"github.com/SENERGY-Platform/platform-connector-lib/model"
+)
+
+const patternDeviceId = "urn:infai:ses:device:2f6c1b0e-4a53-4d6e-9a35-8f7e1c2d3b4a"
+const patternShortDeviceId = "L2wbDkpTTW6aNY9-HC07Sg"
+
+func TestEventTopicPattern(t *testing.T) {
+	t.Run(testEventTopicPatternMatch("devices/{{.LocalDeviceId}}/{{.LocalServiceId}}", "devices/lamp/state/power", map[string]string{"LocalDeviceId": "lamp", "LocalServiceId": "state/power"}))
+	t.Run(testEventTopicPatternMatch("{{.ShortDeviceId}}/+/{{.LocalServiceId}}", shortDeviceIdExample+"/foo/temperature", map[string]string{"ShortDeviceId": shortDeviceIdExample, "LocalServiceId": "temperature"}))
+	t.Run(testEventTopicPatternMatch("{{.DeviceId}}/{{.LocalServiceId}}/#", longDeviceIdExample+"/temperature/foo/bar", map[string]string{"DeviceId": longDeviceIdExample, "LocalServiceId": "temperature"}))
+	t.Run(testEventTopicPatternMatch("devices/{{.LocalDeviceId}}/{{.LocalServiceId}}", "prefix/devices/lamp/state", nil))
+	t.Run(testEventTopicPatternMatch("{{.ShortDeviceId}}/+/{{.LocalServiceId}}", shortDeviceIdExample+"/temperature", nil))
+	t.Run(testEventTopicPatternMatch("{{.DeviceId}}/{{.LocalServiceId}}", "foo/temperature", nil))
+
+	t.Run(testEventTopicPatternError("devices/{{.LocalDeviceId}}"))
+	t.Run(testEventTopicPatternError("devices/{{.Foo}}/{{.LocalServiceId}}"))
+	t.Run(testEventTopicPatternError("devices/#/{{.LocalServiceId}}"))
+	t.Run(testEventTopicPatternError("devices+/{{.LocalServiceId}}"))
+	t.Run(testEventTopicPatternError("+{{.LocalDeviceId}}/{{.LocalServiceId}}"))
+	t.Run(testEventTopicPatternError("{{.LocalServiceId}}/{{.LocalServiceId}}"))
+
+	_, err := topic.CompileEventTopicPatterns([]string{"static/{{.LocalServiceId}}"})
+	if !errors.Is(err, topic.ErrInvalidEventTopicPattern) {
+		t.Error("global patterns without device reference should be rejected", err)
+	}
+}
+
+func TestParseWithEventTopicPatterns(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deviceManagerUrl, deviceRepoUrl, err := iotmock.MockWithoutKafka(ctx)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	iotRepo := iot.New(deviceManagerUrl, deviceRepoUrl, "", slog.Default())
+	iotCache, err := iot.NewCache(iotRepo, 60, 60, 60, 2, 200*time.Millisecond)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	topics := topic.NewFromConfig(iotCache, configuration.Config{EventTopicPatterns: []string{"devices/{{.LocalDeviceId}}/{{.LocalServiceId}}"}}, nil)
+
+	t.Run("create device type", testCreateDeviceType(deviceManagerUrl, model.DeviceType{
+		Id:   "dt1",
+		Name: "dt1",
+		Services: []model.Service{
+			{Id: "s1", LocalId: "state"},
+			{Id: "s2", LocalId: "state/power"},
+			{Id: "s3", LocalId: "power"},
+		},
+	}))
+
+	t.Run("create device type with pattern", testCreateDeviceType(deviceManagerUrl, model.DeviceType{
+		Id:         "dt2",
+		Name:       "dt2",
+		Attributes: []models.Attribute{{Key: topic.EventTopicPatternAttr, Value: "{{.ShortDeviceId}}/+/{{.LocalServiceId}}"}},
+		Services: []model.Service{
+			{Id: "s4", LocalId: "power"},
+			{Id: "s5", LocalId: "meta"},
+		},
+	}))
+
+	t.Run("create device", testCreateType(deviceManagerUrl, model.Device{
+		Id:           longDeviceIdExample,
+		LocalId:      "lamp",
+		DeviceTypeId: "dt1",
+	}))
+
+	t.Run("create device 2", testCreateType(deviceManagerUrl, model.Device{
+		Id:           patternDeviceId,
+		LocalId:      "plug",
+		DeviceTypeId: "dt2",
+	}))
+
+	//services are matched by their complete local id
+	t.Run(testTopicParse(topics, "devices/lamp/state", longDeviceIdExample, "state"))
+	t.Run(testTopicParse(topics, "devices/lamp/state/power", longDeviceIdExample, "state/power"))
+	t.Run(testTopicParserExpectError(topics, "devices/lamp/unknown", topic.ErrNoServiceMatchFound))
+
+	//device-type pattern: "meta" would be matched as service by the heuristics
+	t.Run(testTopicParse(topics, patternShortDeviceId+"/meta/power", patternDeviceId, "power"))
+}
+
+func testEventTopicPatternMatch(pattern string, mqttTopic string, expected map[string]string) (string, func(t *testing.T)) {
+	return pattern + " " + mqttTopic, func(t *testing.T) {
+		compiled, err := topic.CompileEventTopicPattern(pattern)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		values, ok := compiled.Match(mqttTopic)
+		if ok != (expected != nil) {
+			t.Error(ok, values)
+			return
+		}
+		if ok && !reflect.DeepEqual(values, expected) {
+			t.Error(values, expected)
+		}
+	}
+}
+
+func testEventTopicPatternError(pattern string) (string, func(t *testing.T)) {
+	return pattern, func(t *testing.T) {
+		_, err := topic.CompileEventTopicPattern(pattern)
+		if !errors.Is(err, topic.ErrInvalidEventTopicPattern) {
+			t.Error(err)
+		}
+	}
+}
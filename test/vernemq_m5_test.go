@@ -0,0 +1,59 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/SENERGY-Platform/mqtt-platform-connector/lib/webhooks/vernemqtt"
+)
+
+func TestVernemqM5Properties(t *testing.T) {
+	expected := vernemqtt.UserProperties{{Key: "foo", Value: "bar"}}
+	for _, properties := range []string{
+		`{"p_user_property": [["foo", "bar"]], "p_session_expiry_interval": 0}`,
+		`{"p_user_property": [{"k": "foo", "v": "bar"}]}`,
+		`{"p_user_property": {"foo": "bar"}}`,
+	} {
+		t.Run(properties, func(t *testing.T) {
+			msg := vernemqtt.PublishM5WebhookMsg{}
+			err := json.Unmarshal([]byte(`{"topic":"foo","properties":`+properties+`}`), &msg)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !reflect.DeepEqual(msg.Properties.UserProperties, expected) {
+				t.Error(msg.Properties.UserProperties)
+			}
+			if !msg.Properties.CleanSession() {
+				t.Error("missing or zero session expiry should be a clean session")
+			}
+		})
+	}
+
+	msg := vernemqtt.LoginM5WebhookMsg{}
+	err := json.Unmarshal([]byte(`{"clean_start":true,"properties":{"p_session_expiry_interval":3600}}`), &msg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if msg.Properties.CleanSession() {
+		t.Error("session expiry > 0 should not be a clean session")
+	}
+}